@@ -1,20 +1,36 @@
 package main
 
 import (
+	"advanced-blog-management-system/internal/cache"
 	"advanced-blog-management-system/internal/handler"
+	"advanced-blog-management-system/internal/live"
 	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/metrics"
 	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/outbox"
+	"advanced-blog-management-system/internal/pagination"
+	"advanced-blog-management-system/internal/pubsub"
 	"advanced-blog-management-system/internal/repository"
 	"advanced-blog-management-system/internal/service"
+	"advanced-blog-management-system/internal/spam"
+	"advanced-blog-management-system/internal/tracing"
+	"advanced-blog-management-system/internal/trash"
+	"advanced-blog-management-system/internal/webhook"
 	"advanced-blog-management-system/pkg/auth"
 	"advanced-blog-management-system/pkg/database"
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -39,19 +55,35 @@ func main() {
 
 	cfg := loadConfig()
 
-	db, err := database.NewPostgresDB(database.Config{
+	appLogger := logger.New(os.Stdout, cfg.AppEnv, cfg.LogLevel)
+	slog.SetDefault(appLogger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: cfg.ServiceName,
+		Exporter:    cfg.TraceExporter,
+		Endpoint:    cfg.TraceEndpoint,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
+	dbConfig := database.Config{
 		Host:     cfg.DBHost,
 		Port:     cfg.DBPort,
 		User:     cfg.DBUser,
 		Password: cfg.DBPassword,
 		DBName:   cfg.DBName,
 		SSLMode:  cfg.DBSSLMode,
-	})
+	}
+	db, err := database.NewPostgresDB(dbConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer database.Close(db)
 
+	metrics.Register(database.NewStatsCollector(db, cfg.DBName))
+
 	log.Println("Running database migrations...")
 	if err := database.Migrate(db); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
@@ -63,47 +95,294 @@ func main() {
 	userRepo := repository.NewUserRepo(db)
 	postRepo := repository.NewPostRepo(db)
 	commentRepo := repository.NewCommentRepo(db)
+	auditRepo := repository.NewAuditRepo(db)
+	outboxRepo := repository.NewOutboxRepo(db)
+	webhookRepo := repository.NewWebhookRepo(db)
+	trashRepo := repository.NewTrashRepo(db)
+	notificationRepo := repository.NewNotificationRepo(db)
+	activityRepo := repository.NewActivityRepo(db)
+	streamRepo := repository.NewStreamRepo(db)
+	txManager := repository.NewTxManager(db)
+
+	syncPolicy, err := logger.ParseSyncPolicy(cfg.EventFsyncPolicy)
+	if err != nil {
+		log.Fatalf("Invalid EVENT_FSYNC: %v", err)
+	}
 
-	userService := service.NewUserService(userRepo, jwtManager)
-	postService := service.NewPostService(postRepo, userRepo)
-	commentService := service.NewCommentService(commentRepo, postRepo)
+	sinks, err := buildEventSinks(cfg, db, syncPolicy)
+	if err != nil {
+		log.Fatalf("Failed to configure event sinks: %v", err)
+	}
+	// Вебхуки получают события через outbox: приемник только ставит доставки в очередь
+	sinks = append(sinks, webhook.NewSink(webhookRepo))
+
+	relay := outbox.NewRelay(outboxRepo, txManager, outbox.RelayConfig{
+		PollInterval: time.Duration(cfg.OutboxPollIntervalMS) * time.Millisecond,
+		BatchSize:    cfg.EventBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		BaseBackoff:  time.Duration(cfg.OutboxBaseBackoffMS) * time.Millisecond,
+		MaxBackoff:   time.Duration(cfg.OutboxMaxBackoffSeconds) * time.Second,
+		SinkTimeout:  time.Duration(cfg.EventSinkTimeoutMS) * time.Millisecond,
+	}, sinks...)
+	relay.Start()
+
+	dispatcher := webhook.NewDispatcher(webhookRepo, webhook.DispatcherConfig{
+		PollInterval: time.Duration(cfg.WebhookPollIntervalMS) * time.Millisecond,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		BaseBackoff:  time.Duration(cfg.WebhookBaseBackoffSeconds) * time.Second,
+		MaxBackoff:   time.Duration(cfg.WebhookMaxBackoffMinutes) * time.Minute,
+		Timeout:      time.Duration(cfg.WebhookTimeoutSeconds) * time.Second,
+	})
+	dispatcher.Start()
 
-	eventLogger := logger.NewEventLogger("logs.txt")
-	eventLogger.Start()
+	// Записи из корзины удаляются окончательно по истечении срока хранения
+	purger := trash.NewPurger(trashRepo, trash.Config{
+		Interval:  time.Duration(cfg.TrashPurgeIntervalMinutes) * time.Minute,
+		Retention: time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour,
+	})
+	purger.Start()
+
+	// Выделенное соединение LISTEN получает сообщения брокера и инвалидации кеша от других реплик
+	notifier := database.NewNotifier(db)
+	dbListener := database.NewListener(database.GetDSN(dbConfig), database.ListenerConfig{})
+	listening := false
+
+	// Брокер рассылает новые посты и комментарии подписчикам SSE и WebSocket после фиксации транзакции.
+	// В режиме postgres сообщения через LISTEN/NOTIFY доходят до подписчиков всех реплик.
+	// События потоков нумеруются в журнале stream_events, по которому клиенты догружают пропущенное
+	hub := pubsub.NewHub(cfg.StreamBufferSize)
+	var broker pubsub.Broker = hub
+	switch cfg.StreamBroker {
+	case "memory":
+	case "postgres":
+		pgBroker := pubsub.NewPostgresBroker(hub, notifier, streamRepo)
+		dbListener.Handle(pubsub.NotifyChannel, pgBroker.Receive)
+		broker = pgBroker
+		listening = true
+	default:
+		log.Fatalf("Invalid STREAM_BROKER %q: expected memory or postgres", cfg.StreamBroker)
+	}
+
+	// Кеш чтения постов и комментариев; CACHE_TTL_MINUTES=0 отключает его.
+	// Записи сбрасываются после фиксации транзакции на этой и на остальных репликах
+	var posts repository.PostRepository = postRepo
+	var comments repository.CommentRepository = commentRepo
+	if cfg.CacheTTLMinutes > 0 {
+		store := cache.NewLRU(cfg.CacheMaxEntries, time.Duration(cfg.CacheTTLMinutes)*time.Minute)
+		postLoader := cache.NewLoader("posts", store)
+		commentLoader := cache.NewLoader("comments", store)
+
+		invalidation := cache.NewInvalidation(notifier)
+		invalidation.Register(postLoader)
+		invalidation.Register(commentLoader)
+		dbListener.Handle(cache.InvalidateChannel, invalidation.Receive)
+		dbListener.OnReconnect(invalidation.Reset)
+		listening = true
+
+		posts = repository.NewCachedPostRepo(postRepo, postLoader)
+		comments = repository.NewCachedCommentRepo(commentRepo, commentLoader)
+	}
+
+	if listening {
+		if err := dbListener.Start(); err != nil {
+			log.Fatalf("Failed to start database listener: %v", err)
+		}
+	}
+
+	switch cfg.CommentModeration {
+	case model.ModerationOff, model.ModerationAll:
+	default:
+		log.Fatalf("Invalid COMMENT_MODERATION %q: expected off or all", cfg.CommentModeration)
+	}
+
+	// Фильтр спама для новых постов и комментариев. Встроенные словари дополняются
+	// списком из SPAM_WORDS_FILE
+	bannedWords := spam.DefaultWords()
+	if cfg.SpamWordsFile != "" {
+		words, err := spam.ReadWordsFile(cfg.SpamWordsFile)
+		if err != nil {
+			log.Fatalf("Failed to load SPAM_WORDS_FILE: %v", err)
+		}
+		bannedWords = append(bannedWords, words...)
+	}
+	spamFilter := spam.New(userRepo, spam.Config{
+		Enabled:       cfg.SpamFilterEnabled,
+		ModerateScore: cfg.SpamModerateScore,
+		RejectScore:   cfg.SpamRejectScore,
+	},
+		spam.NewBannedWords(bannedWords),
+		spam.NewLinkLimit(map[spam.Kind]int{
+			spam.KindComment: cfg.SpamCommentMaxLinks,
+			spam.KindPost:    cfg.SpamPostMaxLinks,
+		}),
+		spam.NewDuplicates(activityRepo, time.Duration(cfg.SpamDuplicateWindowHours)*time.Hour),
+		spam.NewNewAccount(activityRepo, time.Duration(cfg.SpamNewAccountHours)*time.Hour, cfg.SpamNewAccountHourlyLimit),
+	)
+
+	stream := service.NewStream(streamRepo, broker)
+
+	userService := service.NewUserService(userRepo, posts, comments, webhookRepo, jwtManager, txManager, outboxRepo)
+	postService := service.NewPostService(posts, userRepo, txManager, outboxRepo, stream, spamFilter)
+	commentService := service.NewCommentService(comments, posts, userRepo, txManager, outboxRepo, stream, service.ModerationConfig{
+		Mode:         cfg.CommentModeration,
+		TrustedAfter: cfg.CommentTrustedAfter,
+	}, spamFilter)
+	moderationService := service.NewModerationService(comments, userRepo, notificationRepo, txManager, outboxRepo, stream)
+	notificationService := service.NewNotificationService(notificationRepo)
+	auditService := service.NewAuditService(auditRepo)
+	outboxService := service.NewOutboxService(outboxRepo)
+	webhookService := service.NewWebhookService(webhookRepo)
 
 	authHandler := handler.NewAuthHandler(userService)
-	postHandler := handler.NewPostHandler(postService, eventLogger)
-	commentHandler := handler.NewCommentHandler(commentService, eventLogger)
+	cursorSecret := cfg.CursorSecret
+	if cursorSecret == "" {
+		cursorSecret = cfg.JWTSecret
+	}
+	cursors := pagination.NewCodec(cursorSecret)
+
+	postHandler := handler.NewPostHandler(postService, cursors)
+	commentHandler := handler.NewCommentHandler(commentService, cursors)
+	adminHandler := handler.NewAdminHandler(auditService, userService, outboxService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	moderationHandler := handler.NewModerationHandler(moderationService, notificationService)
+	streamHandler := handler.NewStreamHandler(broker, postService, commentService, time.Duration(cfg.StreamHeartbeatSeconds)*time.Second)
+
+	liveServer := live.NewServer(broker, postService, commentService, live.Config{
+		SendBuffer:      cfg.WSSendBuffer,
+		MaxMessageBytes: cfg.WSMaxMessageBytes,
+		AllowedOrigins:  splitList(cfg.WSAllowedOrigins),
+	})
+	if err := liveServer.Start(); err != nil {
+		log.Fatalf("Failed to start live channel: %v", err)
+	}
+
+	healthHandler := handler.NewHealthHandler(time.Duration(cfg.HealthCheckTimeoutSeconds) * time.Second)
+	relayCheck := func(ctx context.Context) error {
+		if !relay.Running() {
+			return errors.New("outbox relay is not running")
+		}
+		return nil
+	}
+	healthHandler.AddLivenessCheck("outbox_relay", relayCheck)
+	healthHandler.AddLivenessCheck("webhook_dispatcher", func(ctx context.Context) error {
+		if !dispatcher.Running() {
+			return errors.New("webhook dispatcher is not running")
+		}
+		return nil
+	})
+	healthHandler.AddLivenessCheck("trash_purger", func(ctx context.Context) error {
+		if !purger.Running() {
+			return errors.New("trash purger is not running")
+		}
+		return nil
+	})
+	healthHandler.AddReadinessCheck("database", func(ctx context.Context) error {
+		return database.TestConnection(ctx, db)
+	})
+	healthHandler.AddReadinessCheck("migrations", func(ctx context.Context) error {
+		pending, err := database.PendingMigrations(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations: %v", len(pending), pending)
+		}
+		return nil
+	})
+	healthHandler.AddReadinessCheck("outbox_relay", relayCheck)
+	if listening {
+		healthHandler.AddReadinessCheck("database_listener", func(ctx context.Context) error {
+			if !dbListener.Connected() {
+				return errors.New("database listener is disconnected")
+			}
+			return nil
+		})
+	}
 
-	loggingMiddleware := middleware.NewLoggingMiddleware(log.New(os.Stdout, "", log.LstdFlags))
-	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
+	loggingMiddleware := middleware.NewLoggingMiddleware(appLogger)
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager, userRepo)
 
 	router := chi.NewRouter()
 
-	router.Use(loggingMiddleware.Recovery)
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Tracing)
 	router.Use(loggingMiddleware.Logger)
+	router.Use(middleware.Metrics)
+	router.Use(loggingMiddleware.Recovery)
 	router.Use(loggingMiddleware.CORS)
 
+	router.Handle("/metrics", metrics.Handler())
+
 	router.Post("/api/register", authHandler.Register)
 	router.Post("/api/login", authHandler.Login)
-	router.Get("/api/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
-	})
+	router.Get("/livez", healthHandler.Live)
+	router.Get("/readyz", healthHandler.Ready)
+	router.Get("/api/health", healthHandler.Ready)
 
 	apiRouter := chi.NewRouter()
 
 	apiRouter.Group(func(r chi.Router) {
+		// Токен необязателен: автор и модераторы видят свои посты, ожидающие модерации
+		r.Use(middleware.ToMiddleware(authMiddleware.OptionalAuth))
 		r.Get("/posts", postHandler.GetAll)
+		r.Get("/posts/stream", streamHandler.Posts)
 		r.Get("/posts/{id}", postHandler.GetByID)
+		r.Get("/posts/{id}/revisions", postHandler.ListRevisions)
+		r.Get("/posts/{id}/revisions/diff", postHandler.DiffRevisions)
+		r.Get("/posts/{id}/revisions/{rev}", postHandler.GetRevision)
 		r.Get("/posts/{postId}/comments", commentHandler.GetByPost)
+		r.Get("/posts/{postId}/comments/stream", streamHandler.Comments)
+		r.Get("/users/{authorID}/posts", postHandler.GetByAuthor)
 	})
 
 	apiRouter.Group(func(r chi.Router) {
 		r.Use(middleware.ToMiddleware(authMiddleware.RequireAuth))
 		r.Post("/posts", postHandler.Create)
+		r.Put("/posts/{id}", postHandler.Update)
+		r.Delete("/posts/{id}", postHandler.Delete)
+		r.Post("/posts/{id}/revisions/{rev}/restore", postHandler.RestoreRevision)
 		r.Post("/posts/{postId}/comments", commentHandler.Create)
+		r.Put("/posts/{postId}/comments/{id}", commentHandler.Update)
+		r.Delete("/posts/{postId}/comments/{id}", commentHandler.Delete)
+		r.Get("/trash/posts", postHandler.ListDeleted)
+		r.Post("/trash/posts/{id}/restore", postHandler.Restore)
+		r.Get("/trash/comments", commentHandler.ListDeleted)
+		r.Post("/trash/comments/{id}/restore", commentHandler.Restore)
+		r.Put("/posts/{id}/moderation", postHandler.SetCommentModeration)
+		r.Get("/moderation/comments", moderationHandler.ListComments)
+		r.Post("/moderation/comments/approve", moderationHandler.ApproveComments)
+		r.Post("/moderation/comments/reject", moderationHandler.RejectComments)
+		r.Get("/moderation/posts", postHandler.ListPendingPosts)
+		r.Post("/moderation/posts/{id}/approve", postHandler.ApprovePost)
+		r.Get("/notifications", moderationHandler.ListNotifications)
+		r.Post("/notifications/{id}/read", moderationHandler.MarkNotificationRead)
+	})
+
+	// Браузер не может передать заголовок Authorization при открытии WebSocket, поэтому токен
+	// принимается и в параметре access_token
+	apiRouter.With(
+		middleware.ToMiddleware(middleware.QueryToken),
+		middleware.ToMiddleware(authMiddleware.RequireAuth),
+	).Get("/live", liveServer.ServeHTTP)
+
+	apiRouter.Group(func(r chi.Router) {
+		r.Use(middleware.ToMiddleware(authMiddleware.RequireAuth))
+		r.Use(middleware.ToMiddleware(middleware.RequireRole(model.RoleAdmin)))
+		r.Get("/admin/audit", adminHandler.ListAudit)
+		r.Put("/admin/users/{id}/role", adminHandler.ChangeUserRole)
+		r.Delete("/admin/users/{id}", adminHandler.DeleteUser)
+		r.Get("/admin/trash/users", adminHandler.ListDeletedUsers)
+		r.Post("/admin/trash/users/{id}/restore", adminHandler.RestoreUser)
+		r.Get("/admin/outbox", adminHandler.ListOutbox)
+		r.Post("/admin/outbox/{id}/retry", adminHandler.RetryOutbox)
+
+		r.Post("/webhooks", webhookHandler.Create)
+		r.Get("/webhooks", webhookHandler.List)
+		r.Get("/webhooks/{id}", webhookHandler.GetByID)
+		r.Put("/webhooks/{id}", webhookHandler.Update)
+		r.Delete("/webhooks/{id}", webhookHandler.Delete)
+		r.Get("/webhooks/{id}/deliveries", webhookHandler.ListDeliveries)
+		r.Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", webhookHandler.Redeliver)
 	})
 
 	router.Mount("/api", apiRouter)
@@ -112,6 +391,9 @@ func main() {
 		Addr:    cfg.ServerHost + ":" + strconv.Itoa(cfg.ServerPort),
 		Handler: router,
 	}
+	// Shutdown не прерывает активные запросы, поэтому потоки SSE закрываются через брокер
+	server.RegisterOnShutdown(broker.Close)
+	server.RegisterOnShutdown(liveServer.Close)
 
 	go func() {
 		log.Printf("Server starting on %s", server.Addr)
@@ -125,7 +407,10 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
-	eventLogger.Stop()
+	// Readiness начинает отвечать 503 сразу, чтобы балансировщик перестал слать трафик,
+	// пока уже принятые запросы дорабатывают
+	healthHandler.SetShuttingDown()
+	time.Sleep(time.Duration(cfg.ShutdownDrainSeconds) * time.Second)
 
 	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancelShutdown()
@@ -134,9 +419,71 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Ретранслятор останавливается после сервера, чтобы доставить события последних запросов;
+	// недоставленные события останутся в outbox до следующего запуска
+	relay.Stop()
+	dispatcher.Stop()
+	purger.Stop()
+	dbListener.Stop()
+
+	if err := shutdownTracing(ctxShutdown); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+
 	log.Println("Server exited")
 }
 
+// buildEventSinks создает приемники журнала событий из списка EVENT_SINKS
+func buildEventSinks(cfg *Config, db *sql.DB, syncPolicy logger.SyncPolicy) ([]logger.Sink, error) {
+	var sinks []logger.Sink
+	hasPostgres := false
+	for _, name := range strings.Split(cfg.EventSinks, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+			continue
+		case "file":
+			sink, err := logger.NewFileSink(logger.FileSinkConfig{
+				Path:           cfg.EventLogFile,
+				MaxSizeBytes:   int64(cfg.EventFileMaxSizeMB) * 1024 * 1024,
+				RotateInterval: time.Duration(cfg.EventFileRotateHours) * time.Hour,
+				MaxBackups:     cfg.EventFileMaxBackups,
+				MaxAge:         time.Duration(cfg.EventFileMaxAgeDays) * 24 * time.Hour,
+				Sync:           syncPolicy,
+				SyncInterval:   time.Duration(cfg.EventFsyncIntervalMS) * time.Millisecond,
+			})
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "stdout":
+			sinks = append(sinks, logger.NewStdoutSink())
+		case "postgres":
+			if !hasPostgres {
+				sinks = append(sinks, logger.NewPostgresSink(db))
+				hasPostgres = true
+			}
+		case "http":
+			if cfg.EventHTTPSinkURL == "" {
+				return nil, errors.New("EVENT_HTTP_SINK_URL is required for the http sink")
+			}
+			headers := map[string]string{}
+			if cfg.EventHTTPSinkToken != "" {
+				headers["Authorization"] = "Bearer " + cfg.EventHTTPSinkToken
+			}
+			sinks = append(sinks, logger.NewHTTPSink(cfg.EventHTTPSinkURL, headers,
+				time.Duration(cfg.EventSinkTimeoutMS)*time.Millisecond))
+		default:
+			return nil, fmt.Errorf("unknown event sink %q", name)
+		}
+	}
+
+	// Таблица audit_events - источник журнала аудита для администраторов, поэтому пишем в нее всегда
+	if !hasPostgres {
+		sinks = append(sinks, logger.NewPostgresSink(db))
+	}
+	return sinks, nil
+}
+
 func findProjectRoot() (string, error) {
 	currentDir, err := os.Getwd()
 	if err != nil {
@@ -171,6 +518,68 @@ type Config struct {
 	DBSSLMode      string
 	JWTSecret      string
 	JWTExpiryHours int
+	CursorSecret   string
+	AppEnv         string
+	LogLevel       string
+
+	EventLogFile         string
+	EventBatchSize       int
+	EventFsyncPolicy     string
+	EventFsyncIntervalMS int
+	EventSinks           string
+	EventSinkTimeoutMS   int
+	EventFileMaxSizeMB   int
+	EventFileRotateHours int
+	EventFileMaxBackups  int
+	EventFileMaxAgeDays  int
+	EventHTTPSinkURL     string
+	EventHTTPSinkToken   string
+
+	OutboxPollIntervalMS    int
+	OutboxMaxAttempts       int
+	OutboxBaseBackoffMS     int
+	OutboxMaxBackoffSeconds int
+
+	WebhookPollIntervalMS     int
+	WebhookMaxAttempts        int
+	WebhookBaseBackoffSeconds int
+	WebhookMaxBackoffMinutes  int
+	WebhookTimeoutSeconds     int
+
+	TrashRetentionDays        int
+	TrashPurgeIntervalMinutes int
+
+	CommentModeration   string
+	CommentTrustedAfter int
+
+	SpamFilterEnabled         bool
+	SpamModerateScore         int
+	SpamRejectScore           int
+	SpamWordsFile             string
+	SpamCommentMaxLinks       int
+	SpamPostMaxLinks          int
+	SpamDuplicateWindowHours  int
+	SpamNewAccountHours       int
+	SpamNewAccountHourlyLimit int
+
+	StreamBufferSize       int
+	StreamHeartbeatSeconds int
+	StreamBroker           string
+
+	CacheTTLMinutes int
+	CacheMaxEntries int
+
+	WSSendBuffer      int
+	WSMaxMessageBytes int
+	WSAllowedOrigins  string
+
+	HealthCheckTimeoutSeconds int
+	ShutdownDrainSeconds      int
+
+	ServiceName      string
+	TraceExporter    string
+	TraceEndpoint    string
+	TraceSampleRatio float64
 }
 
 func loadConfig() *Config {
@@ -185,9 +594,82 @@ func loadConfig() *Config {
 		DBSSLMode:      getEnv("DB_SSLMODE", "disable"),
 		JWTSecret:      getEnv("JWT_SECRET", "default-secret-key"),
 		JWTExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+		CursorSecret:   getEnv("CURSOR_SECRET", ""),
+		AppEnv:         getEnv("APP_ENV", "development"),
+		LogLevel:       getEnv("LOG_LEVEL", "info"),
+
+		EventLogFile:         getEnv("EVENT_LOG_FILE", "logs.txt"),
+		EventBatchSize:       getEnvAsInt("EVENT_BATCH_SIZE", 100),
+		EventFsyncPolicy:     getEnv("EVENT_FSYNC", "interval"),
+		EventFsyncIntervalMS: getEnvAsInt("EVENT_FSYNC_INTERVAL_MS", 1000),
+		EventSinks:           getEnv("EVENT_SINKS", "file"),
+		EventSinkTimeoutMS:   getEnvAsInt("EVENT_SINK_TIMEOUT_MS", 5000),
+		EventFileMaxSizeMB:   getEnvAsInt("EVENT_FILE_MAX_SIZE_MB", 100),
+		EventFileRotateHours: getEnvAsInt("EVENT_FILE_ROTATE_HOURS", 24),
+		EventFileMaxBackups:  getEnvAsInt("EVENT_FILE_MAX_BACKUPS", 7),
+		EventFileMaxAgeDays:  getEnvAsInt("EVENT_FILE_MAX_AGE_DAYS", 30),
+		EventHTTPSinkURL:     getEnv("EVENT_HTTP_SINK_URL", ""),
+		EventHTTPSinkToken:   getEnv("EVENT_HTTP_SINK_TOKEN", ""),
+
+		OutboxPollIntervalMS:    getEnvAsInt("OUTBOX_POLL_INTERVAL_MS", 1000),
+		OutboxMaxAttempts:       getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+		OutboxBaseBackoffMS:     getEnvAsInt("OUTBOX_BASE_BACKOFF_MS", 1000),
+		OutboxMaxBackoffSeconds: getEnvAsInt("OUTBOX_MAX_BACKOFF_SECONDS", 600),
+
+		WebhookPollIntervalMS:     getEnvAsInt("WEBHOOK_POLL_INTERVAL_MS", 1000),
+		WebhookMaxAttempts:        getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookBaseBackoffSeconds: getEnvAsInt("WEBHOOK_BASE_BACKOFF_SECONDS", 5),
+		WebhookMaxBackoffMinutes:  getEnvAsInt("WEBHOOK_MAX_BACKOFF_MINUTES", 60),
+		WebhookTimeoutSeconds:     getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+
+		TrashRetentionDays:        getEnvAsInt("TRASH_RETENTION_DAYS", 30),
+		TrashPurgeIntervalMinutes: getEnvAsInt("TRASH_PURGE_INTERVAL_MINUTES", 60),
+
+		CommentModeration:   getEnv("COMMENT_MODERATION", model.ModerationOff),
+		CommentTrustedAfter: getEnvAsInt("COMMENT_TRUSTED_AFTER", 3),
+
+		SpamFilterEnabled:         getEnvAsBool("SPAM_FILTER_ENABLED", true),
+		SpamModerateScore:         getEnvAsInt("SPAM_MODERATE_SCORE", 5),
+		SpamRejectScore:           getEnvAsInt("SPAM_REJECT_SCORE", 10),
+		SpamWordsFile:             getEnv("SPAM_WORDS_FILE", ""),
+		SpamCommentMaxLinks:       getEnvAsInt("SPAM_COMMENT_MAX_LINKS", 2),
+		SpamPostMaxLinks:          getEnvAsInt("SPAM_POST_MAX_LINKS", 10),
+		SpamDuplicateWindowHours:  getEnvAsInt("SPAM_DUPLICATE_WINDOW_HOURS", 24),
+		SpamNewAccountHours:       getEnvAsInt("SPAM_NEW_ACCOUNT_HOURS", 24),
+		SpamNewAccountHourlyLimit: getEnvAsInt("SPAM_NEW_ACCOUNT_HOURLY_LIMIT", 5),
+
+		StreamBufferSize:       getEnvAsInt("STREAM_BUFFER_SIZE", 64),
+		StreamHeartbeatSeconds: getEnvAsInt("STREAM_HEARTBEAT_SECONDS", 15),
+		StreamBroker:           getEnv("STREAM_BROKER", "postgres"),
+
+		CacheTTLMinutes: getEnvAsInt("CACHE_TTL_MINUTES", 5),
+		CacheMaxEntries: getEnvAsInt("CACHE_MAX_ENTRIES", 10000),
+
+		WSSendBuffer:      getEnvAsInt("WS_SEND_BUFFER", 64),
+		WSMaxMessageBytes: getEnvAsInt("WS_MAX_MESSAGE_BYTES", 16384),
+		WSAllowedOrigins:  getEnv("WS_ALLOWED_ORIGINS", ""),
+
+		HealthCheckTimeoutSeconds: getEnvAsInt("HEALTH_CHECK_TIMEOUT_SECONDS", 2),
+		ShutdownDrainSeconds:      getEnvAsInt("SHUTDOWN_DRAIN_SECONDS", 0),
+
+		ServiceName:      getEnv("OTEL_SERVICE_NAME", "blog-api"),
+		TraceExporter:    getEnv("TRACE_EXPORTER", "none"),
+		TraceEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TraceSampleRatio: getEnvAsFloat("TRACE_SAMPLE_RATIO", 1),
 	}
 }
 
+// splitList разбирает список значений через запятую, пропуская пустые
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnv(key, defaultValue string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -195,6 +677,24 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.Atoi(v); err == nil {
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU(2, time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected least recently used key b to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a to stay cached, got %v %v", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRU_ExpiresAfterTTL(t *testing.T) {
+	now := time.Now()
+	c := NewLRU(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected entry before TTL")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected entry to expire after TTL")
+	}
+}
+
+func TestLRU_DeletePrefix(t *testing.T) {
+	c := NewLRU(10, time.Minute)
+	c.Set("post:1:list", 1)
+	c.Set("post:1:count", 2)
+	c.Set("post:10:count", 3)
+
+	c.DeletePrefix("post:1:")
+
+	if c.Len() != 1 {
+		t.Errorf("expected only post:10 to remain, got %d entries", c.Len())
+	}
+	if _, ok := c.Get("post:10:count"); !ok {
+		t.Error("expected post:10:count to be kept")
+	}
+}
+
+func TestLoader_CollapsesConcurrentMisses(t *testing.T) {
+	l := NewLoader("posts", NewLRU(10, time.Minute))
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := l.Load(context.Background(), "count", func(ctx context.Context) (any, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			if err != nil || v != 42 {
+				t.Errorf("unexpected result %v, %v", v, err)
+			}
+		}()
+	}
+
+	// Let all goroutines reach the loader before the fetch completes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected a single fetch, got %d", calls.Load())
+	}
+	if _, err := l.Load(context.Background(), "count", func(ctx context.Context) (any, error) {
+		t.Error("expected cached value")
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader_DoesNotCacheErrorsOrStaleLoads(t *testing.T) {
+	l := NewLoader("posts", NewLRU(10, time.Minute))
+	ctx := context.Background()
+
+	if _, err := l.Load(ctx, "id:1", func(ctx context.Context) (any, error) {
+		return nil, errors.New("db down")
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Invalidation while the load is in flight: the old value must not be stored
+	l.Load(ctx, "id:1", func(ctx context.Context) (any, error) {
+		l.Invalidate([]string{"id:1"}, nil)
+		return "stale", nil
+	})
+
+	var calls int
+	l.Load(ctx, "id:1", func(ctx context.Context) (any, error) {
+		calls++
+		return "fresh", nil
+	})
+	if calls != 1 {
+		t.Errorf("expected stale load not to be cached, fetch calls = %d", calls)
+	}
+}
+
+type loopbackNotifier struct {
+	replicas []*Invalidation
+}
+
+func (n *loopbackNotifier) Notify(ctx context.Context, channel string, payload []byte) error {
+	for _, r := range n.replicas {
+		r.Receive(payload)
+	}
+	return nil
+}
+
+func TestInvalidation_ReachesOtherReplicas(t *testing.T) {
+	notifier := &loopbackNotifier{}
+	storeA, storeB := NewLRU(10, time.Minute), NewLRU(10, time.Minute)
+	loaderA, loaderB := NewLoader("comments", storeA), NewLoader("comments", storeB)
+	a, b := NewInvalidation(notifier), NewInvalidation(notifier)
+	a.Register(loaderA)
+	b.Register(loaderB)
+	notifier.replicas = []*Invalidation{a, b}
+
+	fetch := func(ctx context.Context) (any, error) { return 1, nil }
+	loaderA.Load(context.Background(), "post:1:count", fetch)
+	loaderB.Load(context.Background(), "post:1:count", fetch)
+	loaderB.Load(context.Background(), "post:2:count", fetch)
+
+	loaderA.Invalidate(nil, []string{"post:1:"})
+
+	if storeA.Len() != 0 {
+		t.Errorf("expected local entry to be removed, got %d", storeA.Len())
+	}
+	if _, ok := storeB.Get("comments:post:1:count"); ok {
+		t.Error("expected remote replica entry to be removed")
+	}
+	if _, ok := storeB.Get("comments:post:2:count"); !ok {
+		t.Error("expected unrelated entry on remote replica to be kept")
+	}
+
+	b.Reset()
+	if storeB.Len() != 0 {
+		t.Errorf("expected Reset to clear loaders, got %d entries", storeB.Len())
+	}
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// InvalidateChannel - канал Postgres LISTEN/NOTIFY для инвалидации кешей на других репликах
+const InvalidateChannel = "blog_cache_invalidate"
+
+// notifyTimeout - сколько ждать отправки NOTIFY
+const notifyTimeout = 2 * time.Second
+
+// Notifier отправляет сообщение всем репликам; реализуется database.Notifier
+type Notifier interface {
+	Notify(ctx context.Context, channel string, payload []byte) error
+}
+
+type invalidationMessage struct {
+	Origin   string   `json:"origin"`
+	Loader   string   `json:"loader"`
+	Keys     []string `json:"keys,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// Invalidation рассылает инвалидации зарегистрированных загрузчиков другим репликам.
+// Receive подключается к database.Listener на канале InvalidateChannel, а Reset -
+// к его OnReconnect: инвалидации, пропущенные во время обрыва, восстановить нельзя
+type Invalidation struct {
+	notifier Notifier
+	origin   string
+
+	mu      sync.Mutex
+	loaders map[string]*Loader
+}
+
+func NewInvalidation(notifier Notifier) *Invalidation {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return &Invalidation{
+		notifier: notifier,
+		origin:   hex.EncodeToString(b),
+		loaders:  make(map[string]*Loader),
+	}
+}
+
+// Register подключает загрузчик: его инвалидации будут отправляться другим репликам
+func (i *Invalidation) Register(l *Loader) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.loaders[l.name] = l
+	l.remote = i
+}
+
+// Receive применяет инвалидацию, полученную от другой реплики
+func (i *Invalidation) Receive(payload []byte) {
+	var msg invalidationMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		slog.Error("failed to decode cache invalidation", "error", err)
+		return
+	}
+	if msg.Origin == i.origin {
+		return
+	}
+
+	i.mu.Lock()
+	l := i.loaders[msg.Loader]
+	i.mu.Unlock()
+	if l != nil {
+		l.invalidateLocal(msg.Keys, msg.Prefixes)
+	}
+}
+
+// Reset очищает все зарегистрированные загрузчики
+func (i *Invalidation) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, l := range i.loaders {
+		l.reset()
+	}
+}
+
+// publish отправляет инвалидацию; если отправить не удалось, другие реплики увидят
+// устаревшие данные не дольше TTL
+func (i *Invalidation) publish(loader string, keys, prefixes []string) {
+	payload, err := json.Marshal(invalidationMessage{Origin: i.origin, Loader: loader, Keys: keys, Prefixes: prefixes})
+	if err != nil {
+		slog.Error("failed to encode cache invalidation", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := i.notifier.Notify(ctx, InvalidateChannel, payload); err != nil {
+		slog.Warn("failed to send cache invalidation to other replicas", "loader", loader, "error", err)
+	}
+}
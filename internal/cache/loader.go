@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"advanced-blog-management-system/internal/metrics"
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Loader читает значения из общего кеша в своем пространстве ключей и загружает промахи.
+// Одновременные промахи по одному ключу объединяются в один запрос (singleflight), чтобы
+// истечение популярной записи не приводило к лавине одинаковых запросов в БД
+type Loader struct {
+	name   string
+	cache  Cache
+	group  singleflight.Group
+	gen    atomic.Uint64
+	remote *Invalidation
+}
+
+func NewLoader(name string, c Cache) *Loader {
+	return &Loader{name: name, cache: c}
+}
+
+// Load возвращает значение из кеша или вызывает fetch и сохраняет результат.
+// Ошибки не кешируются. Результат загрузки, начатой до инвалидации, не сохраняется,
+// иначе запрос, прочитавший данные до фиксации записи, вернул бы их в кеш
+func (l *Loader) Load(ctx context.Context, key string, fetch func(ctx context.Context) (any, error)) (any, error) {
+	key = l.key(key)
+	if v, ok := l.cache.Get(key); ok {
+		metrics.CacheRequests.WithLabelValues(l.name, "hit").Inc()
+		return v, nil
+	}
+	metrics.CacheRequests.WithLabelValues(l.name, "miss").Inc()
+
+	v, err, _ := l.group.Do(key, func() (any, error) {
+		gen := l.gen.Load()
+		// Отмена запроса, начавшего загрузку, не должна отражаться на остальных ожидающих
+		v, err := fetch(context.WithoutCancel(ctx))
+		if err == nil && l.gen.Load() == gen {
+			l.cache.Set(key, v)
+		}
+		return v, err
+	})
+	return v, err
+}
+
+// Invalidate удаляет ключи и записи с указанными префиксами на этой и, если настроено, других репликах
+func (l *Loader) Invalidate(keys []string, prefixes []string) {
+	l.invalidateLocal(keys, prefixes)
+	if l.remote != nil {
+		l.remote.publish(l.name, keys, prefixes)
+	}
+}
+
+func (l *Loader) invalidateLocal(keys []string, prefixes []string) {
+	l.gen.Add(1)
+	for _, key := range keys {
+		l.cache.Delete(l.key(key))
+	}
+	for _, prefix := range prefixes {
+		l.cache.DeletePrefix(l.key(prefix))
+	}
+}
+
+// reset удаляет все записи загрузчика
+func (l *Loader) reset() {
+	l.invalidateLocal(nil, []string{""})
+}
+
+func (l *Loader) key(key string) string {
+	return l.name + ":" + key
+}
@@ -0,0 +1,232 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// contextLines - число неизмененных строк вокруг изменений в каждом фрагменте
+	contextLines = 3
+	// maxEditDistance ограничивает поиск кратчайшего редактирования: при большем числе
+	// изменений остаток текста считается замененным целиком. Так время и память остаются
+	// предсказуемыми на длинных, полностью переписанных текстах
+	maxEditDistance = 2000
+)
+
+type op byte
+
+const (
+	opEqual  op = ' '
+	opDelete op = '-'
+	opInsert op = '+'
+)
+
+type edit struct {
+	op   op
+	line string
+}
+
+// Unified строит построчный diff в унифицированном формате (как diff -u) между текстами
+// old и new с заголовками fromName и toName. Для одинаковых текстов возвращает пустую строку
+func Unified(fromName, toName, old, new string) string {
+	edits := lineEdits(splitLines(old), splitLines(new))
+
+	var b strings.Builder
+	for _, h := range hunks(edits) {
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		b.WriteString(h)
+	}
+	return b.String()
+}
+
+// splitLines разбивает текст на строки; завершающий перевод строки не дает пустой строки
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineEdits находит кратчайшую последовательность правок алгоритмом Майерса.
+// Общие начало и конец отбрасываются заранее: обычно правка затрагивает малую часть текста
+func lineEdits(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	return edits
+}
+
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	total := n + m
+	offset := total + 1
+	v := make([]int, 2*total+3)
+	// trace[d] - значения v перед шагом d для диагоналей -(d+1)..d+1
+	var trace [][]int
+
+	for d := 0; d <= total; d++ {
+		if d > maxEditDistance {
+			return replaceAll(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack восстанавливает правки по сохраненным шагам, двигаясь от конца текстов к началу
+func backtrack(trace [][]int, a, b []string) []edit {
+	var reversed []edit
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, edit{opEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, edit{opInsert, b[y-1]})
+			} else {
+				reversed = append(reversed, edit{opDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+func replaceAll(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, edit{opDelete, line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{opInsert, line})
+	}
+	return edits
+}
+
+// hunks группирует правки во фрагменты с contextLines строк контекста. Изменения, между
+// которыми не больше 2*contextLines общих строк, попадают в один фрагмент
+func hunks(edits []edit) []string {
+	// Номера строк старого и нового текста перед каждой правкой
+	oldLine := make([]int, len(edits)+1)
+	newLine := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if e.op != opInsert {
+			oldLine[i+1]++
+		}
+		if e.op != opDelete {
+			newLine[i+1]++
+		}
+	}
+
+	var out []string
+	for i := 0; i < len(edits); {
+		for i < len(edits) && edits[i].op == opEqual {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+
+		start := max(i-contextLines, 0)
+		end := i
+		for {
+			for end < len(edits) && edits[end].op != opEqual {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].op == opEqual {
+				next++
+			}
+			if next < len(edits) && next-end <= 2*contextLines {
+				end = next
+				continue
+			}
+			end = min(end+contextLines, len(edits))
+			break
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(oldLine[start], oldLine[end]-oldLine[start]),
+			hunkRange(newLine[start], newLine[end]-newLine[start]))
+		for _, e := range edits[start:end] {
+			b.WriteByte(byte(e.op))
+			b.WriteString(e.line)
+			b.WriteByte('\n')
+		}
+		out = append(out, b.String())
+		i = end
+	}
+	return out
+}
+
+// hunkRange форматирует диапазон строк фрагмента; пустой диапазон указывает на строку перед ним
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
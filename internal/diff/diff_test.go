@@ -0,0 +1,89 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnified_NoChanges(t *testing.T) {
+	if got := Unified("a", "b", "same\ntext\n", "same\ntext"); got != "" {
+		t.Errorf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnified_SingleHunk(t *testing.T) {
+	old := "one\ntwo\nthree\nfour\nfive\n"
+	new := "one\ntwo\n3\nfour\nfive\nsix\n"
+
+	want := "--- revision 1\n+++ revision 2\n" +
+		"@@ -1,5 +1,6 @@\n" +
+		" one\n" +
+		" two\n" +
+		"-three\n" +
+		"+3\n" +
+		" four\n" +
+		" five\n" +
+		"+six\n"
+
+	if got := Unified("revision 1", "revision 2", old, new); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	old := strings.Join(lines, "\n")
+	lines[1] = "changed 2"
+	lines[17] = "changed 18"
+	new := strings.Join(lines, "\n")
+
+	got := Unified("a", "b", old, new)
+
+	if strings.Count(got, "@@ -") != 2 {
+		t.Fatalf("expected two hunks, got:\n%s", got)
+	}
+	for _, header := range []string{"@@ -1,5 +1,5 @@", "@@ -15,6 +15,6 @@"} {
+		if !strings.Contains(got, header) {
+			t.Errorf("expected header %q in:\n%s", header, got)
+		}
+	}
+}
+
+func TestUnified_FromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+first\n+second\n"
+	if got := Unified("a", "b", "", "first\nsecond"); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLineEdits_Minimal(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+
+	edits := lineEdits(a, b)
+
+	changes := 0
+	var gotA, gotB []string
+	for _, e := range edits {
+		if e.op != opEqual {
+			changes++
+		}
+		if e.op != opInsert {
+			gotA = append(gotA, e.line)
+		}
+		if e.op != opDelete {
+			gotB = append(gotB, e.line)
+		}
+	}
+	if strings.Join(gotA, " ") != strings.Join(a, " ") || strings.Join(gotB, " ") != strings.Join(b, " ") {
+		t.Fatalf("edits do not reproduce inputs: %v", edits)
+	}
+	// The shortest edit script for the example from the Myers paper has 5 edits
+	if changes != 5 {
+		t.Errorf("expected 5 changes, got %d", changes)
+	}
+}
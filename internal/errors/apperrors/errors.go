@@ -1,14 +1,53 @@
 package apperrors
 
-import "errors"
+import (
+	"errors"
+	"sort"
+	"strings"
+)
 
 var (
-	ErrUnauthorized       = errors.New("unauthorized")
-	ErrForbidden          = errors.New("forbidden")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrPostNotFound       = errors.New("post not found")
-	ErrCommentNotFound    = errors.New("comment not found")
-	ErrInvalidPostID      = errors.New("invalid post ID")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrUserAlreadyExists    = errors.New("user already exists")
+	ErrInvalidCredentials   = errors.New("invalid credentials")
+	ErrPostNotFound         = errors.New("post not found")
+	ErrCommentNotFound      = errors.New("comment not found")
+	ErrRevisionNotFound     = errors.New("post revision not found")
+	ErrParentDeleted        = errors.New("parent resource is deleted")
+	ErrConflict             = errors.New("resource was modified concurrently")
+	ErrContentRejected      = errors.New("content rejected by spam filter")
+	ErrInvalidPostID        = errors.New("invalid post ID")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrOutboxEventNotFound  = errors.New("outbox event not found")
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrNotificationNotFound = errors.New("notification not found")
 )
+
+// FieldErrors - ошибки отдельных полей запроса: имя поля -> описание ошибки
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field, msg := range e {
+		fields = append(fields, field+": "+msg)
+	}
+	sort.Strings(fields)
+	return "invalid fields: " + strings.Join(fields, "; ")
+}
+
+// ConflictError - запись изменена другим запросом после того, как ее прочитал клиент.
+// Current - актуальная копия записи на сервере, чтобы клиент мог объединить правки
+type ConflictError struct {
+	Current any
+}
+
+func (e *ConflictError) Error() string {
+	return ErrConflict.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/service"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminHandler обрабатывает административные запросы: журнал аудита, управление ролями и outbox
+type AdminHandler struct {
+	auditService  service.AuditServiceInterface
+	userService   service.UserServiceInterface
+	outboxService service.OutboxServiceInterface
+}
+
+func NewAdminHandler(auditService service.AuditServiceInterface, userService service.UserServiceInterface, outboxService service.OutboxServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		auditService:  auditService,
+		userService:   userService,
+		outboxService: outboxService,
+	}
+}
+
+// ListAudit возвращает журнал аудита с фильтрами actor, action, target, from, to.
+// Пагинация курсорная (cursor, limit); при format=csv или Accept: text/csv выгружается весь журнал по фильтру
+func (h *AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		h.exportAuditCSV(w, r, filter)
+		return
+	}
+
+	entries, next, err := h.auditService.List(r.Context(), filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	type AuditResponse struct {
+		Events     []*model.AuditEntry `json:"events"`
+		NextCursor string              `json:"next_cursor,omitempty"`
+	}
+
+	if entries == nil {
+		entries = []*model.AuditEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(AuditResponse{Events: entries, NextCursor: next})
+}
+
+func (h *AdminHandler) exportAuditCSV(w http.ResponseWriter, r *http.Request, filter model.AuditFilter) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "event_id", "action", "actor_id", "target_type", "target_id", "request_id", "occurred_at", "payload"})
+
+	err := h.auditService.Export(r.Context(), filter, func(e *model.AuditEntry) error {
+		actorID := ""
+		if e.ActorID != nil {
+			actorID = strconv.Itoa(*e.ActorID)
+		}
+		writer.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.EventID,
+			string(e.Action),
+			actorID,
+			e.Target.Type,
+			strconv.Itoa(e.Target.ID),
+			e.RequestID,
+			e.OccurredAt.UTC().Format(time.RFC3339),
+			string(e.Payload),
+		})
+		return writer.Error()
+	})
+	writer.Flush()
+
+	// Заголовки уже отправлены, поэтому об ошибке остается только записать в лог
+	if err != nil {
+		logger.FromContext(r.Context()).Error("failed to export audit log", "error", err)
+	}
+}
+
+// ChangeUserRole меняет роль пользователя
+func (h *AdminHandler) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		WriteError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req model.UserRoleUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.ChangeRole(r.Context(), actorID, userID, &req)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user.ToResponse())
+}
+
+// ListOutbox возвращает события outbox по статусу (pending, delivered, dead; по умолчанию dead)
+func (h *AdminHandler) ListOutbox(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", model.OutboxPending, model.OutboxDelivered, model.OutboxDead:
+	default:
+		WriteError(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	limit := 50
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	messages, err := h.outboxService.List(r.Context(), status, limit, offset)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	if messages == nil {
+		messages = []*model.OutboxMessage{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(messages)
+}
+
+// RetryOutbox возвращает событие из dead-letter в очередь доставки
+func (h *AdminHandler) RetryOutbox(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteError(w, "Invalid outbox event ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.outboxService.Retry(r.Context(), id); err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parseAuditFilter разбирает параметры запроса журнала аудита.
+// target задается как "post" или "post:42", from и to - в формате RFC 3339
+func parseAuditFilter(r *http.Request) (model.AuditFilter, error) {
+	q := r.URL.Query()
+	var filter model.AuditFilter
+
+	if actor := q.Get("actor"); actor != "" {
+		id, err := strconv.Atoi(actor)
+		if err != nil || id <= 0 {
+			return filter, errors.New("Invalid actor")
+		}
+		filter.ActorID = id
+	}
+
+	filter.Action = q.Get("action")
+
+	if target := q.Get("target"); target != "" {
+		targetType, targetID, hasID := strings.Cut(target, ":")
+		filter.TargetType = targetType
+		if hasID {
+			id, err := strconv.Atoi(targetID)
+			if err != nil || id <= 0 {
+				return filter, errors.New("Invalid target")
+			}
+			filter.TargetID = id
+		}
+	}
+
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("Invalid %s: expected RFC 3339 timestamp", name)
+			}
+			*dst = t
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 {
+			return filter, errors.New("Invalid limit")
+		}
+		filter.Limit = l
+	}
+
+	return filter, nil
+}
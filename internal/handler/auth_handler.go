@@ -2,6 +2,7 @@ package handler
 
 import (
 	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/metrics"
 	"advanced-blog-management-system/internal/middleware"
 	"advanced-blog-management-system/internal/model"
 	"advanced-blog-management-system/internal/service"
@@ -63,6 +64,8 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	metrics.UsersRegistered.Inc()
+
 	h.respondWithJSON(w, tokenResp, http.StatusCreated)
 }
 
@@ -156,9 +159,14 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 
 // respondWithError отправляет JSON-ответ с ошибкой
 func (h *AuthHandler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	body := map[string]string{"error": message}
+	if requestID := w.Header().Get(middleware.RequestIDHeader); requestID != "" {
+		body["request_id"] = requestID
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(body)
 }
 
 // respondWithJSON отправляет JSON-ответ с данными
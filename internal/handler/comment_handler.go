@@ -1,27 +1,31 @@
 package handler
 
 import (
-	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/metrics"
 	"advanced-blog-management-system/internal/middleware"
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pagination"
 	"advanced-blog-management-system/internal/service"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type CommentHandler struct {
 	commentService service.CommentServiceInterface
-	eventLogger    *logger.EventLogger
+	cursors        *pagination.Codec
 }
 
-func NewCommentHandler(commentService service.CommentServiceInterface, eventLogger *logger.EventLogger) *CommentHandler {
+func NewCommentHandler(commentService service.CommentServiceInterface, cursors *pagination.Codec) *CommentHandler {
 	return &CommentHandler{
 		commentService: commentService,
-		eventLogger:    eventLogger,
+		cursors:        cursors,
 	}
 }
 
@@ -44,8 +48,15 @@ func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
 	var req struct {
-		Content string `json:"content"`
+		Content       string `json:"content"`
+		ContentFormat string `json:"content_format"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		WriteError(w, "Invalid request body", http.StatusBadRequest)
@@ -61,17 +72,16 @@ func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comment, err := h.commentService.Create(r.Context(), userID, postID, req.Content)
+	comment, err := h.commentService.Create(r.Context(), userID, postID, req.Content, req.ContentFormat)
 	if err != nil {
 		HandleServiceError(w, err)
 		return
 	}
 
-	h.eventLogger.LogEvent(fmt.Sprintf("user %d created comment %d", userID, comment.ID))
+	metrics.CommentsCreated.Inc()
+	presentComments([]*model.Comment{comment}, mode)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(w).Encode(comment)
+	writeComment(w, comment, http.StatusCreated)
 }
 
 func (h *CommentHandler) GetByPost(w http.ResponseWriter, r *http.Request) {
@@ -87,6 +97,17 @@ func (h *CommentHandler) GetByPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	if !usesOffset(r) {
+		h.listPage(w, r, postID, mode)
+		return
+	}
+
 	limit := 20
 	offset := 0
 
@@ -102,11 +123,13 @@ func (h *CommentHandler) GetByPost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	comments, total, err := h.commentService.GetByPost(r.Context(), postID, limit, offset)
+	requestorID, _ := middleware.GetUserIDFromContext(r.Context())
+	comments, total, err := h.commentService.GetByPost(r.Context(), postID, requestorID, limit, offset)
 	if err != nil {
 		HandleServiceError(w, err)
 		return
 	}
+	presentComments(comments, mode)
 
 	resp := struct {
 		Comments []*model.Comment `json:"comments"`
@@ -122,7 +145,154 @@ func (h *CommentHandler) GetByPost(w http.ResponseWriter, r *http.Request) {
 		PostID:   postID,
 	}
 
+	// Last-Modified не выставляется: удаление комментария не меняет дат оставшихся,
+	// поэтому актуальность списка проверяется только по ETag
+	writeCacheable(w, r, resp, time.Time{}, requestorCachePolicy(requestorID, cachePolicyList))
+}
+
+// Update меняет текст комментария; доступно только автору. Версию комментария, которую видел
+// клиент, передают в поле version или заголовком If-Match с ETag из ответа на создание или
+// правку; при устаревшей версии ответ 409 содержит актуальную копию
+func (h *CommentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, commentID, ok := parseCommentPath(w, r)
+	if !ok {
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	var req struct {
+		Content       string `json:"content"`
+		ContentFormat string `json:"content_format"`
+		Version       int    `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Content == "" {
+		WriteError(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Content) > 1000 {
+		WriteError(w, "Content exceeds maximum length of 1000 characters", http.StatusBadRequest)
+		return
+	}
+
+	if req.Version == 0 && r.Header.Get("If-Match") != "" {
+		current, err := h.commentService.GetForUpdate(r.Context(), userID, postID, commentID)
+		if err != nil {
+			HandleServiceError(w, err)
+			return
+		}
+		if !checkIfMatch(r, commentETag(current)) {
+			presentComments([]*model.Comment{current}, mode)
+			HandleServiceError(w, &apperrors.ConflictError{Current: current})
+			return
+		}
+		req.Version = current.Version
+	}
+
+	comment, err := h.commentService.Update(r.Context(), userID, postID, commentID, req.Content, req.ContentFormat, req.Version)
+	if err != nil {
+		var conflict *apperrors.ConflictError
+		if errors.As(err, &conflict) {
+			if current, ok := conflict.Current.(*model.Comment); ok {
+				presentComments([]*model.Comment{current}, mode)
+			}
+		}
+		HandleServiceError(w, err)
+		return
+	}
+	presentComments([]*model.Comment{comment}, mode)
+
+	writeComment(w, comment, http.StatusOK)
+}
+
+// Delete удаляет комментарий; доступно только автору
+func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, commentID, ok := parseCommentPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.Delete(r.Context(), userID, postID, commentID); err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeComment отправляет комментарий с ETag, который клиент передает в If-Match следующей правки
+func writeComment(w http.ResponseWriter, comment *model.Comment, status int) {
+	w.Header().Set("ETag", commentETag(comment))
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(resp)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(comment)
+}
+
+// parseCommentPath читает ID поста и комментария из пути; при ошибке сам отвечает 400
+func parseCommentPath(w http.ResponseWriter, r *http.Request) (postID, commentID int, ok bool) {
+	postID, err := strconv.Atoi(chi.URLParam(r, "postId"))
+	if err != nil {
+		WriteError(w, "Invalid post ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	commentID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		WriteError(w, "Invalid comment ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return postID, commentID, true
+}
+
+// listPage отвечает страницей комментариев по курсору, от старых к новым
+func (h *CommentHandler) listPage(w http.ResponseWriter, r *http.Request, postID int, mode string) {
+	scope := fmt.Sprintf("comments:%d", postID)
+	page, ok := readPageRequest(w, r, h.cursors, scope, 20)
+	if !ok {
+		return
+	}
+
+	requestorID, _ := middleware.GetUserIDFromContext(r.Context())
+	comments, info, err := h.commentService.ListPage(r.Context(), postID, requestorID, page)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	presentComments(comments, mode)
+
+	resp := struct {
+		Comments []*model.Comment `json:"comments"`
+		Limit    int              `json:"limit"`
+		Total    *int             `json:"total,omitempty"`
+		PostID   int              `json:"post_id"`
+		pageCursors
+	}{
+		Comments:    comments,
+		Limit:       page.Limit,
+		Total:       info.Total,
+		PostID:      postID,
+		pageCursors: writePageLinks(w, r, h.cursors, scope, info),
+	}
+
+	writeCacheable(w, r, resp, time.Time{}, requestorCachePolicy(requestorID, cachePolicyList))
 }
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// stubCommentEditor implements CommentServiceInterface; only GetForUpdate and Update are used
+type stubCommentEditor struct {
+	service.CommentServiceInterface
+	current  model.Comment
+	versions []int
+}
+
+func (s *stubCommentEditor) GetForUpdate(ctx context.Context, userID, postID, commentID int) (*model.Comment, error) {
+	c := s.current
+	return &c, nil
+}
+
+func (s *stubCommentEditor) Update(ctx context.Context, userID, postID, commentID int, content, format string, version int) (*model.Comment, error) {
+	s.versions = append(s.versions, version)
+	if version != s.current.Version {
+		c := s.current
+		return nil, &apperrors.ConflictError{Current: &c}
+	}
+	s.current.Content = content
+	s.current.Version++
+	c := s.current
+	return &c, nil
+}
+
+func updateComment(t *testing.T, comments *stubCommentEditor, ifMatch, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Put("/posts/{postId}/comments/{id}", NewCommentHandler(comments, nil).Update)
+
+	req := httptest.NewRequest(http.MethodPut, "/posts/1/comments/7", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 2))
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCommentHandler_Update_IfMatch(t *testing.T) {
+	comments := &stubCommentEditor{current: model.Comment{ID: 7, PostID: 1, AuthorID: 2, Content: "First", Version: 2}}
+	etag := commentETag(&comments.current)
+
+	rec := updateComment(t, comments, etag, `{"content": "Edited"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(comments.versions) != 1 || comments.versions[0] != 2 {
+		t.Errorf("expected update at version 2 taken from If-Match, got %v", comments.versions)
+	}
+	if got := rec.Header().Get("ETag"); got != commentETag(&comments.current) || got == etag {
+		t.Errorf("expected ETag of the new version, got %q", got)
+	}
+
+	// The ETag the client had is now stale: the update is refused with the current copy
+	rec = updateComment(t, comments, etag, `{"content": "Lost edit"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	if len(comments.versions) != 1 {
+		t.Errorf("expected stale If-Match not to reach the service, got %v", comments.versions)
+	}
+	var body struct {
+		Current model.Comment `json:"current"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Current.Version != 3 || body.Current.Content != "Edited" {
+		t.Errorf("expected current copy at version 3, got %+v", body.Current)
+	}
+}
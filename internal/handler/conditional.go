@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Политики Cache-Control для кешируемых ответов
+const (
+	// cachePolicyItem - отдельную запись клиент может использовать минуту без запроса к серверу
+	cachePolicyItem = "public, max-age=60"
+	// cachePolicyList - списки меняются часто, поэтому каждый раз перепроверяются по ETag;
+	// неизменившийся список обходится ответом 304 без тела
+	cachePolicyList = "public, no-cache"
+	// cachePolicyPrivate - ответ виден только этому пользователю (например, пост на модерации),
+	// поэтому не сохраняется ни браузером, ни общими кешами
+	cachePolicyPrivate = "private, no-store"
+)
+
+// writeCacheable отправляет JSON-ответ 200 с сильным ETag (SHA-256 от тела), Last-Modified
+// и Cache-Control. Если копия клиента актуальна по If-None-Match или If-Modified-Since,
+// отправляется 304 без тела. Нулевой lastModified не выставляет Last-Modified
+func writeCacheable(w http.ResponseWriter, r *http.Request, v any, lastModified time.Time, cacheControl string) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		WriteError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	etag := contentETag(buf.Bytes())
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// requestorCachePolicy возвращает policy для анонимного запроса и cachePolicyPrivate для
+// авторизованного: ему может быть отдан пост на модерации, который видят только автор
+// и модераторы, а читать такой пост по ID без проверки ради выбора заголовка незачем
+func requestorCachePolicy(requestorID int, policy string) string {
+	if requestorID != 0 {
+		return cachePolicyPrivate
+	}
+	return policy
+}
+
+// representationETag вычисляет ETag, который writeCacheable выставит для ответа с телом v
+func representationETag(v any) (string, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return "", err
+	}
+	return contentETag(buf.Bytes()), nil
+}
+
+// contentETag вычисляет сильный ETag по телу ответа
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified проверяет условия GET по RFC 9110: If-None-Match важнее If-Modified-Since,
+// который учитывается, только если If-None-Match не передан
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagListMatches(inm, etag, true)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// Last-Modified передается с точностью до секунды
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// checkIfMatch проверяет If-Match изменяющего запроса по ETag текущей записи. Запись может
+// отдаваться в нескольких представлениях (например, raw и html), поэтому подходит любой из etags.
+// Сравнение строгое, W/-теги не подходят. Без заголовка проверка проходит
+func checkIfMatch(r *http.Request, etags ...string) bool {
+	im := r.Header.Get("If-Match")
+	if im == "" {
+		return true
+	}
+	for _, etag := range etags {
+		if etagListMatches(im, etag, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// etagListMatches ищет etag в списке заголовка If-Match/If-None-Match; "*" совпадает с любым.
+// При слабом сравнении префикс W/ игнорируется
+func etagListMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
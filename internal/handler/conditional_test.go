@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWriteCacheable_NotModifiedByETag(t *testing.T) {
+	body := map[string]int{"id": 1}
+
+	first := httptest.NewRecorder()
+	writeCacheable(first, httptest.NewRequest(http.MethodGet, "/", nil), body, time.Time{}, cachePolicyList)
+
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with ETag, got %d %q", first.Code, etag)
+	}
+	if cc := first.Header().Get("Cache-Control"); cc != cachePolicyList {
+		t.Errorf("unexpected Cache-Control %q", cc)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	second := httptest.NewRecorder()
+	writeCacheable(second, req, body, time.Time{}, cachePolicyList)
+
+	if second.Code != http.StatusNotModified || second.Body.Len() != 0 {
+		t.Errorf("expected empty 304, got %d with %d bytes", second.Code, second.Body.Len())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	changed := httptest.NewRecorder()
+	writeCacheable(changed, req, map[string]int{"id": 2}, time.Time{}, cachePolicyList)
+
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Errorf("expected 200 with new ETag for changed body, got %d", changed.Code)
+	}
+}
+
+func TestWriteCacheable_NotModifiedSince(t *testing.T) {
+	modified := time.Date(2024, 1, 15, 10, 30, 0, 500, time.UTC)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+	rec := httptest.NewRecorder()
+	writeCacheable(rec, req, "post", modified, cachePolicyItem)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for unmodified resource, got %d", rec.Code)
+	}
+	if lm := rec.Header().Get("Last-Modified"); lm != "Mon, 15 Jan 2024 10:30:00 GMT" {
+		t.Errorf("unexpected Last-Modified %q", lm)
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	writeCacheable(rec, req, "post", modified, cachePolicyItem)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 when ETag does not match, got %d", rec.Code)
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    bool
+	}{
+		{"absent", "", true},
+		{"matches", `"abc"`, true},
+		{"wildcard", "*", true},
+		{"any of the representations", `"old", "def"`, true},
+		{"stale", `"old"`, false},
+		{"weak never matches", `W/"abc"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			if got := checkIfMatch(req, `"abc"`, `"def"`); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPostETags_AnyRepresentation(t *testing.T) {
+	post := &model.Post{ID: 1, Title: "Title", Content: "**bold**", ContentFormat: model.ContentFormatMarkdown, Version: 3}
+
+	for _, mode := range []string{renderRaw, renderHTML} {
+		p := *post
+		presentPost(&p, mode)
+		rec := httptest.NewRecorder()
+		writeCacheable(rec, httptest.NewRequest(http.MethodGet, "/", nil), &p, time.Time{}, cachePolicyItem)
+
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set("If-Match", rec.Header().Get("ETag"))
+		if !checkIfMatch(req, postETags(post)...) {
+			t.Errorf("expected ETag of %s representation to match", mode)
+		}
+	}
+
+	edited := *post
+	edited.Title = "Edited"
+	edited.Version = 4
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	req.Header.Set("If-Match", `"stale"`)
+	if checkIfMatch(req, postETags(&edited)...) {
+		t.Error("expected unknown ETag not to match")
+	}
+}
+
+func TestHandleServiceError_ConflictIncludesCurrent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleServiceError(rec, &apperrors.ConflictError{Current: &model.Post{ID: 1, Version: 4}})
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	var body struct {
+		Current model.Post `json:"current"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Current.ID != 1 || body.Current.Version != 4 {
+		t.Errorf("expected current copy of post 1 at version 4, got %+v", body.Current)
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck - проверка одной зависимости; возвращает ошибку, если зависимость недоступна
+type HealthCheck func(ctx context.Context) error
+
+// CheckResult - результат отдельной проверки в ответе probe
+type CheckResult struct {
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// HealthResponse - тело ответа /livez и /readyz
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// HealthHandler обслуживает liveness и readiness probes
+type HealthHandler struct {
+	liveness     map[string]HealthCheck
+	readiness    map[string]HealthCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewHealthHandler создает обработчик probes с таймаутом на каждую проверку
+func NewHealthHandler(timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		liveness:  make(map[string]HealthCheck),
+		readiness: make(map[string]HealthCheck),
+		timeout:   timeout,
+	}
+}
+
+// AddLivenessCheck регистрирует проверку, провал которой означает, что процесс нужно перезапустить
+func (h *HealthHandler) AddLivenessCheck(name string, check HealthCheck) {
+	h.liveness[name] = check
+}
+
+// AddReadinessCheck регистрирует проверку, провал которой снимает инстанс с балансировки
+func (h *HealthHandler) AddReadinessCheck(name string, check HealthCheck) {
+	h.readiness[name] = check
+}
+
+// SetShuttingDown переводит readiness в состояние отказа при получении SIGTERM
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live обрабатывает GET /livez
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, h.run(r.Context(), h.liveness))
+}
+
+// Ready обрабатывает GET /readyz
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	resp := h.run(r.Context(), h.readiness)
+
+	shutdown := CheckResult{Status: "ok"}
+	if h.shuttingDown.Load() {
+		shutdown = CheckResult{Status: "fail", Error: "server is shutting down"}
+		resp.Status = "fail"
+	}
+	resp.Checks["shutdown"] = shutdown
+
+	h.respond(w, resp)
+}
+
+// run выполняет проверки параллельно, каждую - со своим таймаутом
+func (h *HealthHandler) run(ctx context.Context, checks map[string]HealthCheck) HealthResponse {
+	resp := HealthResponse{Status: "ok", Checks: make(map[string]CheckResult, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			result := CheckResult{Status: "ok", DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			resp.Checks[name] = result
+			if err != nil {
+				resp.Status = "fail"
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	return resp
+}
+
+func (h *HealthHandler) respond(w http.ResponseWriter, resp HealthResponse) {
+	statusCode := http.StatusOK
+	if resp.Status != "ok" {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
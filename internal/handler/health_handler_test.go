@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler_Ready_AllChecksPass(t *testing.T) {
+	h := NewHealthHandler(time.Second)
+	h.AddReadinessCheck("database", func(ctx context.Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var resp HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Checks["database"].Status != "ok" {
+		t.Errorf("expected database check ok, got %+v", resp.Checks["database"])
+	}
+}
+
+func TestHealthHandler_Ready_FailingCheck(t *testing.T) {
+	h := NewHealthHandler(time.Second)
+	h.AddReadinessCheck("database", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", rec.Code)
+	}
+
+	var resp HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Checks["database"].Error != "connection refused" {
+		t.Errorf("expected check error in response, got %+v", resp.Checks["database"])
+	}
+}
+
+func TestHealthHandler_Ready_TimesOutSlowCheck(t *testing.T) {
+	h := NewHealthHandler(10 * time.Millisecond)
+	h.AddReadinessCheck("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+}
+
+func TestHealthHandler_ShuttingDown(t *testing.T) {
+	h := NewHealthHandler(time.Second)
+	h.SetShuttingDown()
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected readiness 503 during shutdown, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Live(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected liveness 200 during shutdown, got %d", rec.Code)
+	}
+}
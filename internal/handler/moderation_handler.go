@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ModerationHandler struct {
+	moderationService   service.ModerationServiceInterface
+	notificationService service.NotificationServiceInterface
+}
+
+func NewModerationHandler(moderationService service.ModerationServiceInterface, notificationService service.NotificationServiceInterface) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService:   moderationService,
+		notificationService: notificationService,
+	}
+}
+
+// ListComments отдает очередь модерации. Параметры: status (pending по умолчанию), post_id, author_id.
+// Модераторы видят комментарии всех авторов, остальные - только собственные
+func (h *ModerationHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.ModerationFilter{Status: query.Get("status")}
+	if v := query.Get("post_id"); v != "" {
+		if filter.PostID, err = strconv.Atoi(v); err != nil || filter.PostID <= 0 {
+			WriteError(w, "Invalid post_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("author_id"); v != "" {
+		if filter.AuthorID, err = strconv.Atoi(v); err != nil || filter.AuthorID <= 0 {
+			WriteError(w, "Invalid author_id", http.StatusBadRequest)
+			return
+		}
+	}
+	filter.Limit, filter.Offset = readTrashPage(r)
+
+	comments, err := h.moderationService.List(r.Context(), userID, filter)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	if comments == nil {
+		comments = []*model.Comment{}
+	}
+	presentComments(comments, mode)
+
+	writeTrashPage(w, "comments", comments, filter.Limit, filter.Offset)
+}
+
+// ApproveComments публикует комментарии из тела запроса
+func (h *ModerationHandler) ApproveComments(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.moderationService.Approve)
+}
+
+// RejectComments отклоняет комментарии из тела запроса; причина сообщается авторам
+func (h *ModerationHandler) RejectComments(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.moderationService.Reject)
+}
+
+func (h *ModerationHandler) decide(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, moderatorID int, req *model.ModerationRequest) ([]*model.Comment, error)) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	var req model.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	comments, err := fn(r.Context(), userID, &req)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	if comments == nil {
+		comments = []*model.Comment{}
+	}
+	presentComments(comments, mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"comments": comments})
+}
+
+// ListNotifications отдает уведомления текущего пользователя; unread=true - только непрочитанные
+func (h *ModerationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	unreadOnly, _ := strconv.ParseBool(r.URL.Query().Get("unread"))
+	limit, offset := readTrashPage(r)
+
+	notifications, err := h.notificationService.List(r.Context(), userID, unreadOnly, limit, offset)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	if notifications == nil {
+		notifications = []*model.Notification{}
+	}
+
+	writeTrashPage(w, "notifications", notifications, limit, offset)
+}
+
+// MarkNotificationRead отмечает уведомление прочитанным
+func (h *ModerationHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteError(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notificationService.MarkRead(r.Context(), userID, id); err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetCommentModeration включает или выключает модерацию комментариев поста
+func (h *PostHandler) SetCommentModeration(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	var req model.PostModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.postService.SetCommentModeration(r.Context(), userID, id, req.Enabled)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	presentPost(post, mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(post)
+}
+
+// ListPendingPosts отдает посты, ожидающие модерации: модераторам - все, остальным - собственные
+func (h *PostHandler) ListPendingPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	limit, offset := readTrashPage(r)
+
+	posts, err := h.postService.ListPending(r.Context(), userID, limit, offset)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	if posts == nil {
+		posts = []*model.Post{}
+	}
+	for _, p := range posts {
+		presentPost(p, mode)
+	}
+
+	writeTrashPage(w, "posts", posts, limit, offset)
+}
+
+// ApprovePost публикует пост из очереди модерации; отклоненный пост модератор удаляет
+func (h *PostHandler) ApprovePost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	post, err := h.postService.Approve(r.Context(), userID, id)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	presentPost(post, mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(post)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pagination"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// usesOffset сообщает, запросил ли клиент старый режим пагинации по смещению
+func usesOffset(r *http.Request) bool {
+	return r.URL.Query().Has("offset")
+}
+
+// readPageRequest разбирает limit, cursor и include_total, а в режиме смещения - offset
+// (total в этом режиме возвращается всегда). Неверный или чужой курсор отклоняется ответом 400
+func readPageRequest(w http.ResponseWriter, r *http.Request, cursors *pagination.Codec, scope string, defaultLimit int) (model.PageRequest, bool) {
+	query := r.URL.Query()
+	page := model.PageRequest{Limit: defaultLimit}
+
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		page.Limit = l
+	}
+	if usesOffset(r) {
+		if o, err := strconv.Atoi(query.Get("offset")); err == nil && o >= 0 {
+			page.Offset = o
+		}
+		page.IncludeTotal = true
+		return page, true
+	}
+	if include, err := strconv.ParseBool(query.Get("include_total")); err == nil {
+		page.IncludeTotal = include
+	}
+
+	if token := query.Get("cursor"); token != "" {
+		cursor, err := cursors.Decode(scope, token)
+		if err != nil {
+			HandleServiceError(w, err)
+			return model.PageRequest{}, false
+		}
+		page.Position = &cursor.Position
+		page.Backward = cursor.Backward
+	}
+	return page, true
+}
+
+// pageCursors - курсоры соседних страниц в теле ответа
+type pageCursors struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// writePageLinks кодирует курсоры соседних страниц и выставляет заголовок Link (RFC 8288)
+// с отношениями first, prev и next. Ссылки сохраняют остальные параметры запроса
+func writePageLinks(w http.ResponseWriter, r *http.Request, cursors *pagination.Codec, scope string, info model.PageInfo) pageCursors {
+	var out pageCursors
+	links := []string{pageLink(r, "", "first")}
+
+	if info.Prev != nil {
+		out.PrevCursor = cursors.Encode(scope, pagination.Cursor{Position: *info.Prev, Backward: true})
+		links = append(links, pageLink(r, out.PrevCursor, "prev"))
+	}
+	if info.Next != nil {
+		out.NextCursor = cursors.Encode(scope, pagination.Cursor{Position: *info.Next})
+		links = append(links, pageLink(r, out.NextCursor, "next"))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	return out
+}
+
+func pageLink(r *http.Request, cursor, rel string) string {
+	query := r.URL.Query()
+	query.Del("cursor")
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	target := r.URL.Path
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+	return "<" + target + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pagination"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPageCursors_LinkHeaderRoundTrip(t *testing.T) {
+	codec := pagination.NewCodec("secret")
+	next := model.Keyset{CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ID: 9}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/posts?limit=5&include_total=true", nil)
+	rec := httptest.NewRecorder()
+	cursors := writePageLinks(rec, req, codec, "posts", model.PageInfo{Next: &next})
+
+	if cursors.NextCursor == "" || cursors.PrevCursor != "" {
+		t.Fatalf("expected only next cursor, got %+v", cursors)
+	}
+	link := rec.Header().Get("Link")
+	if !strings.Contains(link, `</api/posts?include_total=true&limit=5>; rel="first"`) ||
+		!strings.Contains(link, "cursor="+cursors.NextCursor+`&include_total=true&limit=5>; rel="next"`) {
+		t.Errorf("unexpected Link header %q", link)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/posts?limit=5&include_total=true&cursor="+cursors.NextCursor, nil)
+	page, ok := readPageRequest(httptest.NewRecorder(), req, codec, "posts", 10)
+	if !ok || page.Position == nil || page.Position.ID != 9 || page.Backward || page.Limit != 5 || !page.IncludeTotal {
+		t.Errorf("unexpected page request %+v", page)
+	}
+
+	// A cursor issued for one listing is rejected by another
+	req = httptest.NewRequest(http.MethodGet, "/api/users/1/posts?cursor="+cursors.NextCursor, nil)
+	rec = httptest.NewRecorder()
+	if _, ok := readPageRequest(rec, req, codec, "posts:author:1", 10); ok || rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for foreign cursor, got %d", rec.Code)
+	}
+}
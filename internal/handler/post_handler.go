@@ -1,27 +1,30 @@
 package handler
 
 import (
-	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/metrics"
 	"advanced-blog-management-system/internal/middleware"
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pagination"
 	"advanced-blog-management-system/internal/service"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type PostHandler struct {
 	postService service.PostServiceInterface
-	eventLogger *logger.EventLogger
+	cursors     *pagination.Codec
 }
 
-func NewPostHandler(postService service.PostServiceInterface, eventLogger *logger.EventLogger) *PostHandler {
+func NewPostHandler(postService service.PostServiceInterface, cursors *pagination.Codec) *PostHandler {
 	return &PostHandler{
 		postService: postService,
-		eventLogger: eventLogger,
+		cursors:     cursors,
 	}
 }
 
@@ -37,6 +40,12 @@ func (h *PostHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
 	var req model.PostCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		WriteError(w, "Invalid request body", http.StatusBadRequest)
@@ -49,7 +58,8 @@ func (h *PostHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.eventLogger.LogEvent(fmt.Sprintf("user %d created post %d", userID, post.ID))
+	metrics.PostsCreated.Inc()
+	presentPost(post, mode)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -69,6 +79,12 @@ func (h *PostHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
 	var requestorID int
 	if userID, ok := middleware.GetUserIDFromContext(r.Context()); ok {
 		requestorID = userID
@@ -80,55 +96,109 @@ func (h *PostHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(post)
+	presentPost(post, mode)
+	policy := cachePolicyItem
+	if post.Status == model.PostPending {
+		policy = cachePolicyPrivate
+	}
+	writeCacheable(w, r, post, post.UpdatedAt, policy)
 }
 
-func (h *PostHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		WriteError(w, "Method not allowed", http.StatusMethodNotAllowed)
+// Update изменяет заголовок и текст поста; прежняя версия остается в истории правок.
+// Версию поста, которую видел клиент, передают в поле version или заголовком If-Match
+// с ETag из GET /posts/{id}; при устаревшей версии ответ 409 содержит актуальную копию
+func (h *PostHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	limit := 10
-	offset := 0
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	var req model.PostUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	if req.Version == 0 && r.Header.Get("If-Match") != "" {
+		current, err := h.postService.GetByID(r.Context(), id, userID)
+		if err != nil {
+			HandleServiceError(w, err)
+			return
+		}
+		if !checkIfMatch(r, postETags(current)...) {
+			presentPost(current, mode)
+			HandleServiceError(w, &apperrors.ConflictError{Current: current})
+			return
 		}
+		req.Version = current.Version
 	}
 
-	posts, total, err := h.postService.GetAll(r.Context(), limit, offset)
+	post, err := h.postService.Update(r.Context(), userID, id, &req)
 	if err != nil {
+		var conflict *apperrors.ConflictError
+		if errors.As(err, &conflict) {
+			if current, ok := conflict.Current.(*model.Post); ok {
+				presentPost(current, mode)
+			}
+		}
 		HandleServiceError(w, err)
 		return
 	}
+	presentPost(post, mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(post)
+}
 
-	type PostsResponse struct {
-		Posts  []*model.Post `json:"posts"`
-		Total  int           `json:"total"`
-		Limit  int           `json:"limit"`
-		Offset int           `json:"offset"`
+// Delete переносит пост в корзину вместе с комментариями; доступно автору поста и редакторам
+func (h *PostHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	resp := PostsResponse{
-		Posts:  posts,
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(resp)
+	if err := h.postService.Delete(r.Context(), userID, id); err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAll отдает ленту постов с фильтрами и сортировкой. По умолчанию используется пагинация
+// по курсору; передача offset включает прежний режим со смещением и общим числом постов
+func (h *PostHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parsePostFilter(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	h.listPage(w, r, filter)
 }
 
 func (h *PostHandler) GetByAuthor(w http.ResponseWriter, r *http.Request) {
@@ -144,44 +214,59 @@ func (h *PostHandler) GetByAuthor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	limit := 10
-	offset := 0
+	filter, err := parsePostFilter(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	filter.AuthorID = authorID
+
+	h.listPage(w, r, filter)
+}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+// listPage отвечает страницей ленты. Посты отдаются в представлении для списков (без полного
+// текста) или только с полями из fields. В режиме курсоров курсоры соседних страниц передаются
+// в теле ответа и в заголовке Link, а total считается только с include_total
+func (h *PostHandler) listPage(w http.ResponseWriter, r *http.Request, filter model.PostFilter) {
+	fields, err := parsePostFields(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+	// Курсор действителен только для той же выборки и того же порядка
+	scope := "posts?" + filter.Key()
+	page, ok := readPageRequest(w, r, h.cursors, scope, 10)
+	if !ok {
+		return
 	}
 
-	posts, total, err := h.postService.GetByAuthor(r.Context(), authorID, limit, offset)
+	posts, info, err := h.postService.ListPage(r.Context(), filter, page)
 	if err != nil {
 		HandleServiceError(w, err)
 		return
 	}
 
-	type PostsResponse struct {
-		Posts    []*model.Post `json:"posts"`
-		Total    int           `json:"total"`
-		Limit    int           `json:"limit"`
-		Offset   int           `json:"offset"`
-		AuthorID int           `json:"author_id"`
+	resp := struct {
+		Posts    any  `json:"posts"`
+		Total    *int `json:"total,omitempty"`
+		Limit    int  `json:"limit"`
+		Offset   *int `json:"offset,omitempty"`
+		AuthorID int  `json:"author_id,omitempty"`
+		pageCursors
+	}{
+		Posts:    postListView(posts, fields),
+		Total:    info.Total,
+		Limit:    page.Limit,
+		AuthorID: filter.AuthorID,
 	}
-
-	resp := PostsResponse{
-		Posts:    posts,
-		Total:    total,
-		Limit:    limit,
-		Offset:   offset,
-		AuthorID: authorID,
+	if usesOffset(r) {
+		resp.Offset = &page.Offset
+	} else {
+		resp.pageCursors = writePageLinks(w, r, h.cursors, scope, info)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(resp)
+	// Last-Modified у страницы списка не выставляется: новый пост сдвигает страницы,
+	// не меняя дат их записей, поэтому актуальность списка проверяется только по ETag
+	writeCacheable(w, r, resp, time.Time{}, cachePolicyList)
 }
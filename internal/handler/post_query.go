@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxTitlePrefixLength совпадает с максимальной длиной заголовка поста
+const maxTitlePrefixLength = 200
+
+// parsePostFilter разбирает параметры фильтрации и сортировки ленты постов:
+//
+//	sort=-created_at,title      поля через запятую, "-" - по убыванию
+//	created_after, created_before  RFC 3339 или YYYY-MM-DD; created_before не включается
+//	author=ID, title_prefix=..., has_comments=true|false
+//
+// Все ошибки собираются сразу и возвращаются как apperrors.FieldErrors
+func parsePostFilter(r *http.Request) (model.PostFilter, error) {
+	q := r.URL.Query()
+	var filter model.PostFilter
+	fields := apperrors.FieldErrors{}
+
+	if sort := q.Get("sort"); sort != "" {
+		seen := map[string]bool{}
+		for _, item := range strings.Split(sort, ",") {
+			field, desc := strings.CutPrefix(strings.TrimSpace(item), "-")
+			switch {
+			case !slices.Contains(model.PostSortFields, field):
+				fields["sort"] = fmt.Sprintf("unknown field %q, allowed: %s", field, strings.Join(model.PostSortFields, ", "))
+			case seen[field]:
+				fields["sort"] = fmt.Sprintf("duplicate field %q", field)
+			default:
+				seen[field] = true
+				filter.Sort = append(filter.Sort, model.SortField{Field: field, Desc: desc})
+			}
+		}
+	}
+
+	for name, dst := range map[string]*time.Time{"created_after": &filter.CreatedAfter, "created_before": &filter.CreatedBefore} {
+		if v := q.Get(name); v != "" {
+			t, err := parseFilterTime(v)
+			if err != nil {
+				fields[name] = "expected RFC 3339 timestamp or YYYY-MM-DD date"
+				continue
+			}
+			*dst = t
+		}
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		fields["created_before"] = "must be later than created_after"
+	}
+
+	if author := q.Get("author"); author != "" {
+		id, err := strconv.Atoi(author)
+		if err != nil || id <= 0 {
+			fields["author"] = "expected positive user ID"
+		}
+		filter.AuthorID = id
+	}
+
+	if prefix := q.Get("title_prefix"); prefix != "" {
+		if utf8.RuneCountInString(prefix) > maxTitlePrefixLength {
+			fields["title_prefix"] = fmt.Sprintf("must be at most %d characters", maxTitlePrefixLength)
+		}
+		filter.TitlePrefix = prefix
+	}
+
+	if hasComments := q.Get("has_comments"); hasComments != "" {
+		v, err := strconv.ParseBool(hasComments)
+		if err != nil {
+			fields["has_comments"] = "expected true or false"
+		}
+		filter.HasComments = &v
+	}
+
+	if len(fields) > 0 {
+		return model.PostFilter{}, fields
+	}
+	return filter, nil
+}
+
+func parseFilterTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
+
+// postListFields - поля, которые можно запросить в списке постов через fields, и их значения.
+// Ключ совпадает с именем поля в JSON
+var postListFields = map[string]func(item model.PostListItem, p *model.Post) any{
+	"id":             func(item model.PostListItem, p *model.Post) any { return item.ID },
+	"title":          func(item model.PostListItem, p *model.Post) any { return item.Title },
+	"excerpt":        func(item model.PostListItem, p *model.Post) any { return item.Excerpt },
+	"summary":        func(item model.PostListItem, p *model.Post) any { return p.Summary },
+	"content":        func(item model.PostListItem, p *model.Post) any { return p.Content },
+	"content_format": func(item model.PostListItem, p *model.Post) any { return p.ContentFormat },
+	"content_html": func(item model.PostListItem, p *model.Post) any {
+		return renderedHTML(p.ContentFormat, p.Content, p.ContentHTML)
+	},
+	"author_id":            func(item model.PostListItem, p *model.Post) any { return item.AuthorID },
+	"created_at":           func(item model.PostListItem, p *model.Post) any { return item.CreatedAt },
+	"word_count":           func(item model.PostListItem, p *model.Post) any { return item.WordCount },
+	"reading_time_minutes": func(item model.PostListItem, p *model.Post) any { return item.ReadingTimeMinutes },
+}
+
+// postFieldAliases - короткие имена полей для параметра fields
+var postFieldAliases = map[string]string{
+	"author":       "author_id",
+	"reading_time": "reading_time_minutes",
+}
+
+// parsePostFields разбирает fields=id,title,author. Пустой параметр означает стандартное
+// представление списка (model.PostListItem)
+func parsePostFields(r *http.Request) ([]string, error) {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if alias, ok := postFieldAliases[name]; ok {
+			name = alias
+		}
+		if _, ok := postListFields[name]; !ok {
+			allowed := slices.Sorted(maps.Keys(postListFields))
+			return nil, apperrors.FieldErrors{"fields": fmt.Sprintf("unknown field %q, allowed: %s", name, strings.Join(allowed, ", "))}
+		}
+		if !slices.Contains(fields, name) {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// postListView строит представление списка: стандартное или только с запрошенными полями
+func postListView(posts []*model.Post, fields []string) any {
+	if fields == nil {
+		items := make([]model.PostListItem, len(posts))
+		for i, p := range posts {
+			items[i] = model.NewPostListItem(p)
+		}
+		return items
+	}
+
+	items := make([]map[string]any, len(posts))
+	for i, p := range posts {
+		item := model.NewPostListItem(p)
+		items[i] = make(map[string]any, len(fields))
+		for _, name := range fields {
+			items[i][name] = postListFields[name](item, p)
+		}
+	}
+	return items
+}
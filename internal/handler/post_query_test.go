@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParsePostFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/posts?sort=-created_at,title&created_after=2024-01-01&created_before=2024-02-01T00:00:00%2B03:00&author=3&title_prefix=Go&has_comments=true", nil)
+
+	filter, err := parsePostFilter(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	wantSort := []model.SortField{{Field: "created_at", Desc: true}, {Field: "title"}}
+	if len(filter.Sort) != 2 || filter.Sort[0] != wantSort[0] || filter.Sort[1] != wantSort[1] {
+		t.Errorf("unexpected sort %+v", filter.Sort)
+	}
+	if !filter.CreatedAfter.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+		!filter.CreatedBefore.Equal(time.Date(2024, 1, 31, 21, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date range %v - %v", filter.CreatedAfter, filter.CreatedBefore)
+	}
+	if filter.AuthorID != 3 || filter.TitlePrefix != "Go" || filter.HasComments == nil || !*filter.HasComments {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+}
+
+func TestParsePostFilter_FieldErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/posts?sort=title,password&created_after=yesterday&author=-1&has_comments=maybe", nil)
+
+	_, err := parsePostFilter(req)
+	var fields apperrors.FieldErrors
+	if !errors.As(err, &fields) {
+		t.Fatalf("expected FieldErrors, got %v", err)
+	}
+	for _, name := range []string{"sort", "created_after", "author", "has_comments"} {
+		if fields[name] == "" {
+			t.Errorf("expected error for %s, got %v", name, fields)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	HandleServiceError(rec, err)
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadRequest || len(resp.Fields) != 4 {
+		t.Errorf("expected 400 with 4 field errors, got %d %v", rec.Code, resp.Fields)
+	}
+}
+
+func TestPostListView_SparseFields(t *testing.T) {
+	posts := []*model.Post{{ID: 1, Title: "Title", Content: "Full text", AuthorID: 7}}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/posts?fields=id,title,author,id", nil)
+	fields, err := parsePostFields(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	items := postListView(posts, fields).([]map[string]any)
+	if len(items[0]) != 3 || items[0]["id"] != 1 || items[0]["title"] != "Title" || items[0]["author_id"] != 7 {
+		t.Errorf("unexpected sparse item %v", items[0])
+	}
+
+	full := postListView(posts, nil).([]model.PostListItem)
+	if full[0].Excerpt != "Full text" || full[0].WordCount != 2 {
+		t.Errorf("unexpected list item %+v", full[0])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/posts?fields=id,password", nil)
+	var fieldErrs apperrors.FieldErrors
+	if _, err := parsePostFields(req); !errors.As(err, &fieldErrs) || fieldErrs["fields"] == "" {
+		t.Errorf("expected field error for unknown field, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListRevisions отдает историю правок поста от новых версий к старым, без текста
+func (h *PostHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 20
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	requestorID, _ := middleware.GetUserIDFromContext(r.Context())
+	revisions, err := h.postService.ListRevisions(r.Context(), id, requestorID, limit, offset)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	if revisions == nil {
+		revisions = []*model.PostRevision{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"revisions": revisions,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// GetRevision отдает версию поста целиком. Версии не меняются, поэтому ответ кешируется;
+// версии поста на модерации видны только автору и модераторам
+func (h *PostHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+	revision, ok := parseRevision(w, r)
+	if !ok {
+		return
+	}
+
+	requestorID, _ := middleware.GetUserIDFromContext(r.Context())
+	rev, err := h.postService.GetRevision(r.Context(), id, revision, requestorID)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	writeCacheable(w, r, rev, rev.CreatedAt, requestorCachePolicy(requestorID, cachePolicyItem))
+}
+
+// DiffRevisions отдает построчный diff текста между версиями from и to
+func (h *PostHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	fields := apperrors.FieldErrors{}
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil || from <= 0 {
+		fields["from"] = "expected positive revision number"
+	}
+	to, err := strconv.Atoi(query.Get("to"))
+	if err != nil || to <= 0 {
+		fields["to"] = "expected positive revision number"
+	}
+	if len(fields) > 0 {
+		WriteFieldErrors(w, fields)
+		return
+	}
+
+	requestorID, _ := middleware.GetUserIDFromContext(r.Context())
+	d, err := h.postService.DiffRevisions(r.Context(), id, from, to, requestorID)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	writeCacheable(w, r, d, time.Time{}, requestorCachePolicy(requestorID, cachePolicyItem))
+}
+
+// RestoreRevision возвращает посту заголовок и текст выбранной версии; доступно автору
+// поста и редакторам
+func (h *PostHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+	revision, ok := parseRevision(w, r)
+	if !ok {
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	post, err := h.postService.RestoreRevision(r.Context(), userID, id, revision)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	presentPost(post, mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(post)
+}
+
+// parsePostID читает ID поста из пути; при ошибке сам отвечает 400
+func parsePostID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		WriteError(w, "Invalid post ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func parseRevision(w http.ResponseWriter, r *http.Request) (int, bool) {
+	revision, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil || revision <= 0 {
+		WriteError(w, "Invalid revision number", http.StatusBadRequest)
+		return 0, false
+	}
+	return revision, true
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/render"
+	"fmt"
+	"net/http"
+)
+
+// Представления текста в ответах: raw - исходный текст в content, html - безопасный HTML
+// в content_html
+const (
+	renderRaw  = "raw"
+	renderHTML = "html"
+)
+
+// parseRenderMode разбирает параметр render; по умолчанию отдается исходный текст
+func parseRenderMode(r *http.Request) (string, error) {
+	switch mode := r.URL.Query().Get("render"); mode {
+	case "", renderRaw:
+		return renderRaw, nil
+	case renderHTML:
+		return renderHTML, nil
+	default:
+		return "", apperrors.FieldErrors{"render": "expected html or raw"}
+	}
+}
+
+// renderedHTML возвращает сохраненный HTML; для записей, созданных до появления content_html,
+// он строится при чтении
+func renderedHTML(format, content, html string) string {
+	if html != "" || content == "" {
+		return html
+	}
+	if format == "" {
+		format = model.ContentFormatPlain
+	}
+	html, err := render.HTML(format, content)
+	if err != nil {
+		return ""
+	}
+	return html
+}
+
+// presentPost оставляет в посте одно представление текста. Пост изменяется на месте:
+// сервис возвращает копии, поэтому закешированные объекты не затрагиваются
+func presentPost(p *model.Post, mode string) {
+	if mode == renderHTML {
+		p.ContentHTML = renderedHTML(p.ContentFormat, p.Content, p.ContentHTML)
+		p.Content = ""
+		return
+	}
+	p.ContentHTML = ""
+}
+
+// presentComments оставляет в комментариях одно представление текста
+func presentComments(comments []*model.Comment, mode string) {
+	for _, c := range comments {
+		if mode == renderHTML {
+			c.ContentHTML = renderedHTML(c.ContentFormat, c.Content, c.ContentHTML)
+			c.Content = ""
+			continue
+		}
+		c.ContentHTML = ""
+	}
+}
+
+// postETags возвращает ETag, которые GET /posts/{id} отдает для поста в каждом из представлений
+// (raw и html); If-Match правки сверяется с любым из них
+func postETags(post *model.Post) []string {
+	etags := make([]string, 0, 2)
+	for _, mode := range []string{renderRaw, renderHTML} {
+		p := *post
+		presentPost(&p, mode)
+		if etag, err := representationETag(&p); err == nil {
+			etags = append(etags, etag)
+		}
+	}
+	return etags
+}
+
+// commentETag возвращает ETag комментария для If-Match. Отдельного GET у комментариев нет,
+// а время в ответе на запись и в прочитанной из БД копии может отличаться точностью,
+// поэтому ETag строится по ID и версии, которая меняется при каждой правке
+func commentETag(comment *model.Comment) string {
+	return fmt.Sprintf(`"comment-%d-v%d"`, comment.ID, comment.Version)
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/metrics"
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
+	"advanced-blog-management-system/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// streamReplayPage - сколько записей догружается из БД за один запрос при переподключении
+	streamReplayPage = 100
+	// streamWriteTimeout - сколько ждать записи одного сообщения, прежде чем считать клиента отвалившимся
+	streamWriteTimeout = 10 * time.Second
+	// streamRetryMS - рекомендуемая клиенту задержка переподключения
+	streamRetryMS = 3000
+)
+
+// replayFunc возвращает до streamReplayPage событий темы с номером больше afterID в порядке номеров
+type replayFunc func(ctx context.Context, afterID int64) ([]pubsub.Message, error)
+
+// StreamHandler отдает новые посты и комментарии в формате Server-Sent Events
+type StreamHandler struct {
+	broker         pubsub.Broker
+	postService    service.PostServiceInterface
+	commentService service.CommentServiceInterface
+	heartbeat      time.Duration
+}
+
+func NewStreamHandler(broker pubsub.Broker, postService service.PostServiceInterface, commentService service.CommentServiceInterface, heartbeat time.Duration) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &StreamHandler{
+		broker:         broker,
+		postService:    postService,
+		commentService: commentService,
+		heartbeat:      heartbeat,
+	}
+}
+
+// Posts - поток новых постов
+func (h *StreamHandler) Posts(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, service.TopicPosts, model.EventPostCreated, func(ctx context.Context, afterID int64) ([]pubsub.Message, error) {
+		return h.postService.StreamAfter(ctx, afterID, streamReplayPage)
+	})
+}
+
+// Comments - поток новых комментариев поста
+func (h *StreamHandler) Comments(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(chi.URLParam(r, "postId"))
+	if err != nil {
+		WriteError(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	requestorID, _ := middleware.GetUserIDFromContext(r.Context())
+	h.serve(w, r, service.CommentsTopic(postID), model.EventCommentCreated, func(ctx context.Context, afterID int64) ([]pubsub.Message, error) {
+		return h.commentService.StreamAfter(ctx, postID, requestorID, afterID, streamReplayPage)
+	})
+}
+
+// serve подписывается на тему, догружает из журнала все, что клиент пропустил после Last-Event-ID,
+// и затем пересылает сообщения хаба. Подписка оформляется до догрузки, поэтому сообщения,
+// опубликованные во время нее, не теряются, а дубликаты отсекаются по номеру события.
+// Номера в теме идут без пропусков в порядке фиксации: если сообщение хаба пришло раньше
+// предыдущего (другая транзакция или реплика еще не доставила свое), недостающее догружается из журнала.
+// В тему также публикуются изменения и удаления; поток SSE передает только события created
+func (h *StreamHandler) serve(w http.ResponseWriter, r *http.Request, topic string, created model.EventType, replay replayFunc) {
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	lastID, err := lastEventID(r)
+	if err != nil {
+		WriteError(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.broker.Subscribe(topic)
+	if err != nil {
+		WriteError(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	// Первая страница запрашивается до отправки заголовков, чтобы вернуть обычную ошибку (например, 404)
+	backlog, err := replay(ctx, lastID)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	metrics.StreamConnections.Inc()
+	defer metrics.StreamConnections.Dec()
+
+	log := logger.FromContext(ctx).With("topic", topic)
+
+	write := func(format string, args ...any) bool {
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	// send сдвигает lastID на каждом событии темы, но клиенту отправляет только события created
+	send := func(msg pubsub.Message) bool {
+		if msg.ID <= lastID {
+			return true
+		}
+		lastID = msg.ID
+		if msg.Event != string(created) {
+			return true
+		}
+		return write("id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Event, msg.Data)
+	}
+	sendAll := func(messages []pubsub.Message) bool {
+		for _, msg := range messages {
+			if !send(msg) {
+				return false
+			}
+		}
+		return true
+	}
+	// catchUp догружает из журнала все события после lastID
+	catchUp := func() bool {
+		for {
+			backlog, err := replay(ctx, lastID)
+			if err != nil {
+				log.Error("failed to replay stream", "error", err)
+				return false
+			}
+			if !sendAll(backlog) {
+				return false
+			}
+			if len(backlog) < streamReplayPage {
+				return true
+			}
+		}
+	}
+
+	if !write("retry: %d\n\n", streamRetryMS) {
+		return
+	}
+
+	if !sendAll(backlog) {
+		return
+	}
+	if len(backlog) == streamReplayPage && !catchUp() {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				// Хаб закрыт при остановке сервера или клиент не успевал читать;
+				// клиент переподключится и догрузит пропущенное по Last-Event-ID
+				if errors.Is(sub.Err(), pubsub.ErrSlowConsumer) {
+					log.Warn("stream client too slow, disconnecting", "last_event_id", lastID)
+				}
+				return
+			}
+			if msg.ID > lastID+1 && !catchUp() {
+				return
+			}
+			if !send(msg) {
+				return
+			}
+		}
+	}
+}
+
+// lastEventID читает заголовок Last-Event-ID, который браузер отправляет при переподключении,
+// или параметр last_event_id для первого подключения
+func lastEventID(r *http.Request) (int64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid last event id")
+	}
+	return id, nil
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
+	"advanced-blog-management-system/internal/service"
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// streamLog is an in-memory stream journal shared by the stub services
+type streamLog struct {
+	mu       sync.Mutex
+	messages []pubsub.Message
+}
+
+func (l *streamLog) append(msgs ...pubsub.Message) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msgs...)
+}
+
+func (l *streamLog) after(afterID int64, limit int) []pubsub.Message {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var result []pubsub.Message
+	for _, msg := range l.messages {
+		if msg.ID > afterID && len(result) < limit {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// stubPostService implements PostServiceInterface; only StreamAfter is used by the stream handler
+type stubPostService struct {
+	service.PostServiceInterface
+	log streamLog
+}
+
+func (s *stubPostService) StreamAfter(ctx context.Context, afterID int64, limit int) ([]pubsub.Message, error) {
+	return s.log.after(afterID, limit), nil
+}
+
+// stubCommentService implements CommentServiceInterface; only StreamAfter is used by the stream handler
+type stubCommentService struct {
+	service.CommentServiceInterface
+}
+
+func (s *stubCommentService) StreamAfter(ctx context.Context, postID, requestorID int, afterID int64, limit int) ([]pubsub.Message, error) {
+	return nil, apperrors.ErrPostNotFound
+}
+
+// postCreated builds a post.created stream message with the given sequence number
+func postCreated(id int64, data string) pubsub.Message {
+	return pubsub.Message{ID: id, Event: string(model.EventPostCreated), Data: []byte(data)}
+}
+
+func newStreamServer(t *testing.T, hub *pubsub.Hub, posts *stubPostService) *httptest.Server {
+	t.Helper()
+	h := NewStreamHandler(hub, posts, &stubCommentService{}, 50*time.Millisecond)
+	router := chi.NewRouter()
+	router.Get("/posts/stream", h.Posts)
+	router.Get("/posts/{postId}/comments/stream", h.Comments)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// readUntil reads SSE lines until one has the given prefix
+func readUntil(t *testing.T, r *bufio.Reader, prefix string) string {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended before %q: %v", prefix, err)
+		}
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(line)
+		}
+	}
+}
+
+func TestStreamHandler_ResumesFromLastEventIDThenStreamsLive(t *testing.T) {
+	hub := pubsub.NewHub(8)
+	posts := &stubPostService{}
+	posts.log.append(postCreated(1, `{"id":1}`), postCreated(2, `{"id":2}`), postCreated(3, `{"id":3}`))
+	server := newStreamServer(t, hub, posts)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/posts/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if id := readUntil(t, reader, "id:"); id != "id: 2" {
+		t.Errorf("expected replay to start after Last-Event-ID, got %q", id)
+	}
+	if id := readUntil(t, reader, "id:"); id != "id: 3" {
+		t.Errorf("expected second replayed post, got %q", id)
+	}
+
+	// A message already sent during replay must not be repeated
+	posts.log.append(postCreated(4, `{"id":4}`))
+	hub.Publish(service.TopicPosts, postCreated(3, `{}`))
+	hub.Publish(service.TopicPosts, postCreated(4, `{"id":4}`))
+
+	if id := readUntil(t, reader, "id:"); id != "id: 4" {
+		t.Errorf("expected live post 4, got %q", id)
+	}
+	if event := readUntil(t, reader, "event:"); event != "event: post.created" {
+		t.Errorf("unexpected event line %q", event)
+	}
+	if data := readUntil(t, reader, "data:"); data != `data: {"id":4}` {
+		t.Errorf("unexpected data line %q", data)
+	}
+
+	readUntil(t, reader, ": ping")
+}
+
+func TestStreamHandler_FillsGapFromJournal(t *testing.T) {
+	hub := pubsub.NewHub(8)
+	posts := &stubPostService{}
+	posts.log.append(postCreated(1, `{"id":1}`))
+	server := newStreamServer(t, hub, posts)
+
+	resp, err := http.Get(server.URL + "/posts/stream")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	if id := readUntil(t, reader, "id:"); id != "id: 1" {
+		t.Fatalf("expected backlog event 1, got %q", id)
+	}
+
+	// Transactions took sequence numbers 2 and 3 in commit order, but the one holding 3
+	// reached the hub first; an update event in between is skipped by SSE yet still counted
+	posts.log.append(
+		pubsub.Message{ID: 2, Event: string(model.EventPostUpdated), Data: []byte(`{"id":1}`)},
+		postCreated(3, `{"id":3}`),
+		postCreated(4, `{"id":4}`),
+	)
+	hub.Publish(service.TopicPosts, postCreated(4, `{"id":4}`))
+	hub.Publish(service.TopicPosts, postCreated(3, `{"id":3}`))
+
+	if id := readUntil(t, reader, "id:"); id != "id: 3" {
+		t.Errorf("expected gap to be filled with event 3, got %q", id)
+	}
+	if id := readUntil(t, reader, "id:"); id != "id: 4" {
+		t.Errorf("expected event 4 after the gap, got %q", id)
+	}
+
+	// The late copy of event 3 must not be repeated
+	posts.log.append(postCreated(5, `{"id":5}`))
+	hub.Publish(service.TopicPosts, postCreated(5, `{"id":5}`))
+	if id := readUntil(t, reader, "id:"); id != "id: 5" {
+		t.Errorf("expected event 5 without duplicates, got %q", id)
+	}
+}
+
+func TestStreamHandler_HubCloseEndsStream(t *testing.T) {
+	hub := pubsub.NewHub(8)
+	server := newStreamServer(t, hub, &stubPostService{})
+
+	resp, err := http.Get(server.URL + "/posts/stream")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	readUntil(t, reader, "retry:")
+
+	hub.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected stream to end after hub close")
+	}
+}
+
+func TestStreamHandler_UnknownPostReturns404(t *testing.T) {
+	server := newStreamServer(t, pubsub.NewHub(8), &stubPostService{})
+
+	resp, err := http.Get(server.URL + "/posts/42/comments/stream")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListDeleted отдает посты из корзины: редакторам - все, остальным - собственные
+func (h *PostHandler) ListDeleted(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	limit, offset := readTrashPage(r)
+
+	posts, err := h.postService.ListDeleted(r.Context(), userID, limit, offset)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	if posts == nil {
+		posts = []*model.Post{}
+	}
+	for _, p := range posts {
+		presentPost(p, mode)
+	}
+
+	writeTrashPage(w, "posts", posts, limit, offset)
+}
+
+// Restore возвращает пост из корзины вместе с комментариями, удаленными одновременно с ним
+func (h *PostHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	post, err := h.postService.Restore(r.Context(), userID, id)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	presentPost(post, mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(post)
+}
+
+// ListDeleted отдает комментарии текущего пользователя из корзины
+func (h *CommentHandler) ListDeleted(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	limit, offset := readTrashPage(r)
+
+	comments, err := h.commentService.ListDeleted(r.Context(), userID, limit, offset)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	if comments == nil {
+		comments = []*model.Comment{}
+	}
+	presentComments(comments, mode)
+
+	writeTrashPage(w, "comments", comments, limit, offset)
+}
+
+// Restore возвращает комментарий из корзины; доступно только автору
+func (h *CommentHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	commentID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		WriteError(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := parseRenderMode(r)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	comment, err := h.commentService.Restore(r.Context(), userID, commentID)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+	presentComments([]*model.Comment{comment}, mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(comment)
+}
+
+// DeleteUser переносит пользователя в корзину вместе с его постами и комментариями
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		WriteError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.Delete(r.Context(), actorID, userID); err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeletedUsers отдает пользователей из корзины
+func (h *AdminHandler) ListDeletedUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset := readTrashPage(r)
+
+	users, err := h.userService.ListDeleted(r.Context(), limit, offset)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	resp := make([]model.UserResponse, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, u.ToResponse())
+	}
+
+	writeTrashPage(w, "users", resp, limit, offset)
+}
+
+// RestoreUser возвращает пользователя из корзины вместе с постами и комментариями,
+// удаленными одновременно с ним
+func (h *AdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		WriteError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.Restore(r.Context(), actorID, userID)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(user.ToResponse())
+}
+
+// readTrashPage читает limit и offset списка корзины; некорректные значения заменяются умолчаниями
+func readTrashPage(r *http.Request) (limit, offset int) {
+	limit = 20
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset
+}
+
+func writeTrashPage(w http.ResponseWriter, key string, items any, limit, offset int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		key:      items,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/middleware"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -11,17 +12,49 @@ import (
 
 // ErrorResponse - структура для ответа с ошибкой
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error     string `json:"error"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	// Fields - ошибки отдельных полей или параметров запроса
+	Fields map[string]string `json:"fields,omitempty"`
+	// Current - актуальная копия записи при конфликте версий
+	Current any `json:"current,omitempty"`
 }
 
-// WriteError отправляет JSON-ответ с ошибкой
+// WriteError отправляет JSON-ответ с ошибкой.
+// RequestID берется из заголовка ответа, который выставляет middleware.RequestID
 func WriteError(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(ErrorResponse{
-		Error:   http.StatusText(statusCode),
-		Message: message,
+		Error:     http.StatusText(statusCode),
+		Message:   message,
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+	})
+}
+
+// WriteFieldErrors отправляет ответ 400 с ошибками отдельных полей запроса
+func WriteFieldErrors(w http.ResponseWriter, fields apperrors.FieldErrors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:     http.StatusText(http.StatusBadRequest),
+		Message:   "Invalid request parameters",
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+		Fields:    fields,
+	})
+}
+
+// writeConflict отправляет ответ 409 с актуальной копией записи, чтобы клиент мог
+// объединить свои правки и повторить запрос с новой версией
+func writeConflict(w http.ResponseWriter, current any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:     http.StatusText(http.StatusConflict),
+		Message:   "Resource was modified by another request",
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+		Current:   current,
 	})
 }
 
@@ -32,6 +65,18 @@ func HandleServiceError(w http.ResponseWriter, err error) {
 		return
 	}
 
+	var fields apperrors.FieldErrors
+	if errors.As(err, &fields) {
+		WriteFieldErrors(w, fields)
+		return
+	}
+
+	var conflict *apperrors.ConflictError
+	if errors.As(err, &conflict) {
+		writeConflict(w, conflict.Current)
+		return
+	}
+
 	switch {
 	case errors.Is(err, apperrors.ErrUserAlreadyExists):
 		WriteError(w, "User already exists", http.StatusConflict)
@@ -41,6 +86,26 @@ func HandleServiceError(w http.ResponseWriter, err error) {
 		WriteError(w, "Post not found", http.StatusNotFound)
 	case errors.Is(err, apperrors.ErrCommentNotFound):
 		WriteError(w, "Comment not found", http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrRevisionNotFound):
+		WriteError(w, "Revision not found", http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrContentRejected):
+		WriteError(w, "Content rejected by spam filter", http.StatusUnprocessableEntity)
+	case errors.Is(err, apperrors.ErrConflict):
+		WriteError(w, "Resource was modified by another request", http.StatusConflict)
+	case errors.Is(err, apperrors.ErrParentDeleted):
+		WriteError(w, "Cannot restore: the parent resource is deleted", http.StatusConflict)
+	case errors.Is(err, apperrors.ErrUserNotFound):
+		WriteError(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrOutboxEventNotFound):
+		WriteError(w, "Outbox event not found or not in dead-letter state", http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrWebhookNotFound):
+		WriteError(w, "Webhook not found", http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrDeliveryNotFound):
+		WriteError(w, "Webhook delivery not found", http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrNotificationNotFound):
+		WriteError(w, "Notification not found", http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrInvalidCursor):
+		WriteError(w, "Invalid cursor", http.StatusBadRequest)
 	case errors.Is(err, apperrors.ErrForbidden):
 		WriteError(w, "Forbidden", http.StatusForbidden)
 	case errors.Is(err, apperrors.ErrUnauthorized):
@@ -0,0 +1,287 @@
+package live
+
+import (
+	"advanced-blog-management-system/internal/metrics"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
+	"advanced-blog-management-system/internal/service"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// typingInterval - не чаще одного события набора текста от подключения за этот интервал
+	typingInterval = 2 * time.Second
+	// replayLimit - сколько событий догружается из журнала за один запрос
+	replayLimit = 100
+)
+
+// client - одно WebSocket-подключение. Читает сообщения в readLoop, а все исходящие сообщения
+// проходят через очередь send, которую разбирает единственный writeLoop
+type client struct {
+	id     string
+	server *Server
+	conn   *websocket.Conn
+	viewer Viewer
+
+	send      chan ServerMessage
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	subs       map[int]*pubsub.Subscription
+	lastTyping time.Time
+}
+
+func newClient(s *Server, conn *websocket.Conn, viewer Viewer) *client {
+	return &client{
+		id:     newConnID(),
+		server: s,
+		conn:   conn,
+		viewer: viewer,
+		send:   make(chan ServerMessage, s.cfg.SendBuffer),
+		done:   make(chan struct{}),
+		subs:   make(map[int]*pubsub.Subscription),
+	}
+}
+
+// enqueue ставит сообщение в очередь без блокировки; клиент, чья очередь заполнена, отключается
+func (c *client) enqueue(msg ServerMessage) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.server.logger(c).Warn("live client too slow, disconnecting")
+		c.close()
+	}
+}
+
+func (c *client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *client) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.server.cfg.WriteTimeout))
+			if err := websocket.JSON.Send(c.conn, msg); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (c *client) readLoop() {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(c.server.cfg.ReadTimeout))
+
+		var msg ClientMessage
+		if err := websocket.JSON.Receive(c.conn, &msg); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				c.enqueue(ServerMessage{Type: msgError, Message: "Invalid message"})
+				continue
+			}
+			return
+		}
+
+		c.handle(msg)
+	}
+}
+
+func (c *client) handle(msg ClientMessage) {
+	switch msg.Type {
+	case msgPing:
+		c.enqueue(ServerMessage{Type: msgPong, Ref: msg.Ref})
+	case msgSubscribe:
+		c.subscribe(msg)
+	case msgUnsubscribe:
+		c.unsubscribe(msg)
+	case msgComment:
+		c.comment(msg)
+	case msgTyping:
+		c.typing(msg)
+	default:
+		c.enqueue(ServerMessage{Type: msgError, Ref: msg.Ref, Message: "Unknown message type"})
+	}
+}
+
+// subscribe подписывает клиента на события комментариев поста и добавляет его в список зрителей
+func (c *client) subscribe(msg ClientMessage) {
+	ctx := c.conn.Request().Context()
+
+	if _, err := c.server.posts.GetByID(ctx, msg.PostID, c.viewer.UserID); err != nil {
+		c.enqueue(errorMessage(msg.Ref, err))
+		return
+	}
+
+	c.mu.Lock()
+	_, already := c.subs[msg.PostID]
+	c.mu.Unlock()
+	if already {
+		c.enqueue(ServerMessage{Type: msgSubscribed, PostID: msg.PostID, Ref: msg.Ref, Viewers: c.server.presence.viewers(msg.PostID)})
+		return
+	}
+
+	sub, err := c.server.broker.Subscribe(service.CommentsTopic(msg.PostID))
+	if err != nil {
+		c.enqueue(errorMessage(msg.Ref, err))
+		return
+	}
+
+	c.mu.Lock()
+	c.subs[msg.PostID] = sub
+	c.mu.Unlock()
+
+	c.server.watch(msg.PostID, c)
+	c.enqueue(ServerMessage{Type: msgSubscribed, PostID: msg.PostID, Ref: msg.Ref, Viewers: c.server.presence.viewers(msg.PostID)})
+
+	go c.forward(ctx, msg.PostID, sub, msg.AfterID, msg.Ref)
+}
+
+// forward догружает события после afterID и пересылает клиенту события комментариев поста до отписки.
+// Подписка на брокер оформлена до догрузки, поэтому новые события не теряются, а дубликаты
+// отбрасываются по номеру. Номера в теме идут без пропусков в порядке фиксации: если сообщение хаба
+// обогнало предыдущее, недостающее догружается из журнала. Без after_id отсчет начинается
+// с первого полученного события
+func (c *client) forward(ctx context.Context, postID int, sub *pubsub.Subscription, afterID *int64, ref string) {
+	lastID := int64(-1)
+	if afterID != nil {
+		lastID = max(*afterID, 0)
+		if err := c.catchUp(ctx, postID, &lastID); err != nil {
+			c.enqueue(errorMessage(ref, err))
+		}
+	}
+
+	for m := range sub.Messages() {
+		if lastID >= 0 && m.ID > lastID+1 {
+			if err := c.catchUp(ctx, postID, &lastID); err != nil {
+				c.server.logger(c).Error("failed to replay comment stream", "post_id", postID, "error", err)
+				c.close()
+				return
+			}
+		}
+		c.deliver(postID, m, &lastID)
+	}
+
+	// Подписку закрыл брокер: клиент не успевал читать или сервер останавливается.
+	// Соединение закрывается, клиент переподключится и догрузит пропущенное по after_id
+	if err := sub.Err(); err != nil {
+		if errors.Is(err, pubsub.ErrSlowConsumer) {
+			c.server.logger(c).Warn("live client subscription dropped", "post_id", postID)
+		}
+		c.close()
+	}
+}
+
+// catchUp догружает из журнала все события темы поста после lastID
+func (c *client) catchUp(ctx context.Context, postID int, lastID *int64) error {
+	for {
+		messages, err := c.server.comments.StreamAfter(ctx, postID, c.viewer.UserID, *lastID, replayLimit)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			c.deliver(postID, m, lastID)
+		}
+		if len(messages) < replayLimit {
+			return nil
+		}
+	}
+}
+
+// deliver отправляет событие клиенту, если оно новее lastID, и сдвигает lastID
+func (c *client) deliver(postID int, m pubsub.Message, lastID *int64) {
+	if m.ID <= *lastID {
+		return
+	}
+	*lastID = m.ID
+	c.enqueue(ServerMessage{Type: m.Event, PostID: postID, ID: m.ID, Data: m.Data})
+}
+
+func (c *client) unsubscribe(msg ClientMessage) {
+	c.mu.Lock()
+	sub, ok := c.subs[msg.PostID]
+	delete(c.subs, msg.PostID)
+	c.mu.Unlock()
+
+	if ok {
+		sub.Close()
+		c.server.unwatch(msg.PostID, c)
+	}
+	c.enqueue(ServerMessage{Type: msgUnsubscribed, PostID: msg.PostID, Ref: msg.Ref})
+}
+
+func (c *client) unsubscribeAll() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	postIDs := make([]int, 0, len(c.subs))
+	for postID, sub := range c.subs {
+		sub.Close()
+		postIDs = append(postIDs, postID)
+	}
+	clear(c.subs)
+	return postIDs
+}
+
+// comment публикует комментарий через CommentService.Create с той же валидацией, что и REST API
+func (c *client) comment(msg ClientMessage) {
+	ctx := c.conn.Request().Context()
+
+	req := model.CommentCreateRequest{Content: msg.Content, ContentFormat: msg.ContentFormat, PostID: msg.PostID}
+	if err := req.Validate(); err != nil {
+		c.enqueue(errorMessage(msg.Ref, err))
+		return
+	}
+
+	comment, err := c.server.comments.Create(ctx, c.viewer.UserID, msg.PostID, msg.Content, msg.ContentFormat)
+	if err != nil {
+		c.enqueue(errorMessage(msg.Ref, err))
+		return
+	}
+	metrics.CommentsCreated.Inc()
+
+	data, _ := json.Marshal(comment)
+	c.enqueue(ServerMessage{Type: msgAck, PostID: msg.PostID, Ref: msg.Ref, Data: data})
+}
+
+// typing рассылает индикатор набора текста зрителям поста, на который подписан клиент
+func (c *client) typing(msg ClientMessage) {
+	c.mu.Lock()
+	_, subscribed := c.subs[msg.PostID]
+	now := time.Now()
+	throttled := now.Sub(c.lastTyping) < typingInterval
+	if subscribed && !throttled {
+		c.lastTyping = now
+	}
+	c.mu.Unlock()
+
+	if !subscribed {
+		c.enqueue(ServerMessage{Type: msgError, Ref: msg.Ref, Message: "Not subscribed to post"})
+		return
+	}
+	if !throttled {
+		c.server.publishPresence(presenceTyping, msg.PostID, c)
+	}
+}
+
+func newConnID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
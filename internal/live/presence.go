@@ -0,0 +1,114 @@
+package live
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// presenceTopic - общая тема брокера с событиями присутствия и набора текста всех постов.
+// Каждая реплика подписана на нее один раз, поэтому список зрителей собирается со всех реплик
+const presenceTopic = "live/presence"
+
+// Виды событий присутствия
+const (
+	presenceJoin   = "join"
+	presenceLeave  = "leave"
+	presenceTyping = "typing"
+)
+
+// presenceEvent передается через брокер; ConnID различает вкладки одного пользователя
+type presenceEvent struct {
+	Kind   string `json:"kind"`
+	PostID int    `json:"post_id"`
+	ConnID string `json:"conn_id"`
+	Viewer Viewer `json:"viewer"`
+}
+
+type presenceEntry struct {
+	viewer   Viewer
+	lastSeen time.Time
+}
+
+// presence хранит зрителей постов. Реплики периодически повторяют join для своих подключений,
+// поэтому записи реплики, остановленной без leave, удаляются по истечении TTL
+type presence struct {
+	mu    sync.Mutex
+	posts map[int]map[string]presenceEntry
+}
+
+func newPresence() *presence {
+	return &presence{posts: make(map[int]map[string]presenceEntry)}
+}
+
+// join добавляет или продлевает подключение; возвращает true, если список зрителей изменился
+func (p *presence) join(postID int, connID string, viewer Viewer, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conns := p.posts[postID]
+	if conns == nil {
+		conns = make(map[string]presenceEntry)
+		p.posts[postID] = conns
+	}
+	_, existed := conns[connID]
+	conns[connID] = presenceEntry{viewer: viewer, lastSeen: now}
+	return !existed
+}
+
+// leave удаляет подключение; возвращает true, если оно было в списке
+func (p *presence) leave(postID int, connID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conns := p.posts[postID]
+	if _, ok := conns[connID]; !ok {
+		return false
+	}
+	delete(conns, connID)
+	if len(conns) == 0 {
+		delete(p.posts, postID)
+	}
+	return true
+}
+
+// expire удаляет подключения, не подтвержденные с момента before, и возвращает затронутые посты
+func (p *presence) expire(before time.Time) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var changed []int
+	for postID, conns := range p.posts {
+		removed := false
+		for connID, entry := range conns {
+			if entry.lastSeen.Before(before) {
+				delete(conns, connID)
+				removed = true
+			}
+		}
+		if len(conns) == 0 {
+			delete(p.posts, postID)
+		}
+		if removed {
+			changed = append(changed, postID)
+		}
+	}
+	return changed
+}
+
+// viewers возвращает уникальных пользователей поста, отсортированных по ID
+func (p *presence) viewers(postID int) []Viewer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seen := make(map[int]bool)
+	viewers := []Viewer{}
+	for _, entry := range p.posts[postID] {
+		if !seen[entry.viewer.UserID] {
+			seen[entry.viewer.UserID] = true
+			viewers = append(viewers, entry.viewer)
+		}
+	}
+	slices.SortFunc(viewers, func(a, b Viewer) int { return a.UserID - b.UserID })
+	return viewers
+}
@@ -0,0 +1,86 @@
+package live
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"encoding/json"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Типы сообщений от клиента
+const (
+	// subscribe - подписаться на пост; after_id - номер последнего полученного события темы,
+	// все события после него догружаются из журнала
+	msgSubscribe   = "subscribe"
+	msgUnsubscribe = "unsubscribe"
+	// comment - опубликовать комментарий; ответ приходит как ack или error с тем же ref
+	msgComment = "comment"
+	msgTyping  = "typing"
+	msgPing    = "ping"
+)
+
+// Типы сообщений сервера; события комментариев передаются с типом доменного события
+// (comment.created, comment.updated, comment.deleted)
+const (
+	msgSubscribed   = "subscribed"
+	msgUnsubscribed = "unsubscribed"
+	msgPresence     = "presence"
+	msgAck          = "ack"
+	msgError        = "error"
+	msgPong         = "pong"
+)
+
+// ClientMessage - сообщение от клиента
+type ClientMessage struct {
+	Type    string `json:"type"`
+	PostID  int    `json:"post_id,omitempty"`
+	AfterID *int64 `json:"after_id,omitempty"`
+	Content string `json:"content,omitempty"`
+	// ContentFormat - формат текста комментария: plain (по умолчанию) или markdown
+	ContentFormat string `json:"content_format,omitempty"`
+	// Ref - произвольный идентификатор запроса, который возвращается в ack или error
+	Ref string `json:"ref,omitempty"`
+}
+
+// ServerMessage - сообщение сервера. ID - номер события комментария в теме поста;
+// его клиент передает в after_id при переподключении
+type ServerMessage struct {
+	Type    string          `json:"type"`
+	PostID  int             `json:"post_id,omitempty"`
+	ID      int64           `json:"id,omitempty"`
+	Ref     string          `json:"ref,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Viewers []Viewer        `json:"viewers,omitempty"`
+	User    *Viewer         `json:"user,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// Viewer - пользователь, открывший обсуждение поста
+type Viewer struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+func errorMessage(ref string, err error) ServerMessage {
+	return ServerMessage{Type: msgError, Ref: ref, Message: describeError(err)}
+}
+
+// describeError переводит ошибку сервиса в текст для клиента, не раскрывая внутренние детали
+func describeError(err error) string {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrors):
+		return "Validation error: invalid input"
+	case errors.Is(err, apperrors.ErrPostNotFound):
+		return "Post not found"
+	case errors.Is(err, apperrors.ErrCommentNotFound):
+		return "Comment not found"
+	case errors.Is(err, apperrors.ErrInvalidPostID):
+		return "Invalid post ID"
+	case errors.Is(err, apperrors.ErrForbidden):
+		return "Forbidden"
+	default:
+		return "Internal server error"
+	}
+}
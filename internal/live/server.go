@@ -0,0 +1,337 @@
+package live
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/metrics"
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/pubsub"
+	"advanced-blog-management-system/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Config содержит настройки WebSocket-канала
+type Config struct {
+	// SendBuffer - сколько исходящих сообщений может ждать отправки одному клиенту
+	SendBuffer int
+	// ReadTimeout - клиент, не приславший ни одного сообщения (например, ping) за это время, отключается
+	ReadTimeout time.Duration
+	// WriteTimeout - максимальное время отправки одного сообщения
+	WriteTimeout time.Duration
+	// MaxMessageBytes - максимальный размер сообщения клиента
+	MaxMessageBytes int
+	// PresenceInterval - как часто реплика подтверждает своих зрителей; записи без подтверждения
+	// дольше трех интервалов удаляются
+	PresenceInterval time.Duration
+	// AllowedOrigins - разрешенные значения заголовка Origin; пустой список разрешает любые
+	AllowedOrigins []string
+}
+
+func withDefaults(cfg Config) Config {
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = 64
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = 60 * time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 10 * time.Second
+	}
+	if cfg.MaxMessageBytes <= 0 {
+		cfg.MaxMessageBytes = 16 * 1024
+	}
+	if cfg.PresenceInterval <= 0 {
+		cfg.PresenceInterval = 20 * time.Second
+	}
+	return cfg
+}
+
+// Server обслуживает WebSocket-канал обсуждений: подписку на посты, события комментариев,
+// публикацию комментариев, присутствие и индикатор набора текста. Все рассылки идут через
+// pubsub.Broker, поэтому при замене брокера на межрепличный канал сервер работает без изменений
+type Server struct {
+	broker   pubsub.Broker
+	posts    service.PostServiceInterface
+	comments service.CommentServiceInterface
+	cfg      Config
+	presence *presence
+
+	mu       sync.Mutex
+	clients  map[*client]struct{}
+	watchers map[int]map[*client]struct{}
+	closed   bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	now func() time.Time
+}
+
+func NewServer(broker pubsub.Broker, posts service.PostServiceInterface, comments service.CommentServiceInterface, cfg Config) *Server {
+	return &Server{
+		broker:   broker,
+		posts:    posts,
+		comments: comments,
+		cfg:      withDefaults(cfg),
+		presence: newPresence(),
+		clients:  make(map[*client]struct{}),
+		watchers: make(map[int]map[*client]struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Start подписывается на события присутствия и запускает их обработку
+func (s *Server) Start() error {
+	sub, err := s.broker.Subscribe(presenceTopic)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to presence: %w", err)
+	}
+	go s.run(sub)
+	return nil
+}
+
+// Close закрывает все подключения. http.Server.Shutdown не отслеживает перехваченные
+// соединения, поэтому Close регистрируется через RegisterOnShutdown
+func (s *Server) Close() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		clients := make([]*client, 0, len(s.clients))
+		for c := range s.clients {
+			clients = append(clients, c)
+		}
+		s.mu.Unlock()
+
+		for _, c := range clients {
+			c.close()
+		}
+
+		close(s.stop)
+		<-s.done
+		slog.Info("live channel stopped gracefully")
+	})
+}
+
+// ServeHTTP выполняет WebSocket-рукопожатие. Маршрут должен быть защищен RequireAuth
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	username, _ := middleware.GetUsernameFromContext(r.Context())
+
+	websocket.Server{
+		Handshake: s.checkOrigin,
+		Handler: func(conn *websocket.Conn) {
+			s.serve(conn, Viewer{UserID: userID, Username: username})
+		},
+	}.ServeHTTP(w, r)
+}
+
+func (s *Server) checkOrigin(cfg *websocket.Config, r *http.Request) error {
+	if len(s.cfg.AllowedOrigins) == 0 {
+		return nil
+	}
+	if origin := r.Header.Get("Origin"); slices.Contains(s.cfg.AllowedOrigins, origin) {
+		return nil
+	}
+	return errors.New("origin not allowed")
+}
+
+func (s *Server) serve(conn *websocket.Conn, viewer Viewer) {
+	conn.MaxPayloadBytes = s.cfg.MaxMessageBytes
+	c := newClient(s, conn, viewer)
+
+	if !s.register(c) {
+		return
+	}
+	defer s.unregister(c)
+
+	metrics.LiveConnections.Inc()
+	defer metrics.LiveConnections.Dec()
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+func (s *Server) register(c *client) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.clients[c] = struct{}{}
+	return true
+}
+
+func (s *Server) unregister(c *client) {
+	for _, postID := range c.unsubscribeAll() {
+		s.unwatch(postID, c)
+	}
+	c.close()
+
+	s.mu.Lock()
+	delete(s.clients, c)
+	s.mu.Unlock()
+}
+
+// watch добавляет клиента в список локальных зрителей поста и объявляет его присутствие
+func (s *Server) watch(postID int, c *client) {
+	s.mu.Lock()
+	if s.watchers[postID] == nil {
+		s.watchers[postID] = make(map[*client]struct{})
+	}
+	s.watchers[postID][c] = struct{}{}
+	s.mu.Unlock()
+
+	// Реестр обновляется сразу, чтобы ответ subscribed уже содержал самого клиента;
+	// собственное событие, вернувшееся через брокер, реестр уже не изменит
+	if s.presence.join(postID, c.id, c.viewer, s.now()) {
+		s.sendPresenceExcept(postID, c)
+	}
+	s.publishPresence(presenceJoin, postID, c)
+}
+
+func (s *Server) unwatch(postID int, c *client) {
+	s.mu.Lock()
+	delete(s.watchers[postID], c)
+	if len(s.watchers[postID]) == 0 {
+		delete(s.watchers, postID)
+	}
+	s.mu.Unlock()
+
+	if s.presence.leave(postID, c.id) {
+		s.sendPresenceExcept(postID, c)
+	}
+	s.publishPresence(presenceLeave, postID, c)
+}
+
+func (s *Server) publishPresence(kind string, postID int, c *client) {
+	data, err := json.Marshal(presenceEvent{Kind: kind, PostID: postID, ConnID: c.id, Viewer: c.viewer})
+	if err != nil {
+		slog.Error("failed to encode presence event", "error", err)
+		return
+	}
+	s.broker.Publish(presenceTopic, pubsub.Message{Event: kind, Data: data})
+}
+
+// run применяет события присутствия со всех реплик и периодически подтверждает своих зрителей
+func (s *Server) run(sub *pubsub.Subscription) {
+	defer close(s.done)
+	defer func() { sub.Close() }()
+
+	ticker := time.NewTicker(s.cfg.PresenceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.refreshPresence()
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				if errors.Is(sub.Err(), pubsub.ErrHubClosed) {
+					return
+				}
+				// Обработчик отстал от брокера: переподписываемся, пропущенное восстановит refresh
+				slog.Warn("presence subscription dropped, resubscribing", "error", sub.Err())
+				var err error
+				if sub, err = s.broker.Subscribe(presenceTopic); err != nil {
+					slog.Error("failed to resubscribe to presence", "error", err)
+					return
+				}
+				continue
+			}
+			s.applyPresence(msg)
+		}
+	}
+}
+
+func (s *Server) applyPresence(msg pubsub.Message) {
+	var e presenceEvent
+	if err := json.Unmarshal(msg.Data, &e); err != nil {
+		slog.Error("failed to decode presence event", "error", err)
+		return
+	}
+
+	switch e.Kind {
+	case presenceJoin:
+		if s.presence.join(e.PostID, e.ConnID, e.Viewer, s.now()) {
+			s.sendPresence(e.PostID)
+		}
+	case presenceLeave:
+		if s.presence.leave(e.PostID, e.ConnID) {
+			s.sendPresence(e.PostID)
+		}
+	case presenceTyping:
+		viewer := e.Viewer
+		s.sendToWatchers(e.PostID, ServerMessage{Type: msgTyping, PostID: e.PostID, User: &viewer}, func(c *client) bool {
+			return c.id != e.ConnID
+		})
+	}
+}
+
+func (s *Server) refreshPresence() {
+	s.mu.Lock()
+	type watch struct {
+		postID int
+		c      *client
+	}
+	var local []watch
+	for postID, clients := range s.watchers {
+		for c := range clients {
+			local = append(local, watch{postID, c})
+		}
+	}
+	s.mu.Unlock()
+
+	for _, w := range local {
+		s.publishPresence(presenceJoin, w.postID, w.c)
+	}
+
+	for _, postID := range s.presence.expire(s.now().Add(-3 * s.cfg.PresenceInterval)) {
+		s.sendPresence(postID)
+	}
+}
+
+func (s *Server) sendPresence(postID int) {
+	s.sendToWatchers(postID, ServerMessage{Type: msgPresence, PostID: postID, Viewers: s.presence.viewers(postID)}, nil)
+}
+
+func (s *Server) sendPresenceExcept(postID int, except *client) {
+	s.sendToWatchers(postID, ServerMessage{Type: msgPresence, PostID: postID, Viewers: s.presence.viewers(postID)}, func(c *client) bool {
+		return c != except
+	})
+}
+
+func (s *Server) sendToWatchers(postID int, msg ServerMessage, filter func(*client) bool) {
+	s.mu.Lock()
+	targets := make([]*client, 0, len(s.watchers[postID]))
+	for c := range s.watchers[postID] {
+		if filter == nil || filter(c) {
+			targets = append(targets, c)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, c := range targets {
+		c.enqueue(msg)
+	}
+}
+
+func (s *Server) logger(c *client) *slog.Logger {
+	return logger.FromContext(c.conn.Request().Context()).With("conn_id", c.id)
+}
@@ -0,0 +1,278 @@
+package live
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/middleware"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
+	"advanced-blog-management-system/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// stubPostService implements PostServiceInterface; only GetByID is used by the live channel
+type stubPostService struct {
+	service.PostServiceInterface
+}
+
+func (s *stubPostService) GetByID(ctx context.Context, id int, requestorID int) (*model.Post, error) {
+	if id != 1 {
+		return nil, apperrors.ErrPostNotFound
+	}
+	return &model.Post{ID: id}, nil
+}
+
+// stubCommentService creates comments and numbers their events per topic like the real stream journal
+type stubCommentService struct {
+	service.CommentServiceInterface
+	hub *pubsub.Hub
+
+	mu      sync.Mutex
+	nextID  int
+	journal map[string][]pubsub.Message
+}
+
+// record appends a comment event to the journal without publishing it to the hub
+func (s *stubCommentService) record(event model.EventType, comment *model.Comment) pubsub.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.journal == nil {
+		s.journal = make(map[string][]pubsub.Message)
+	}
+	topic := service.CommentsTopic(comment.PostID)
+	data, _ := json.Marshal(comment)
+	msg := pubsub.Message{ID: int64(len(s.journal[topic]) + 1), Event: string(event), Data: data}
+	s.journal[topic] = append(s.journal[topic], msg)
+	return msg
+}
+
+func (s *stubCommentService) Create(ctx context.Context, userID, postID int, content, format string) (*model.Comment, error) {
+	s.mu.Lock()
+	s.nextID++
+	comment := &model.Comment{ID: s.nextID, PostID: postID, AuthorID: userID, Content: content}
+	s.mu.Unlock()
+	s.hub.Publish(service.CommentsTopic(postID), s.record(model.EventCommentCreated, comment))
+	return comment, nil
+}
+
+func (s *stubCommentService) StreamAfter(ctx context.Context, postID, requestorID int, afterID int64, limit int) ([]pubsub.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []pubsub.Message
+	for _, msg := range s.journal[service.CommentsTopic(postID)] {
+		if msg.ID > afterID && len(messages) < limit {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+func newLiveServer(t *testing.T) (*httptest.Server, *stubCommentService) {
+	t.Helper()
+	hub := pubsub.NewHub(16)
+	comments := &stubCommentService{hub: hub}
+	live := NewServer(hub, &stubPostService{}, comments, Config{})
+	if err := live.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// Stands in for RequireAuth: the user comes from the query string
+	auth := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := strconv.Atoi(r.URL.Query().Get("user"))
+		ctx := context.WithValue(r.Context(), middleware.UserIDKey, userID)
+		ctx = context.WithValue(ctx, middleware.UserNameKey, "user"+r.URL.Query().Get("user"))
+		live.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	server := httptest.NewServer(auth)
+	t.Cleanup(func() {
+		live.Close()
+		server.Close()
+		hub.Close()
+	})
+	return server, comments
+}
+
+func dial(t *testing.T, server *httptest.Server, userID int) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/?user=" + strconv.Itoa(userID)
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg ClientMessage) {
+	t.Helper()
+	if err := websocket.JSON.Send(conn, msg); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+}
+
+// expect reads messages until one of the given type arrives
+func expect(t *testing.T, conn *websocket.Conn, msgType string) ServerMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg ServerMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatalf("expected %q message: %v", msgType, err)
+		}
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+func viewerIDs(viewers []Viewer) []int {
+	ids := make([]int, 0, len(viewers))
+	for _, v := range viewers {
+		ids = append(ids, v.UserID)
+	}
+	return ids
+}
+
+func TestServer_PresenceAndTyping(t *testing.T) {
+	server, _ := newLiveServer(t)
+
+	alice := dial(t, server, 1)
+	send(t, alice, ClientMessage{Type: msgSubscribe, PostID: 1, Ref: "a"})
+	if msg := expect(t, alice, msgSubscribed); msg.Ref != "a" || len(msg.Viewers) != 1 || msg.Viewers[0].UserID != 1 {
+		t.Fatalf("unexpected subscribed reply: %+v", msg)
+	}
+
+	bob := dial(t, server, 2)
+	send(t, bob, ClientMessage{Type: msgSubscribe, PostID: 1})
+	if ids := viewerIDs(expect(t, bob, msgSubscribed).Viewers); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("expected both viewers in snapshot, got %v", ids)
+	}
+	if ids := viewerIDs(expect(t, alice, msgPresence).Viewers); len(ids) != 2 {
+		t.Errorf("expected presence update with 2 viewers, got %v", ids)
+	}
+
+	send(t, bob, ClientMessage{Type: msgTyping, PostID: 1})
+	if msg := expect(t, alice, msgTyping); msg.User == nil || msg.User.UserID != 2 || msg.User.Username != "user2" {
+		t.Errorf("expected typing from user 2, got %+v", msg)
+	}
+
+	send(t, bob, ClientMessage{Type: msgUnsubscribe, PostID: 1})
+	expect(t, bob, msgUnsubscribed)
+	if ids := viewerIDs(expect(t, alice, msgPresence).Viewers); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("expected presence update after leave, got %v", ids)
+	}
+}
+
+func TestServer_CommentIsAcknowledgedAndBroadcast(t *testing.T) {
+	server, _ := newLiveServer(t)
+
+	alice := dial(t, server, 1)
+	bob := dial(t, server, 2)
+	send(t, alice, ClientMessage{Type: msgSubscribe, PostID: 1})
+	expect(t, alice, msgSubscribed)
+	send(t, bob, ClientMessage{Type: msgSubscribe, PostID: 1})
+	expect(t, bob, msgSubscribed)
+
+	send(t, bob, ClientMessage{Type: msgComment, PostID: 1, Content: "hello", Ref: "c1"})
+	ack := expect(t, bob, msgAck)
+	if ack.Ref != "c1" {
+		t.Errorf("expected ack for ref c1, got %q", ack.Ref)
+	}
+
+	event := expect(t, alice, string(model.EventCommentCreated))
+	var comment model.Comment
+	if err := json.Unmarshal(event.Data, &comment); err != nil || comment.Content != "hello" || comment.AuthorID != 2 {
+		t.Errorf("unexpected broadcast comment %s: %v", event.Data, err)
+	}
+}
+
+func TestServer_RejectsInvalidRequests(t *testing.T) {
+	server, _ := newLiveServer(t)
+	conn := dial(t, server, 1)
+
+	send(t, conn, ClientMessage{Type: msgSubscribe, PostID: 42, Ref: "s"})
+	if msg := expect(t, conn, msgError); msg.Ref != "s" || msg.Message != "Post not found" {
+		t.Errorf("unexpected error for unknown post: %+v", msg)
+	}
+
+	send(t, conn, ClientMessage{Type: msgComment, PostID: 1, Ref: "c"})
+	if msg := expect(t, conn, msgError); msg.Ref != "c" {
+		t.Errorf("expected validation error for empty comment, got %+v", msg)
+	}
+
+	send(t, conn, ClientMessage{Type: msgTyping, PostID: 1, Ref: "t"})
+	if msg := expect(t, conn, msgError); msg.Ref != "t" {
+		t.Errorf("expected error for typing without subscription, got %+v", msg)
+	}
+}
+
+func commentID(t *testing.T, msg ServerMessage) int {
+	t.Helper()
+	var comment model.Comment
+	if err := json.Unmarshal(msg.Data, &comment); err != nil {
+		t.Fatalf("invalid comment in %+v: %v", msg, err)
+	}
+	return comment.ID
+}
+
+func TestServer_ApprovedCommentReachesClientThatSawLaterComment(t *testing.T) {
+	server, comments := newLiveServer(t)
+
+	alice := dial(t, server, 1)
+	send(t, alice, ClientMessage{Type: msgSubscribe, PostID: 1})
+	expect(t, alice, msgSubscribed)
+
+	// Comment 11 is streamed first; the earlier pending comment 10 is approved afterwards
+	comments.mu.Lock()
+	comments.nextID = 10
+	comments.mu.Unlock()
+	send(t, alice, ClientMessage{Type: msgComment, PostID: 1, Content: "later"})
+	if msg := expect(t, alice, string(model.EventCommentCreated)); msg.ID != 1 || commentID(t, msg) != 11 {
+		t.Fatalf("expected comment 11 as event 1, got %+v", msg)
+	}
+	approved := comments.record(model.EventCommentCreated, &model.Comment{ID: 10, PostID: 1, Content: "approved"})
+	comments.hub.Publish(service.CommentsTopic(1), approved)
+	if msg := expect(t, alice, string(model.EventCommentCreated)); msg.ID != 2 || commentID(t, msg) != 10 {
+		t.Fatalf("expected approved comment 10 as event 2, got %+v", msg)
+	}
+
+	// A client that reconnects after event 1 (it has comment 11) still gets comment 10
+	after := int64(1)
+	bob := dial(t, server, 2)
+	send(t, bob, ClientMessage{Type: msgSubscribe, PostID: 1, AfterID: &after})
+	expect(t, bob, msgSubscribed)
+	if msg := expect(t, bob, string(model.EventCommentCreated)); msg.ID != 2 || commentID(t, msg) != 10 {
+		t.Errorf("expected replay of comment 10 after event 1, got %+v", msg)
+	}
+}
+
+func TestServer_FillsGapFromJournal(t *testing.T) {
+	server, comments := newLiveServer(t)
+
+	alice := dial(t, server, 1)
+	send(t, alice, ClientMessage{Type: msgSubscribe, PostID: 1})
+	expect(t, alice, msgSubscribed)
+
+	send(t, alice, ClientMessage{Type: msgComment, PostID: 1, Content: "first"})
+	expect(t, alice, string(model.EventCommentCreated))
+
+	// Event 2 is committed but its hub message is late; event 3 arrives first
+	comments.record(model.EventCommentCreated, &model.Comment{ID: 2, PostID: 1})
+	comments.hub.Publish(service.CommentsTopic(1), comments.record(model.EventCommentCreated, &model.Comment{ID: 3, PostID: 1}))
+
+	for _, want := range []int64{2, 3} {
+		if msg := expect(t, alice, string(model.EventCommentCreated)); msg.ID != want {
+			t.Fatalf("expected event %d, got %+v", want, msg)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"DEBUG", slog.LevelDebug},
+		{"warn", slog.LevelWarn},
+		{" warning ", slog.LevelWarn},
+		{"error", slog.LevelError},
+		{"info", slog.LevelInfo},
+		{"", slog.LevelInfo},
+		{"bogus", slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		if got := ParseLevel(tt.input); got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNew_ProductionUsesJSON(t *testing.T) {
+	for _, env := range []string{"production", "prod", "PRODUCTION"} {
+		var buf bytes.Buffer
+		New(&buf, env, "info").Info("hello", "key", "value")
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("env %q: expected JSON output, got %q: %v", env, buf.String(), err)
+		}
+		if record["msg"] != "hello" || record["key"] != "value" {
+			t.Errorf("env %q: unexpected record %v", env, record)
+		}
+	}
+}
+
+func TestNew_DevelopmentUsesText(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, "development", "info").Info("hello", "key", "value")
+
+	out := buf.String()
+	if json.Valid(buf.Bytes()) {
+		t.Fatalf("expected text output, got JSON %q", out)
+	}
+	if !strings.Contains(out, "msg=hello") || !strings.Contains(out, "key=value") {
+		t.Errorf("expected key=value pairs, got %q", out)
+	}
+}
+
+func TestNew_RespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "development", "warn")
+
+	l.Info("skipped")
+	if buf.Len() != 0 {
+		t.Fatalf("expected info to be filtered at warn level, got %q", buf.String())
+	}
+
+	l.Warn("kept")
+	if !strings.Contains(buf.String(), "msg=kept") {
+		t.Errorf("expected warn record, got %q", buf.String())
+	}
+}
+
+func TestFromContext_DefaultsAndRequestID(t *testing.T) {
+	if FromContext(nil) != slog.Default() {
+		t.Error("expected slog.Default for nil context")
+	}
+
+	ctx := WithRequestID(t.Context(), "req-1")
+	if got := RequestIDFromContext(ctx); got != "req-1" {
+		t.Errorf("expected request ID 'req-1', got %q", got)
+	}
+}
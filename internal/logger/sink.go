@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"advanced-blog-management-system/internal/metrics"
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Sink - получатель пакетов доменных событий (файл, stdout, БД, HTTP)
+type Sink interface {
+	// Name возвращает имя приемника для логов и метрик
+	Name() string
+	// Write записывает пакет событий; ошибка не влияет на другие приемники
+	Write(ctx context.Context, events []model.Event) error
+	Close() error
+}
+
+// WriteToSink записывает пакет в приемник с ограничением по времени.
+// Паника внутри приемника превращается в ошибку, чтобы сбой одного приемника не ронял процесс
+func WriteToSink(ctx context.Context, sink Sink, events []model.Event, timeout time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sink panic: %v", r)
+		}
+		if err != nil {
+			metrics.EventSinkErrors.WithLabelValues(sink.Name()).Inc()
+			slog.Error("failed to write events to sink", "sink", sink.Name(), "events", len(events), "error", err)
+			return
+		}
+		metrics.EventSinkWritten.WithLabelValues(sink.Name()).Add(float64(len(events)))
+	}()
+
+	return sink.Write(ctx, events)
+}
@@ -0,0 +1,242 @@
+package logger
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SyncPolicy определяет, когда FileSink вызывает fsync для файла журнала
+type SyncPolicy int
+
+const (
+	// SyncNever полагается на сброс буферов операционной системой
+	SyncNever SyncPolicy = iota
+	// SyncEveryBatch вызывает fsync после записи каждого пакета
+	SyncEveryBatch
+	// SyncInterval вызывает fsync не чаще одного раза за SyncInterval
+	SyncInterval
+)
+
+// ParseSyncPolicy преобразует строку конфигурации в SyncPolicy
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "never":
+		return SyncNever, nil
+	case "batch":
+		return SyncEveryBatch, nil
+	case "interval":
+		return SyncInterval, nil
+	default:
+		return SyncNever, fmt.Errorf("unknown fsync policy %q", s)
+	}
+}
+
+// FileSinkConfig содержит настройки файлового приемника с ротацией
+type FileSinkConfig struct {
+	Path string
+	// MaxSizeBytes - размер сегмента, после которого файл ротируется (0 - без ограничения)
+	MaxSizeBytes int64
+	// RotateInterval - максимальный возраст сегмента (0 - без ротации по времени)
+	RotateInterval time.Duration
+	// MaxBackups - сколько сжатых сегментов хранить (0 - без ограничения)
+	MaxBackups int
+	// MaxAge - сколько хранить сжатые сегменты (0 - без ограничения)
+	MaxAge       time.Duration
+	Sync         SyncPolicy
+	SyncInterval time.Duration
+}
+
+// FileSink пишет события в файл JSON Lines, ротирует его по размеру и времени,
+// сжимает старые сегменты gzip и удаляет их по политике хранения
+type FileSink struct {
+	cfg      FileSinkConfig
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	lastSync time.Time
+	now      func() time.Time
+}
+
+// NewFileSink открывает (или создает) файл журнала событий
+func NewFileSink(cfg FileSinkConfig) (*FileSink, error) {
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = time.Second
+	}
+
+	s := &FileSink{cfg: cfg, now: time.Now}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat event log file: %w", err)
+	}
+
+	s.file = f
+	s.size = info.Size()
+	s.openedAt = s.now()
+	s.lastSync = s.openedAt
+	return nil
+}
+
+func (s *FileSink) Write(ctx context.Context, events []model.Event) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := encoder.Encode(e); err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", e.ID, err)
+		}
+	}
+
+	if s.shouldRotate(int64(buf.Len())) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(buf.Bytes())
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write events: %w", err)
+	}
+
+	if s.cfg.Sync == SyncEveryBatch || (s.cfg.Sync == SyncInterval && s.now().Sub(s.lastSync) >= s.cfg.SyncInterval) {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync event log file: %w", err)
+		}
+		s.lastSync = s.now()
+	}
+	return nil
+}
+
+func (s *FileSink) shouldRotate(incoming int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.cfg.MaxSizeBytes > 0 && s.size+incoming > s.cfg.MaxSizeBytes {
+		return true
+	}
+	return s.cfg.RotateInterval > 0 && s.now().Sub(s.openedAt) >= s.cfg.RotateInterval
+}
+
+// rotate закрывает текущий сегмент, сжимает его и открывает новый файл
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close event log segment: %w", err)
+	}
+
+	segment := s.backupName(s.now())
+	if err := os.Rename(s.cfg.Path, segment); err != nil {
+		return fmt.Errorf("failed to rename event log segment: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+
+	if err := compressFile(segment); err != nil {
+		slog.Error("failed to compress event log segment", "segment", segment, "error", err)
+	}
+	s.prune()
+	return nil
+}
+
+// backupName возвращает имя сегмента вида logs-20240115T103545.000.txt
+func (s *FileSink) backupName(t time.Time) string {
+	ext := filepath.Ext(s.cfg.Path)
+	base := strings.TrimSuffix(s.cfg.Path, ext)
+	return fmt.Sprintf("%s-%s%s", base, t.UTC().Format("20060102T150405.000"), ext)
+}
+
+// prune удаляет сжатые сегменты сверх MaxBackups и старше MaxAge
+func (s *FileSink) prune() {
+	ext := filepath.Ext(s.cfg.Path)
+	pattern := strings.TrimSuffix(s.cfg.Path, ext) + "-*" + ext + ".gz"
+
+	backups, err := filepath.Glob(pattern)
+	if err != nil {
+		slog.Error("failed to list event log segments", "error", err)
+		return
+	}
+	// Имена содержат время ротации, поэтому сортировка по имени - это сортировка по времени
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, backup := range backups {
+		expired := false
+		if s.cfg.MaxBackups > 0 && i >= s.cfg.MaxBackups {
+			expired = true
+		}
+		if s.cfg.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && s.now().Sub(info.ModTime()) > s.cfg.MaxAge {
+				expired = true
+			}
+		}
+		if expired {
+			if err := os.Remove(backup); err != nil {
+				slog.Error("failed to remove event log segment", "segment", backup, "error", err)
+			}
+		}
+	}
+}
+
+func (s *FileSink) Close() error {
+	if s.cfg.Sync != SyncNever {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync event log file: %w", err)
+		}
+	}
+	return s.file.Close()
+}
+
+// compressFile сжимает файл в path.gz и удаляет исходный файл
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// PostgresSink сохраняет события в таблицу audit_events.
+// Повторная запись события с тем же ID игнорируется, поэтому запись идемпотентна
+type PostgresSink struct {
+	db *sql.DB
+}
+
+func NewPostgresSink(db *sql.DB) *PostgresSink {
+	return &PostgresSink{db: db}
+}
+
+func (s *PostgresSink) Name() string {
+	return "postgres"
+}
+
+func (s *PostgresSink) Write(ctx context.Context, events []model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	const columns = 8
+	placeholders := make([]string, 0, len(events))
+	args := make([]any, 0, len(events)*columns)
+	for i, e := range events {
+		base := i * columns
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8))
+
+		var actorID, requestID, payload any
+		if e.ActorID != 0 {
+			actorID = e.ActorID
+		}
+		if e.RequestID != "" {
+			requestID = e.RequestID
+		}
+		if len(e.Payload) > 0 {
+			payload = string(e.Payload)
+		}
+		args = append(args, e.ID, string(e.Type), actorID, e.Target.Type, e.Target.ID, requestID, payload, e.Timestamp)
+	}
+
+	query := `
+		INSERT INTO audit_events (event_id, event_type, actor_id, target_type, target_id, request_id, payload, occurred_at)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+		ON CONFLICT (event_id) DO NOTHING
+	`
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert audit events: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresSink) Close() error {
+	return nil
+}
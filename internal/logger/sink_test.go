@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testEvent(id int) model.Event {
+	return model.NewEvent(model.EventPostCreated, 1, model.EventTarget{Type: "post", ID: id}, nil)
+}
+
+func readEventLines(t *testing.T, path string) []model.Event {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open event log: %v", err)
+	}
+	defer f.Close()
+
+	var lines []model.Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line model.Event
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// panickingSink паникует при записи
+type panickingSink struct{}
+
+func (s *panickingSink) Name() string { return "panicking" }
+
+func (s *panickingSink) Write(ctx context.Context, events []model.Event) error {
+	panic("sink exploded")
+}
+
+func (s *panickingSink) Close() error { return nil }
+
+func TestWriteToSink_RecoversPanic(t *testing.T) {
+	err := WriteToSink(context.Background(), &panickingSink{}, []model.Event{testEvent(1)}, time.Second)
+	if err == nil {
+		t.Fatal("expected panic to be returned as error")
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for input, want := range map[string]SyncPolicy{
+		"":         SyncNever,
+		"batch":    SyncEveryBatch,
+		"INTERVAL": SyncInterval,
+	} {
+		got, err := ParseSyncPolicy(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSyncPolicy(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+
+	if _, err := ParseSyncPolicy("unknown"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestFileSink_RotatesAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.log")
+
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	sink, err := NewFileSink(FileSinkConfig{Path: path, MaxSizeBytes: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("failed to create file sink: %v", err)
+	}
+	sink.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		now = now.Add(time.Second)
+		if err := sink.Write(context.Background(), []model.Event{testEvent(i)}); err != nil {
+			t.Fatalf("write %d failed: %v", i, err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "events-*.log.gz"))
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 compressed segments after retention, got %v", backups)
+	}
+	if plain, _ := filepath.Glob(filepath.Join(dir, "events-*.log")); len(plain) != 0 {
+		t.Errorf("expected uncompressed segments to be removed, got %v", plain)
+	}
+
+	if lines := readEventLines(t, path); len(lines) != 1 || lines[0].Target.ID != 3 {
+		t.Errorf("expected active file to contain only the last event, got %v", lines)
+	}
+
+	// Самый новый сегмент содержит предпоследнее событие
+	f, err := os.Open(backups[len(backups)-1])
+	if err != nil {
+		t.Fatalf("failed to open segment: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("segment is not gzip: %v", err)
+	}
+	scanner := bufio.NewScanner(gz)
+	if !scanner.Scan() {
+		t.Fatal("expected event in compressed segment")
+	}
+}
+
+func TestFileSink_RotatesByInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.log")
+
+	now := time.Now()
+	sink, err := NewFileSink(FileSinkConfig{Path: path, RotateInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to create file sink: %v", err)
+	}
+	sink.now = func() time.Time { return now }
+	defer sink.Close()
+
+	_ = sink.Write(context.Background(), []model.Event{testEvent(1)})
+	now = now.Add(30 * time.Minute)
+	_ = sink.Write(context.Background(), []model.Event{testEvent(2)})
+	if backups, _ := filepath.Glob(filepath.Join(dir, "events-*.log.gz")); len(backups) != 0 {
+		t.Fatalf("expected no rotation before interval, got %v", backups)
+	}
+
+	now = now.Add(time.Hour)
+	_ = sink.Write(context.Background(), []model.Event{testEvent(3)})
+	if backups, _ := filepath.Glob(filepath.Join(dir, "events-*.log.gz")); len(backups) != 1 {
+		t.Fatalf("expected one rotated segment, got %v", backups)
+	}
+}
+
+func TestHTTPSink_PostsNDJSON(t *testing.T) {
+	var gotContentType, gotAuth string
+	var gotLines int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		gotAuth = r.Header.Get("Authorization")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			gotLines++
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, map[string]string{"Authorization": "Bearer token"}, time.Second)
+	if err := sink.Write(context.Background(), []model.Event{testEvent(1), testEvent(2)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotContentType != "application/x-ndjson" {
+		t.Errorf("expected application/x-ndjson, got %q", gotContentType)
+	}
+	if gotAuth != "Bearer token" {
+		t.Errorf("expected Authorization header, got %q", gotAuth)
+	}
+	if gotLines != 2 {
+		t.Errorf("expected 2 lines, got %d", gotLines)
+	}
+}
+
+func TestHTTPSink_ErrorOnNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, nil, time.Second)
+	if err := sink.Write(context.Background(), []model.Event{testEvent(1)}); err == nil {
+		t.Error("expected error for 503 response")
+	}
+}
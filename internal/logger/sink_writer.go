@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// WriterSink пишет события в формате JSON Lines в произвольный io.Writer
+type WriterSink struct {
+	name string
+	w    io.Writer
+}
+
+// NewStdoutSink создает приемник, печатающий события в stdout (удобно для контейнеров)
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink("stdout", os.Stdout)
+}
+
+// NewWriterSink создает приемник поверх io.Writer
+func NewWriterSink(name string, w io.Writer) *WriterSink {
+	return &WriterSink{name: name, w: w}
+}
+
+func (s *WriterSink) Name() string {
+	return s.name
+}
+
+func (s *WriterSink) Write(ctx context.Context, events []model.Event) error {
+	encoder := json.NewEncoder(s.w)
+	for _, e := range events {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WriterSink) Close() error {
+	return nil
+}
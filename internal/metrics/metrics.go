@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "blog"
+
+var (
+	// HTTPRequestsTotal - количество обработанных HTTP-запросов
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration - гистограмма длительности HTTP-запросов
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestsInFlight - количество запросов в обработке
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	// PostsCreated - количество созданных постов
+	PostsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Total number of created posts.",
+	})
+
+	// CommentsCreated - количество созданных комментариев
+	CommentsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_created_total",
+		Help:      "Total number of created comments.",
+	})
+
+	// UsersRegistered - количество зарегистрированных пользователей
+	UsersRegistered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "users_registered_total",
+		Help:      "Total number of registered users.",
+	})
+
+	// EventSinkWritten - количество событий, записанных приемником журнала событий
+	EventSinkWritten = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "event_sink",
+		Name:      "written_events_total",
+		Help:      "Total number of events written by each event sink.",
+	}, []string{"sink"})
+
+	// EventSinkErrors - количество неудачных записей пакетов в приемник
+	EventSinkErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "event_sink",
+		Name:      "errors_total",
+		Help:      "Total number of failed batch writes by each event sink.",
+	}, []string{"sink"})
+
+	// OutboxDelivered - количество событий, доставленных из outbox во все приемники
+	OutboxDelivered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "delivered_events_total",
+		Help:      "Total number of outbox events delivered to all sinks.",
+	})
+
+	// OutboxRetries - количество неудачных попыток доставки, после которых событие будет повторено
+	OutboxRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "retries_total",
+		Help:      "Total number of failed outbox delivery attempts scheduled for retry.",
+	})
+
+	// OutboxDeadLettered - количество событий, исчерпавших попытки доставки
+	OutboxDeadLettered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "dead_lettered_events_total",
+		Help:      "Total number of outbox events moved to the dead-letter state.",
+	})
+
+	// OutboxPending - количество событий, ожидающих доставки
+	OutboxPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "pending_events",
+		Help:      "Number of outbox events waiting for delivery.",
+	})
+
+	// OutboxDead - количество событий в dead-letter
+	OutboxDead = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "dead_events",
+		Help:      "Number of outbox events in the dead-letter state.",
+	})
+
+	// WebhookDeliveries - количество попыток доставки вебхуков по результату (succeeded, retry, failed)
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "delivery_attempts_total",
+		Help:      "Total number of webhook delivery attempts by outcome.",
+	}, []string{"outcome"})
+
+	// StreamConnections - количество открытых SSE-подключений
+	StreamConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "connections",
+		Help:      "Number of open Server-Sent Events connections.",
+	})
+
+	// LiveConnections - количество открытых WebSocket-подключений
+	LiveConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "live",
+		Name:      "connections",
+		Help:      "Number of open WebSocket live channel connections.",
+	})
+
+	// CacheRequests - обращения к кешу по пространству ключей и результату (hit, miss)
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Total number of cache lookups by cache and result.",
+	}, []string{"cache", "result"})
+
+	// SpamVerdicts - решения фильтра спама по виду публикации и решению (allow, moderate, reject)
+	SpamVerdicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "spam",
+		Name:      "verdicts_total",
+		Help:      "Total number of spam filter verdicts by content kind and action.",
+	}, []string{"kind", "action"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		PostsCreated,
+		CommentsCreated,
+		UsersRegistered,
+		EventSinkWritten,
+		EventSinkErrors,
+		OutboxDelivered,
+		OutboxRetries,
+		OutboxDeadLettered,
+		OutboxPending,
+		OutboxDead,
+		WebhookDeliveries,
+		StreamConnections,
+		LiveConnections,
+		CacheRequests,
+		SpamVerdicts,
+	)
+}
+
+// Register регистрирует дополнительный коллектор, например статистику пула БД
+func Register(c prometheus.Collector) {
+	prometheus.MustRegister(c)
+}
+
+// Handler возвращает HTTP-обработчик для /metrics в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/model"
 	"advanced-blog-management-system/pkg/auth"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 )
@@ -18,17 +22,27 @@ const (
 	UserEmailKey contextKey = "userEmail"
 	// UserNameKey - ключ для сохранения username в контекс
 	UserNameKey contextKey = "username"
+	// UserRoleKey - ключ для сохранения роли пользователя в контексте
+	UserRoleKey contextKey = "userRole"
 )
 
+// UserLookup находит пользователя по ID; удаленные пользователи не находятся (ErrUserNotFound)
+type UserLookup interface {
+	GetByID(ctx context.Context, id int) (*model.User, error)
+}
+
 // AuthMiddleware обеспечивает JWT аутентификацию
 type AuthMiddleware struct {
 	jwtManager *auth.JWTManager
+	users      UserLookup
 }
 
-// NewAuthMiddleware создает новый инстанс auth middleware
-func NewAuthMiddleware(jwtManager *auth.JWTManager) *AuthMiddleware {
+// NewAuthMiddleware создает новый инстанс auth middleware. Каждый токен дополнительно
+// сверяется с users: JWT удаленного пользователя остается валидным до истечения срока
+func NewAuthMiddleware(jwtManager *auth.JWTManager, users UserLookup) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtManager: jwtManager,
+		users:      users,
 	}
 }
 
@@ -49,10 +63,23 @@ func (m *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// 2.1. Убедиться, что пользователь не удален
+		if _, err := m.users.GetByID(r.Context(), claims.UserID); err != nil {
+			if errors.Is(err, apperrors.ErrUserNotFound) {
+				writeJSONError(w, "User not found", http.StatusUnauthorized)
+				return
+			}
+			logger.FromContext(r.Context()).Error("failed to check token user", "error", err)
+			writeJSONError(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
 		// 3. Добавить данные пользователя в контекст
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
 		ctx = context.WithValue(ctx, UserNameKey, claims.Username)
+		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = withLogUser(ctx, claims.UserID)
 
 		// 4. Передать управление следующему handler
 		next(w, r.WithContext(ctx))
@@ -77,16 +104,55 @@ func (m *AuthMiddleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// 4.1. Токен удаленного пользователя тоже не дает прав
+		if _, err := m.users.GetByID(r.Context(), claims.UserID); err != nil {
+			if !errors.Is(err, apperrors.ErrUserNotFound) {
+				logger.FromContext(r.Context()).Error("failed to check token user", "error", err)
+			}
+			next(w, r)
+			return
+		}
+
 		// 3. Если токен валидный, то добавить данные в контекст
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
 		ctx = context.WithValue(ctx, UserNameKey, claims.Username)
+		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = withLogUser(ctx, claims.UserID)
 
 		// 5. Передать управление следующему handler
 		next(w, r.WithContext(ctx))
 	}
 }
 
+// RequireRole - middleware пропускает только пользователей с одной из указанных ролей.
+// Используется после RequireAuth; роль берется из JWT, поэтому ее смена вступает в силу со следующим входом
+func RequireRole(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			role, _ := GetUserRoleFromContext(r.Context())
+			for _, allowed := range roles {
+				if role == allowed {
+					next(w, r)
+					return
+				}
+			}
+			writeJSONError(w, "Forbidden", http.StatusForbidden)
+		}
+	}
+}
+
+// QueryToken переносит токен из параметра access_token в заголовок Authorization, если заголовка нет.
+// Нужен перед RequireAuth для WebSocket: браузерный API не позволяет задать заголовки при подключении
+func QueryToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next(w, r)
+	}
+}
+
 // extractToken извлекает JWT токен из заголовка Authorization
 func extractToken(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
@@ -121,11 +187,16 @@ func GetUsernameFromContext(ctx context.Context) (string, bool) {
 	return username, ok
 }
 
-// writeJSONError отправляет ошибку в формате JSON
+// writeJSONError отправляет ошибку в формате JSON вместе с идентификатором запроса
 func writeJSONError(w http.ResponseWriter, message string, statusCode int) {
+	body := map[string]string{"error": message}
+	if requestID := w.Header().Get(RequestIDHeader); requestID != "" {
+		body["request_id"] = requestID
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(body)
 }
 
 // Chain позволяет объединить несколько middleware в цепочку
@@ -135,3 +206,9 @@ func Chain(handler http.HandlerFunc, middlewares ...func(http.HandlerFunc) http.
 	}
 	return handler
 }
+
+// GetUserRoleFromContext извлекает роль пользователя из контекста
+func GetUserRoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(UserRoleKey).(string)
+	return role, ok
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/pkg/auth"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeUsers resolves every user except the ones listed as deleted
+type fakeUsers struct {
+	deleted map[int]bool
+}
+
+func (f *fakeUsers) GetByID(ctx context.Context, id int) (*model.User, error) {
+	if f.deleted[id] {
+		return nil, apperrors.ErrUserNotFound
+	}
+	return &model.User{ID: id}, nil
+}
+
+func TestRequireRole(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", 1)
+	authMiddleware := NewAuthMiddleware(jwtManager, &fakeUsers{})
+
+	handler := Chain(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, authMiddleware.RequireAuth, RequireRole("admin"))
+
+	tests := []struct {
+		name   string
+		role   string
+		status int
+	}{
+		{"admin allowed", "admin", http.StatusNoContent},
+		{"editor forbidden", "editor", http.StatusForbidden},
+		{"user forbidden", "user", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := jwtManager.GenerateToken(1, "a@example.com", "alice", tt.role)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRequireAuth_RejectsDeletedUser(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", 1)
+	authMiddleware := NewAuthMiddleware(jwtManager, &fakeUsers{deleted: map[int]bool{2: true}})
+
+	handler := authMiddleware.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		userID int
+		status int
+	}{
+		{"active user", 1, http.StatusNoContent},
+		{"deleted user", 2, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := jwtManager.GenerateToken(tt.userID, "a@example.com", "alice", "user")
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestOptionalAuth_DeletedUserIsAnonymous(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", 1)
+	authMiddleware := NewAuthMiddleware(jwtManager, &fakeUsers{deleted: map[int]bool{2: true}})
+
+	var authenticated bool
+	handler := authMiddleware.OptionalAuth(func(w http.ResponseWriter, r *http.Request) {
+		_, authenticated = GetUserIDFromContext(r.Context())
+	})
+
+	token, _, err := jwtManager.GenerateToken(2, "a@example.com", "alice", "user")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	handler(httptest.NewRecorder(), req)
+
+	if authenticated {
+		t.Error("expected deleted user to be treated as anonymous")
+	}
+}
@@ -1,16 +1,32 @@
 package middleware
 
 import (
-	"log"
+	"advanced-blog-management-system/internal/logger"
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
 	"net/http"
+	"runtime/debug"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// logInfoKey - ключ контекста для данных, которые заполняются ниже по цепочке middleware
+const logInfoKey contextKey = "logInfo"
+
+// requestLogInfo собирает поля access-лога, известные только после аутентификации
+type requestLogInfo struct {
+	userID int
+}
+
 type LoggingMiddleware struct {
-	logger *log.Logger
+	logger *slog.Logger
 }
 
-func NewLoggingMiddleware(logger *log.Logger) *LoggingMiddleware {
+func NewLoggingMiddleware(logger *slog.Logger) *LoggingMiddleware {
 	return &LoggingMiddleware{logger: logger}
 }
 
@@ -18,17 +34,33 @@ func (m *LoggingMiddleware) Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-		next.ServeHTTP(ww, r)
-
-		m.logger.Printf(
-			"%s | %s %s | %d | %v",
-			r.RemoteAddr,
-			r.Method,
-			r.URL.Path,
-			ww.statusCode,
-			time.Since(start),
+		info := &requestLogInfo{}
+		ctx := context.WithValue(r.Context(), logInfoKey, info)
+		ctx = logger.WithLogger(ctx, m.logger)
+		ctx = logger.With(ctx,
+			slog.String("request_id", logger.RequestIDFromContext(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
 		)
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			ctx = logger.With(ctx, slog.String("trace_id", sc.TraceID().String()))
+		}
+
+		ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		attrs := []slog.Attr{
+			slog.String("route", routePattern(r)),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.Int("status", ww.statusCode),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", ww.bytesWritten),
+		}
+		if info.userID != 0 {
+			attrs = append(attrs, slog.Int("user_id", info.userID))
+		}
+
+		logger.FromContext(ctx).LogAttrs(ctx, levelForStatus(ww.statusCode), "http request", attrs...)
 	})
 }
 
@@ -36,8 +68,11 @@ func (m *LoggingMiddleware) Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				m.logger.Printf("PANIC: %v\n", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				logger.FromContext(r.Context()).Error("panic recovered",
+					"panic", err,
+					"stack", string(debug.Stack()),
+				)
+				writeJSONError(w, "Internal Server Error", http.StatusInternalServerError)
 			}
 		}()
 		next.ServeHTTP(w, r)
@@ -47,8 +82,9 @@ func (m *LoggingMiddleware) Recovery(next http.Handler) http.Handler {
 func (m *LoggingMiddleware) CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, If-None-Match, "+RequestIDHeader)
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, "+RequestIDHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -59,12 +95,62 @@ func (m *LoggingMiddleware) CORS(next http.Handler) http.Handler {
 	})
 }
 
+// withLogUser добавляет user ID в логгер запроса и в итоговую строку access-лога
+func withLogUser(ctx context.Context, userID int) context.Context {
+	if info, ok := ctx.Value(logInfoKey).(*requestLogInfo); ok {
+		info.userID = userID
+	}
+	return logger.With(ctx, slog.Int("user_id", userID))
+}
+
+// routePattern возвращает шаблон маршрута chi, чтобы не плодить уникальные пути в логах
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return r.URL.Path
+}
+
+func levelForStatus(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += n
+	return n, err
+}
+
+// Unwrap дает http.ResponseController доступ к Flush и дедлайнам исходного writer (нужно для SSE)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack передает соединение обработчику WebSocket; в логах и метриках запрос получает статус 101
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
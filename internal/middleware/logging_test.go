@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestLogger_AccessLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLoggingMiddleware(logger.New(&buf, "production", "debug"))
+
+	r := chi.NewRouter()
+	r.Use(RequestID)
+	r.Use(m.Logger)
+	r.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// AuthMiddleware records the user the same way after validating the token
+		withLogUser(r.Context(), 42)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/7", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+
+	if record["msg"] != "http request" {
+		t.Errorf("expected msg 'http request', got %v", record["msg"])
+	}
+	if record["request_id"] != "req-123" {
+		t.Errorf("expected request_id 'req-123', got %v", record["request_id"])
+	}
+	if record["user_id"] != float64(42) {
+		t.Errorf("expected user_id 42, got %v", record["user_id"])
+	}
+	if record["route"] != "/posts/{id}" {
+		t.Errorf("expected route pattern '/posts/{id}', got %v", record["route"])
+	}
+	if record["path"] != "/posts/7" {
+		t.Errorf("expected path '/posts/7', got %v", record["path"])
+	}
+	if record["status"] != float64(http.StatusCreated) {
+		t.Errorf("expected status 201, got %v", record["status"])
+	}
+	if record["bytes"] != float64(5) {
+		t.Errorf("expected bytes 5, got %v", record["bytes"])
+	}
+	if _, ok := record["latency"].(float64); !ok {
+		t.Errorf("expected numeric latency, got %v", record["latency"])
+	}
+}
+
+func TestLogger_LevelByStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		level  string
+	}{
+		{http.StatusOK, "INFO"},
+		{http.StatusNotFound, "WARN"},
+		{http.StatusInternalServerError, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		m := NewLoggingMiddleware(logger.New(&buf, "production", "debug"))
+		handler := m.Logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("status %d: invalid JSON %q: %v", tt.status, buf.String(), err)
+		}
+		if record["level"] != tt.level {
+			t.Errorf("status %d: expected level %s, got %v", tt.status, tt.level, record["level"])
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"advanced-blog-management-system/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_LabelsByRoutePattern(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(Metrics)
+	router.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	counter := metrics.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/posts/{id}", "404")
+	before := testutil.ToFloat64(counter)
+
+	for _, path := range []string{"/posts/1", "/posts/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("expected 2 requests counted for route pattern, got %v", got)
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader - заголовок, через который передается идентификатор запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину входящего идентификатора
+const maxRequestIDLength = 128
+
+// RequestID принимает X-Request-ID от клиента или генерирует новый,
+// возвращает его в ответе и сохраняет в контексте запроса
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := logger.WithRequestID(r.Context(), requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestIDFromContext извлекает идентификатор запроса из контекста
+func GetRequestIDFromContext(ctx context.Context) string {
+	return logger.RequestIDFromContext(ctx)
+}
+
+// isValidRequestID пропускает только короткие идентификаторы из безопасных символов,
+// чтобы клиент не мог внедрить в логи произвольный текст
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
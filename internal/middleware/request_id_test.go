@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID_GeneratesWhenMissing(t *testing.T) {
+	var fromContext string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromContext = GetRequestIDFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	header := rec.Header().Get(RequestIDHeader)
+	if header == "" {
+		t.Fatal("expected generated request ID in response header")
+	}
+	if fromContext != header {
+		t.Errorf("expected context request ID %q, got %q", header, fromContext)
+	}
+}
+
+func TestRequestID_AcceptsIncoming(t *testing.T) {
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("expected request ID 'abc-123', got %q", got)
+	}
+}
+
+func TestRequestID_RejectsUnsafeIncoming(t *testing.T) {
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got == "bad id\nwith newline" {
+		t.Error("expected unsafe request ID to be replaced")
+	}
+}
+
+func TestWriteJSONError_IncludesRequestID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set(RequestIDHeader, "req-1")
+
+	writeJSONError(rec, "Invalid token", http.StatusUnauthorized)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "{\"error\":\"Invalid token\",\"request_id\":\"req-1\"}\n" {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
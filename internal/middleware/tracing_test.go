@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"advanced-blog-management-system/internal/tracing"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing_ContinuesIncomingTraceparent(t *testing.T) {
+	provider, exporter := tracing.NewInMemoryProvider()
+	defer provider.Shutdown(context.Background())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	router := chi.NewRouter()
+	router.Use(Tracing)
+	router.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "PostService.GetByID")
+		span.End()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	child, server := spans[0], spans[1]
+	if server.Name != "GET /posts/{id}" {
+		t.Errorf("expected server span name 'GET /posts/{id}', got %q", server.Name)
+	}
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("expected server span kind, got %v", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected incoming trace ID to be continued, got %s", got)
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("expected service span to be a child of the server span")
+	}
+}
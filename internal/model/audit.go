@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry - запись журнала аудита, сохраненная из доменного события
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	EventID    string          `json:"event_id"`
+	Action     EventType       `json:"action"`
+	ActorID    *int            `json:"actor_id,omitempty"`
+	Target     EventTarget     `json:"target"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// AuditFilter - условия выборки журнала аудита; нулевые поля не ограничивают выборку
+type AuditFilter struct {
+	ActorID int
+	// Action - точный тип события или префикс вида "post.*"
+	Action     string
+	TargetType string
+	TargetID   int
+	From       time.Time
+	To         time.Time
+	// AfterID - курсор: вернуть записи с ID меньше указанного
+	AfterID int64
+	Limit   int
+}
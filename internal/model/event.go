@@ -0,0 +1,257 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// EventType - тип доменного события
+type EventType string
+
+const (
+	EventUserRegistered  EventType = "user.registered"
+	EventUserLoggedIn    EventType = "user.logged_in"
+	EventLoginFailed     EventType = "user.login_failed"
+	EventUserRoleChanged EventType = "user.role_changed"
+	EventUserDeleted     EventType = "user.deleted"
+	EventUserRestored    EventType = "user.restored"
+	EventPostCreated     EventType = "post.created"
+	EventPostUpdated     EventType = "post.updated"
+	EventPostDeleted     EventType = "post.deleted"
+	EventPostRestored    EventType = "post.restored"
+	EventPostApproved    EventType = "post.approved"
+	EventCommentCreated  EventType = "comment.created"
+	EventCommentUpdated  EventType = "comment.updated"
+	EventCommentDeleted  EventType = "comment.deleted"
+	EventCommentRestored EventType = "comment.restored"
+	EventCommentApproved EventType = "comment.approved"
+	EventCommentRejected EventType = "comment.rejected"
+	EventContentFlagged  EventType = "content.flagged"
+)
+
+// EventTarget - сущность, над которой выполнено действие
+type EventTarget struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+// Event - доменное событие, сериализуемое в JSON Lines
+type Event struct {
+	ID        string          `json:"id"`
+	Type      EventType       `json:"type"`
+	ActorID   int             `json:"actor_id,omitempty"`
+	Target    EventTarget     `json:"target"`
+	Timestamp time.Time       `json:"timestamp"`
+	RequestID string          `json:"request_id,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+type PostCreatedPayload struct {
+	Title string `json:"title"`
+}
+
+type PostUpdatedPayload struct {
+	Title        string `json:"title"`
+	Revision     int    `json:"revision"`
+	RestoredFrom int    `json:"restored_from,omitempty"`
+}
+
+type CommentCreatedPayload struct {
+	PostID int    `json:"post_id"`
+	Status string `json:"status"`
+}
+
+type CommentChangedPayload struct {
+	PostID int `json:"post_id"`
+}
+
+// CommentModeratedPayload - решение модератора; Reason передается при отклонении
+type CommentModeratedPayload struct {
+	CommentID int    `json:"comment_id"`
+	PostID    int    `json:"post_id"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ContentFlaggedPayload - решение фильтра спама и сработавшие правила
+type ContentFlaggedPayload struct {
+	Action string   `json:"action"`
+	Score  int      `json:"score"`
+	Rules  []string `json:"rules"`
+}
+
+type UserRegisteredPayload struct {
+	Username string `json:"username"`
+}
+
+type UserLoggedInPayload struct {
+	Username string `json:"username"`
+}
+
+type UserRoleChangedPayload struct {
+	OldRole string `json:"old_role"`
+	NewRole string `json:"new_role"`
+}
+
+type LoginFailedPayload struct {
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
+
+// NewEvent создает событие с уникальным ID и временем в UTC
+func NewEvent(eventType EventType, actorID int, target EventTarget, payload any) Event {
+	e := Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		ActorID:   actorID,
+		Target:    target,
+		Timestamp: time.Now().UTC(),
+	}
+	if payload != nil {
+		// Payload - это всегда структура из этого файла, ошибка маршалинга невозможна
+		e.Payload, _ = json.Marshal(payload)
+	}
+	return e
+}
+
+func NewPostCreatedEvent(post *Post) Event {
+	return NewEvent(EventPostCreated, post.AuthorID,
+		EventTarget{Type: "post", ID: post.ID},
+		PostCreatedPayload{Title: post.Title},
+	)
+}
+
+// NewPostUpdatedEvent - правка или восстановление поста пользователем actorID, сохраненная как версия rev
+func NewPostUpdatedEvent(actorID int, post *Post, rev *PostRevision) Event {
+	return NewEvent(EventPostUpdated, actorID,
+		EventTarget{Type: "post", ID: post.ID},
+		PostUpdatedPayload{Title: post.Title, Revision: rev.Revision, RestoredFrom: rev.RestoredFrom},
+	)
+}
+
+// NewPostDeletedEvent - перенос поста в корзину пользователем actorID
+func NewPostDeletedEvent(actorID int, post *Post) Event {
+	return NewEvent(EventPostDeleted, actorID,
+		EventTarget{Type: "post", ID: post.ID},
+		PostCreatedPayload{Title: post.Title},
+	)
+}
+
+// NewPostRestoredEvent - восстановление поста из корзины пользователем actorID
+func NewPostRestoredEvent(actorID int, post *Post) Event {
+	return NewEvent(EventPostRestored, actorID,
+		EventTarget{Type: "post", ID: post.ID},
+		PostCreatedPayload{Title: post.Title},
+	)
+}
+
+// NewPostApprovedEvent - публикация поста из очереди модерации модератором actorID
+func NewPostApprovedEvent(actorID int, post *Post) Event {
+	return NewEvent(EventPostApproved, actorID,
+		EventTarget{Type: "post", ID: post.ID},
+		PostCreatedPayload{Title: post.Title},
+	)
+}
+
+func NewCommentCreatedEvent(comment *Comment) Event {
+	return NewEvent(EventCommentCreated, comment.AuthorID,
+		EventTarget{Type: "comment", ID: comment.ID},
+		CommentCreatedPayload{PostID: comment.PostID, Status: comment.Status},
+	)
+}
+
+// NewCommentUpdatedEvent - изменение комментария пользователем actorID
+func NewCommentUpdatedEvent(actorID int, comment *Comment) Event {
+	return NewEvent(EventCommentUpdated, actorID,
+		EventTarget{Type: "comment", ID: comment.ID},
+		CommentChangedPayload{PostID: comment.PostID},
+	)
+}
+
+// NewCommentDeletedEvent - удаление комментария пользователем actorID
+func NewCommentDeletedEvent(actorID int, comment *Comment) Event {
+	return NewEvent(EventCommentDeleted, actorID,
+		EventTarget{Type: "comment", ID: comment.ID},
+		CommentChangedPayload{PostID: comment.PostID},
+	)
+}
+
+// NewCommentRestoredEvent - восстановление комментария из корзины пользователем actorID
+func NewCommentRestoredEvent(actorID int, comment *Comment) Event {
+	return NewEvent(EventCommentRestored, actorID,
+		EventTarget{Type: "comment", ID: comment.ID},
+		CommentChangedPayload{PostID: comment.PostID},
+	)
+}
+
+// NewCommentModeratedEvent - одобрение или отклонение комментария модератором actorID
+func NewCommentModeratedEvent(actorID int, comment *Comment) Event {
+	eventType := EventCommentApproved
+	if comment.Status == CommentRejected {
+		eventType = EventCommentRejected
+	}
+	return NewEvent(eventType, actorID,
+		EventTarget{Type: "comment", ID: comment.ID},
+		CommentModeratedPayload{CommentID: comment.ID, PostID: comment.PostID, Reason: comment.ModerationReason},
+	)
+}
+
+// NewContentFlaggedEvent - фильтр спама отправил публикацию автора authorID на модерацию
+func NewContentFlaggedEvent(authorID int, target EventTarget, action string, score int, rules []string) Event {
+	return NewEvent(EventContentFlagged, authorID, target,
+		ContentFlaggedPayload{Action: action, Score: score, Rules: rules},
+	)
+}
+
+func NewUserRegisteredEvent(user *User) Event {
+	return NewEvent(EventUserRegistered, user.ID,
+		EventTarget{Type: "user", ID: user.ID},
+		UserRegisteredPayload{Username: user.Username},
+	)
+}
+
+func NewUserLoggedInEvent(user *User) Event {
+	return NewEvent(EventUserLoggedIn, user.ID,
+		EventTarget{Type: "user", ID: user.ID},
+		UserLoggedInPayload{Username: user.Username},
+	)
+}
+
+// NewLoginFailedEvent - неудачный вход; userID равен 0, если пользователь с таким email не найден
+func NewLoginFailedEvent(userID int, email, reason string) Event {
+	return NewEvent(EventLoginFailed, 0,
+		EventTarget{Type: "user", ID: userID},
+		LoginFailedPayload{Email: email, Reason: reason},
+	)
+}
+
+// NewUserRoleChangedEvent - смена роли пользователя администратором actorID
+func NewUserRoleChangedEvent(actorID int, user *User, oldRole string) Event {
+	return NewEvent(EventUserRoleChanged, actorID,
+		EventTarget{Type: "user", ID: user.ID},
+		UserRoleChangedPayload{OldRole: oldRole, NewRole: user.Role},
+	)
+}
+
+// NewUserDeletedEvent - перенос пользователя в корзину администратором actorID
+func NewUserDeletedEvent(actorID int, user *User) Event {
+	return NewEvent(EventUserDeleted, actorID,
+		EventTarget{Type: "user", ID: user.ID},
+		UserRegisteredPayload{Username: user.Username},
+	)
+}
+
+// NewUserRestoredEvent - восстановление пользователя из корзины администратором actorID
+func NewUserRestoredEvent(actorID int, user *User) Event {
+	return NewEvent(EventUserRestored, actorID,
+		EventTarget{Type: "user", ID: user.ID},
+		UserRegisteredPayload{Username: user.Username},
+	)
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -6,30 +6,67 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// Роли пользователей
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
 type User struct {
 	ID        int       `json:"id" db:"id"`
 	Username  string    `json:"username" db:"username"`
 	Email     string    `json:"email" db:"email"`
 	Password  string    `json:"-" db:"password"`
+	Role      string    `json:"role" db:"role"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt - время мягкого удаления; заполнено только у записей из корзины
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+// Форматы текста постов и комментариев
+const (
+	ContentFormatPlain    = "plain"
+	ContentFormatMarkdown = "markdown"
+)
+
 type Post struct {
-	ID        int       `json:"id" db:"id"`
-	Title     string    `json:"title" db:"title"`
-	Content   string    `json:"content" db:"content"`
-	AuthorID  int       `json:"author_id" db:"author_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID      int    `json:"id" db:"id"`
+	Title   string `json:"title" db:"title"`
+	Content string `json:"content,omitempty" db:"content"`
+	// ContentFormat - формат Content: plain или markdown
+	ContentFormat string `json:"content_format" db:"content_format"`
+	// ContentHTML - безопасный HTML, отрендеренный из Content при записи
+	ContentHTML string `json:"content_html,omitempty" db:"content_html"`
+	// Summary - краткое описание от автора; в списках заменяет автоматическую выдержку
+	Summary   string     `json:"summary,omitempty" db:"summary"`
+	AuthorID  int        `json:"author_id" db:"author_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	Version   int        `json:"version" db:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// ModerateComments - новые комментарии поста проходят модерацию
+	ModerateComments bool `json:"moderate_comments" db:"moderate_comments"`
+	// Status - статус модерации: published или pending
+	Status string `json:"status" db:"status"`
 }
 
 type Comment struct {
-	ID        int       `json:"id" db:"id"`
-	Content   string    `json:"content" db:"content"`
-	PostID    int       `json:"post_id" db:"post_id"`
-	AuthorID  int       `json:"author_id" db:"author_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID            int        `json:"id" db:"id"`
+	Content       string     `json:"content,omitempty" db:"content"`
+	ContentFormat string     `json:"content_format" db:"content_format"`
+	ContentHTML   string     `json:"content_html,omitempty" db:"content_html"`
+	PostID        int        `json:"post_id" db:"post_id"`
+	AuthorID      int        `json:"author_id" db:"author_id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	Version       int        `json:"version" db:"version"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Status - статус модерации: pending, approved или rejected
+	Status string `json:"status" db:"status"`
+	// ModerationReason - причина отклонения, которую видит автор
+	ModerationReason string `json:"moderation_reason,omitempty" db:"moderation_reason"`
 }
 
 type UserCreateRequest struct {
@@ -44,20 +81,38 @@ type UserLoginRequest struct {
 }
 
 type PostCreateRequest struct {
-	Title   string `json:"title" validate:"required,min=1,max=200"`
-	Content string `json:"content" validate:"required,min=1"`
+	Title         string `json:"title" validate:"required,min=1,max=200"`
+	Content       string `json:"content" validate:"required,min=1"`
+	ContentFormat string `json:"content_format" validate:"omitempty,oneof=plain markdown"`
+	Summary       string `json:"summary" validate:"max=500"`
+}
+
+// PostUpdateRequest - правка поста; пустой content_format сохраняет прежний формат.
+// Version - версия поста, которую видел клиент; вместо нее можно передать заголовок If-Match
+type PostUpdateRequest struct {
+	Title         string `json:"title" validate:"required,min=1,max=200"`
+	Content       string `json:"content" validate:"required,min=1"`
+	ContentFormat string `json:"content_format" validate:"omitempty,oneof=plain markdown"`
+	Version       int    `json:"version" validate:"required,gt=0"`
 }
 
 type CommentCreateRequest struct {
-	Content string `json:"content" validate:"required,min=1,max=1000"`
-	PostID  int    `json:"post_id" validate:"required,gt=0"`
+	Content       string `json:"content" validate:"required,min=1,max=1000"`
+	ContentFormat string `json:"content_format" validate:"omitempty,oneof=plain markdown"`
+	PostID        int    `json:"post_id" validate:"required,gt=0"`
+}
+
+type UserRoleUpdateRequest struct {
+	Role string `json:"role" validate:"required,oneof=user editor admin"`
 }
 
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int        `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type TokenResponse struct {
@@ -88,7 +143,9 @@ func (u *User) ToResponse() UserResponse {
 		ID:        u.ID,
 		Username:  u.Username,
 		Email:     u.Email,
+		Role:      u.Role,
 		CreatedAt: u.CreatedAt,
+		DeletedAt: u.DeletedAt,
 	}
 }
 
@@ -102,11 +159,21 @@ func (r *UserLoginRequest) Validate() error {
 	return validate.Struct(r)
 }
 
+func (r *UserRoleUpdateRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
 func (r *PostCreateRequest) Validate() error {
 	validate := validator.New()
 	return validate.Struct(r)
 }
 
+func (r *PostUpdateRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
 func (r *CommentCreateRequest) Validate() error {
 	validate := validator.New()
 	return validate.Struct(r)
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Статусы модерации комментария. Только одобренные комментарии видны всем; ожидающие
+// и отклоненные видят их автор и модераторы
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentRejected = "rejected"
+)
+
+// Статусы модерации поста. Пост, отправленный фильтром спама на модерацию, видят только
+// автор и модераторы, пока его не одобрят
+const (
+	PostPublished = "published"
+	PostPending   = "pending"
+)
+
+// Режимы модерации комментариев
+const (
+	// ModerationOff - комментарии публикуются сразу, кроме постов с включенной модерацией
+	ModerationOff = "off"
+	// ModerationAll - все новые комментарии проходят модерацию
+	ModerationAll = "all"
+)
+
+// ModerationFilter - выборка очереди модерации. AuthorID = 0 - комментарии всех авторов,
+// PostID = 0 - всех постов
+type ModerationFilter struct {
+	Status   string
+	PostID   int
+	AuthorID int
+	Limit    int
+	Offset   int
+}
+
+// ModerationRequest - решение модератора по группе комментариев; причина сообщается авторам
+type ModerationRequest struct {
+	IDs    []int  `json:"ids" validate:"required,min=1,max=100,dive,gt=0"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+func (r *ModerationRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// PostModerationRequest включает или выключает модерацию комментариев отдельного поста
+type PostModerationRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Notification - уведомление пользователя; Type совпадает с типом породившего его события
+type Notification struct {
+	ID        int64           `json:"id"`
+	UserID    int             `json:"user_id"`
+	Type      EventType       `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+}
+
+// NewCommentModeratedNotification уведомляет автора комментария о решении модератора
+func NewCommentModeratedNotification(comment *Comment) *Notification {
+	eventType := EventCommentApproved
+	if comment.Status == CommentRejected {
+		eventType = EventCommentRejected
+	}
+	payload, _ := json.Marshal(CommentModeratedPayload{
+		CommentID: comment.ID,
+		PostID:    comment.PostID,
+		Reason:    comment.ModerationReason,
+	})
+	return &Notification{
+		UserID:  comment.AuthorID,
+		Type:    eventType,
+		Payload: payload,
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Статусы сообщений outbox
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxDead      = "dead"
+)
+
+// OutboxMessage - доменное событие, ожидающее доставки в приемники
+type OutboxMessage struct {
+	ID            int64      `json:"id"`
+	Event         Event      `json:"event"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	// DeliveredSinks - приемники, уже принявшие событие; при повторе оно пишется только в остальные
+	DeliveredSinks []string `json:"delivered_sinks,omitempty"`
+}
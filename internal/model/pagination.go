@@ -0,0 +1,105 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keyset - позиция записи в ленте: значения полей сортировки и ID для однозначности.
+// Title заполняется только для лент, отсортированных по заголовку
+type Keyset struct {
+	CreatedAt time.Time
+	ID        int
+	Title     string
+}
+
+// PageRequest - запрос страницы ленты. Без Position возвращается первая страница.
+// Backward запрашивает записи перед Position в порядке ленты (предыдущую страницу), иначе - после нее.
+// Offset включает прежний режим пагинации по смещению, Position при этом не используется
+type PageRequest struct {
+	Position     *Keyset
+	Backward     bool
+	Offset       int
+	Limit        int
+	IncludeTotal bool
+}
+
+// PageInfo - позиции для перехода к соседним страницам; nil, если страницы в этом направлении нет.
+// Total заполняется, только если он запрошен
+type PageInfo struct {
+	Next  *Keyset
+	Prev  *Keyset
+	Total *int
+}
+
+// Поля, по которым можно сортировать ленту постов
+const (
+	PostSortCreatedAt = "created_at"
+	PostSortTitle     = "title"
+)
+
+// PostSortFields - допустимые поля сортировки ленты постов
+var PostSortFields = []string{PostSortCreatedAt, PostSortTitle}
+
+// SortField - поле сортировки; Desc задает порядок по убыванию
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultPostSort - порядок ленты постов по умолчанию: от новых к старым
+var DefaultPostSort = []SortField{{Field: PostSortCreatedAt, Desc: true}}
+
+// PostFilter - условия выборки и порядок ленты постов; нулевые поля не ограничивают выборку,
+// пустой Sort означает DefaultPostSort
+type PostFilter struct {
+	AuthorID      int
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	TitlePrefix   string
+	HasComments   *bool
+	Sort          []SortField
+}
+
+// SortOrDefault возвращает порядок ленты с учетом значения по умолчанию
+func (f PostFilter) SortOrDefault() []SortField {
+	if len(f.Sort) == 0 {
+		return DefaultPostSort
+	}
+	return f.Sort
+}
+
+// Key - каноническая запись фильтра: одинаковые выборки дают одинаковый ключ.
+// Используется в ключах кеша и в области действия курсоров
+func (f PostFilter) Key() string {
+	var parts []string
+	if f.AuthorID != 0 {
+		parts = append(parts, "author="+strconv.Itoa(f.AuthorID))
+	}
+	if !f.CreatedAfter.IsZero() {
+		parts = append(parts, "after="+strconv.FormatInt(f.CreatedAfter.UnixNano(), 10))
+	}
+	if !f.CreatedBefore.IsZero() {
+		parts = append(parts, "before="+strconv.FormatInt(f.CreatedBefore.UnixNano(), 10))
+	}
+	if f.TitlePrefix != "" {
+		parts = append(parts, "title="+strconv.Quote(f.TitlePrefix))
+	}
+	if f.HasComments != nil {
+		parts = append(parts, "has_comments="+strconv.FormatBool(*f.HasComments))
+	}
+
+	sort := make([]string, 0, len(f.SortOrDefault()))
+	for _, s := range f.SortOrDefault() {
+		if s.Desc {
+			sort = append(sort, "-"+s.Field)
+		} else {
+			sort = append(sort, s.Field)
+		}
+	}
+	parts = append(parts, fmt.Sprintf("sort=%s", strings.Join(sort, ",")))
+
+	return strings.Join(parts, "&")
+}
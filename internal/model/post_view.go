@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// ExcerptLength - длина автоматической выдержки в символах
+	ExcerptLength = 280
+	// wordsPerMinute - средняя скорость чтения для оценки времени
+	wordsPerMinute = 200
+)
+
+// PostListItem - представление поста в списках: вместо полного текста выдержка,
+// число слов и оценка времени чтения
+type PostListItem struct {
+	ID                 int       `json:"id"`
+	Title              string    `json:"title"`
+	Excerpt            string    `json:"excerpt"`
+	AuthorID           int       `json:"author_id"`
+	CreatedAt          time.Time `json:"created_at"`
+	WordCount          int       `json:"word_count"`
+	ReadingTimeMinutes int       `json:"reading_time_minutes"`
+}
+
+// NewPostListItem строит представление поста для списка. Выдержкой служит Summary,
+// если автор его задал, иначе начало текста
+func NewPostListItem(p *Post) PostListItem {
+	words := CountWords(p.Content)
+	excerpt := p.Summary
+	if excerpt == "" {
+		excerpt = Excerpt(p.Content, ExcerptLength)
+	}
+	return PostListItem{
+		ID:                 p.ID,
+		Title:              p.Title,
+		Excerpt:            excerpt,
+		AuthorID:           p.AuthorID,
+		CreatedAt:          p.CreatedAt,
+		WordCount:          words,
+		ReadingTimeMinutes: ReadingTime(words),
+	}
+}
+
+// CountWords считает слова, разделенные пробельными символами
+func CountWords(s string) int {
+	return len(strings.Fields(s))
+}
+
+// ReadingTime оценивает время чтения в минутах с округлением вверх; непустой текст - минимум минута
+func ReadingTime(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// Excerpt возвращает первые limit символов текста, не разрывая слово, с многоточием,
+// если текст обрезан. Переводы строк и повторяющиеся пробелы схлопываются
+func Excerpt(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	runes := []rune(s)
+	cut := limit
+	// Отступаем к границе слова, если она не слишком далеко
+	for i := limit; i > limit/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"short text unchanged", "Hello\n\n  world", 20, "Hello world"},
+		{"cut at word boundary", "The quick brown fox jumps", 12, "The quick…"},
+		{"trailing punctuation dropped", "Привет, мир и все остальные", 8, "Привет…"},
+		{"long word is cut", strings.Repeat("a", 30), 10, strings.Repeat("a", 10) + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Excerpt(tt.text, tt.limit); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewPostListItem(t *testing.T) {
+	post := &Post{ID: 1, Title: "Long read", Content: strings.Repeat("word ", 450), AuthorID: 2}
+
+	item := NewPostListItem(post)
+	if item.WordCount != 450 || item.ReadingTimeMinutes != 3 {
+		t.Errorf("expected 450 words and 3 minutes, got %d and %d", item.WordCount, item.ReadingTimeMinutes)
+	}
+	if len([]rune(item.Excerpt)) > ExcerptLength+1 || !strings.HasSuffix(item.Excerpt, "…") {
+		t.Errorf("unexpected excerpt %q", item.Excerpt)
+	}
+
+	post.Summary = "Written by the author"
+	if item := NewPostListItem(post); item.Excerpt != post.Summary {
+		t.Errorf("expected summary to be used as excerpt, got %q", item.Excerpt)
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// PostRevision - сохраненная версия заголовка и текста поста. Версия 1 - пост при создании,
+// каждое изменение и восстановление добавляет следующую
+type PostRevision struct {
+	PostID        int    `json:"post_id"`
+	Revision      int    `json:"revision"`
+	Title         string `json:"title"`
+	Content       string `json:"content,omitempty"`
+	ContentFormat string `json:"content_format"`
+	// EditorID - пользователь, сохранивший версию
+	EditorID int `json:"editor_id"`
+	// RestoredFrom - номер версии, из которой восстановлен текст; 0 для обычных правок
+	RestoredFrom int       `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RevisionDiff - разница между двумя версиями поста: заголовки и построчный diff текста
+// в унифицированном формате
+type RevisionDiff struct {
+	PostID    int    `json:"post_id"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	FromTitle string `json:"from_title"`
+	ToTitle   string `json:"to_title"`
+	Diff      string `json:"diff"`
+}
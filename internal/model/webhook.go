@@ -0,0 +1,92 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook - подписка внешней системы на доменные события
+type Webhook struct {
+	ID      int    `json:"id"`
+	OwnerID int    `json:"owner_id"`
+	URL     string `json:"url"`
+	// Secret возвращается только при создании подписки
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches сообщает, подписан ли вебхук на событие. Пустой фильтр означает все события,
+// элемент вида "post.*" - все события с префиксом "post."
+func (w *Webhook) Matches(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, pattern := range w.Events {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(string(eventType), prefix) {
+				return true
+			}
+		} else if pattern == string(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery - попытка доставить событие в конкретный вебхук
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int             `json:"webhook_id"`
+	EventID       string          `json:"event_id"`
+	EventType     EventType       `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  *int            `json:"response_code,omitempty"`
+	ResponseBody  string          `json:"response_body,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	DurationMS    *int            `json:"duration_ms,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+
+	// URL и Secret заполняются при выборке доставок для отправки
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+type WebhookCreateRequest struct {
+	URL    string   `json:"url" validate:"required,url,startswith=http"`
+	Events []string `json:"events" validate:"dive,required,max=64"`
+	// Secret можно не указывать - тогда он будет сгенерирован
+	Secret string `json:"secret" validate:"omitempty,min=16,max=128"`
+}
+
+type WebhookUpdateRequest struct {
+	URL    *string   `json:"url" validate:"omitempty,url,startswith=http"`
+	Events *[]string `json:"events" validate:"omitempty,dive,required,max=64"`
+	Active *bool     `json:"active"`
+}
+
+func (r *WebhookCreateRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *WebhookUpdateRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
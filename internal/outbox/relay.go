@@ -0,0 +1,275 @@
+package outbox
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/metrics"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/pkg/retry"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RelayConfig содержит настройки ретранслятора outbox
+type RelayConfig struct {
+	// PollInterval - как часто проверять outbox на новые события
+	PollInterval time.Duration
+	// BatchSize - сколько событий захватывать за один раз
+	BatchSize int
+	// MaxAttempts - после стольких неудачных попыток событие переходит в dead-letter
+	MaxAttempts int
+	// BaseBackoff и MaxBackoff задают экспоненциальную задержку между попытками
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// SinkTimeout - максимальное время записи пакета в один приемник
+	SinkTimeout time.Duration
+	// LeaseTimeout - на сколько захватывается пакет; после падения реплики он снова
+	// станет доступен по истечении аренды. Не меньше двух SinkTimeout
+	LeaseTimeout time.Duration
+}
+
+// Relay доставляет события из таблицы outbox в приемники с семантикой at-least-once:
+// событие считается доставленным, только когда его приняли все приемники.
+// Каждый приемник отмечается отдельно, и при повторе событие пишется только в те,
+// что его не приняли. После падения посреди пакета приемники могут получить дубликаты
+type Relay struct {
+	repo  repository.OutboxRepository
+	tx    repository.TxManager
+	sinks []logger.Sink
+	cfg   RelayConfig
+
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	running atomic.Bool
+
+	now func() time.Time
+}
+
+func NewRelay(repo repository.OutboxRepository, tx repository.TxManager, cfg RelayConfig, sinks ...logger.Sink) *Relay {
+	return &Relay{
+		repo:  repo,
+		tx:    tx,
+		sinks: sinks,
+		cfg:   withRelayDefaults(cfg),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		now:   time.Now,
+	}
+}
+
+func withRelayDefaults(cfg RelayConfig) RelayConfig {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Minute
+	}
+	if cfg.SinkTimeout <= 0 {
+		cfg.SinkTimeout = 5 * time.Second
+	}
+	if cfg.LeaseTimeout < 2*cfg.SinkTimeout {
+		cfg.LeaseTimeout = max(time.Minute, 2*cfg.SinkTimeout)
+	}
+	return cfg
+}
+
+func (r *Relay) Start() {
+	r.running.Store(true)
+	go r.run()
+}
+
+// Running сообщает, работает ли горутина доставки
+func (r *Relay) Running() bool {
+	return r.running.Load()
+}
+
+// Stop останавливает опрос outbox, делает последнюю попытку доставки и закрывает приемники.
+// Недоставленные события остаются в outbox и будут доставлены после перезапуска
+func (r *Relay) Stop() {
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+
+		for _, sink := range r.sinks {
+			if err := sink.Close(); err != nil {
+				slog.Error("failed to close event sink", "sink", sink.Name(), "error", err)
+			}
+		}
+		slog.Info("outbox relay stopped gracefully")
+	})
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+	defer r.running.Store(false)
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			r.drain()
+			return
+		case <-ticker.C:
+			r.drain()
+			r.observe()
+		}
+	}
+}
+
+// drain доставляет пакеты, пока outbox не опустеет или не случится ошибка
+func (r *Relay) drain() {
+	for {
+		n, err := r.ProcessBatch(context.Background())
+		if err != nil {
+			slog.Error("failed to process outbox batch", "error", err)
+			return
+		}
+		if n < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// observe обновляет метрики числа ожидающих и dead-letter событий
+func (r *Relay) observe() {
+	counts, err := r.repo.CountByStatus(context.Background())
+	if err != nil {
+		slog.Error("failed to count outbox events", "error", err)
+		return
+	}
+	metrics.OutboxPending.Set(float64(counts[model.OutboxPending]))
+	metrics.OutboxDead.Set(float64(counts[model.OutboxDead]))
+}
+
+// ProcessBatch доставляет один пакет событий и возвращает его размер.
+// Пакет захватывается арендой, запись в приемники идет вне транзакции,
+// а результаты фиксируются отдельной короткой транзакцией
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	messages, err := r.repo.Claim(ctx, r.cfg.BatchSize, r.now().Add(r.cfg.LeaseTimeout))
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	batches, errs := r.deliver(ctx, messages)
+
+	err = r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return r.record(ctx, messages, batches, errs)
+	})
+	return len(messages), err
+}
+
+// deliver параллельно пишет в каждый приемник события, которые он еще не принял;
+// медленный приемник не задерживает запись в остальные. Возвращает отправленные
+// каждому приемнику события и ошибки по приемникам
+func (r *Relay) deliver(ctx context.Context, messages []*model.OutboxMessage) ([][]*model.OutboxMessage, []error) {
+	batches := make([][]*model.OutboxMessage, len(r.sinks))
+	errs := make([]error, len(r.sinks))
+
+	var wg sync.WaitGroup
+	for i, sink := range r.sinks {
+		var events []model.Event
+		for _, msg := range messages {
+			if !slices.Contains(msg.DeliveredSinks, sink.Name()) {
+				batches[i] = append(batches[i], msg)
+				events = append(events, msg.Event)
+			}
+		}
+		if len(events) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := logger.WriteToSink(ctx, sink, events, r.cfg.SinkTimeout); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", sink.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return batches, errs
+}
+
+// record отмечает приемники, принявшие события, завершает события, принятые всеми
+// приемниками, и планирует повтор для остальных
+func (r *Relay) record(ctx context.Context, messages []*model.OutboxMessage, batches [][]*model.OutboxMessage, errs []error) error {
+	failed := make(map[int64][]error)
+	for i, sink := range r.sinks {
+		if len(batches[i]) == 0 {
+			continue
+		}
+
+		if errs[i] != nil {
+			for _, msg := range batches[i] {
+				failed[msg.ID] = append(failed[msg.ID], errs[i])
+			}
+			continue
+		}
+
+		ids := make([]int64, 0, len(batches[i]))
+		for _, msg := range batches[i] {
+			ids = append(ids, msg.ID)
+		}
+		if err := r.repo.MarkSinkDelivered(ctx, sink.Name(), ids); err != nil {
+			return err
+		}
+	}
+
+	var delivered []int64
+	for _, msg := range messages {
+		if len(failed[msg.ID]) == 0 {
+			delivered = append(delivered, msg.ID)
+			continue
+		}
+		if err := r.scheduleRetry(ctx, msg, errors.Join(failed[msg.ID]...)); err != nil {
+			return err
+		}
+	}
+
+	if len(delivered) > 0 {
+		if err := r.repo.MarkDelivered(ctx, delivered); err != nil {
+			return err
+		}
+		metrics.OutboxDelivered.Add(float64(len(delivered)))
+	}
+	return nil
+}
+
+func (r *Relay) scheduleRetry(ctx context.Context, msg *model.OutboxMessage, deliverErr error) error {
+	attempts := msg.Attempts + 1
+	dead := attempts >= r.cfg.MaxAttempts
+
+	if err := r.repo.MarkFailed(ctx, msg.ID, attempts, r.now().Add(retry.Backoff(r.cfg.BaseBackoff, r.cfg.MaxBackoff, attempts)), deliverErr.Error(), dead); err != nil {
+		return err
+	}
+
+	if dead {
+		metrics.OutboxDeadLettered.Inc()
+		slog.Error("outbox event moved to dead-letter", "event_id", msg.Event.ID, "event_type", msg.Event.Type, "attempts", attempts, "error", deliverErr)
+	} else {
+		metrics.OutboxRetries.Inc()
+	}
+	return nil
+}
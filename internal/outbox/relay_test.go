@@ -0,0 +1,302 @@
+package outbox
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryOutbox - реализация OutboxRepository в памяти
+type memoryOutbox struct {
+	mu       sync.Mutex
+	messages []*model.OutboxMessage
+	leases   map[int64]time.Time
+	clock    time.Time
+}
+
+func (m *memoryOutbox) Publish(ctx context.Context, e model.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, &model.OutboxMessage{
+		ID:     int64(len(m.messages) + 1),
+		Event:  e,
+		Status: model.OutboxPending,
+	})
+	return nil
+}
+
+func (m *memoryOutbox) Claim(ctx context.Context, limit int, lockedUntil time.Time) ([]*model.OutboxMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leases == nil {
+		m.leases = make(map[int64]time.Time)
+	}
+	var claimed []*model.OutboxMessage
+	for _, msg := range m.messages {
+		if msg.Status != model.OutboxPending || len(claimed) >= limit {
+			continue
+		}
+		if until, ok := m.leases[msg.ID]; ok && until.After(m.clock) {
+			continue
+		}
+		m.leases[msg.ID] = lockedUntil
+		copied := *msg
+		copied.DeliveredSinks = slices.Clone(msg.DeliveredSinks)
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (m *memoryOutbox) MarkSinkDelivered(ctx context.Context, sink string, ids []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		msg := m.messages[id-1]
+		if !slices.Contains(msg.DeliveredSinks, sink) {
+			msg.DeliveredSinks = append(msg.DeliveredSinks, sink)
+		}
+	}
+	return nil
+}
+
+func (m *memoryOutbox) MarkDelivered(ctx context.Context, ids []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		m.messages[id-1].Status = model.OutboxDelivered
+		delete(m.leases, id)
+	}
+	return nil
+}
+
+func (m *memoryOutbox) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msg := m.messages[id-1]
+	msg.Attempts = attempts
+	msg.NextAttemptAt = nextAttemptAt
+	msg.LastError = lastError
+	delete(m.leases, id)
+	if dead {
+		msg.Status = model.OutboxDead
+	}
+	return nil
+}
+
+func (m *memoryOutbox) ListByStatus(ctx context.Context, status string, limit, offset int) ([]*model.OutboxMessage, error) {
+	return nil, nil
+}
+
+func (m *memoryOutbox) Requeue(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (m *memoryOutbox) CountByStatus(ctx context.Context) (map[string]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[string]int)
+	for _, msg := range m.messages {
+		counts[msg.Status]++
+	}
+	return counts, nil
+}
+
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type txContextKey struct{}
+
+// markingTx помечает контекст транзакции, чтобы приемник мог проверить, что пишет вне нее
+type markingTx struct{}
+
+func (markingTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txContextKey{}, true))
+}
+
+// recordingSink запоминает полученные события и может возвращать ошибку
+type recordingSink struct {
+	name   string
+	err    error
+	mu     sync.Mutex
+	events []model.Event
+	closed bool
+	inTx   bool
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Write(ctx context.Context, events []model.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	if ctx.Value(txContextKey{}) != nil {
+		s.inTx = true
+	}
+	return s.err
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func publishEvents(t *testing.T, repo *memoryOutbox, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		e := model.NewEvent(model.EventPostCreated, 1, model.EventTarget{Type: "post", ID: i}, nil)
+		if err := repo.Publish(context.Background(), e); err != nil {
+			t.Fatalf("publish failed: %v", err)
+		}
+	}
+}
+
+func TestRelay_DeliversToAllSinks(t *testing.T) {
+	repo := &memoryOutbox{}
+	publishEvents(t, repo, 3)
+
+	first, second := &recordingSink{name: "first"}, &recordingSink{name: "second"}
+	relay := NewRelay(repo, noTx{}, RelayConfig{BatchSize: 10}, first, second)
+
+	n, err := relay.ProcessBatch(context.Background())
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 processed events, got %d, %v", n, err)
+	}
+
+	if len(first.events) != 3 || len(second.events) != 3 {
+		t.Errorf("expected both sinks to receive 3 events, got %d and %d", len(first.events), len(second.events))
+	}
+	for _, msg := range repo.messages {
+		if msg.Status != model.OutboxDelivered {
+			t.Errorf("expected message %d to be delivered, got %s", msg.ID, msg.Status)
+		}
+	}
+}
+
+func TestRelay_RetriesWithBackoffAndDeadLetters(t *testing.T) {
+	repo := &memoryOutbox{}
+	publishEvents(t, repo, 1)
+
+	healthy := &recordingSink{name: "healthy"}
+	failing := &recordingSink{name: "failing", err: errors.New("unavailable")}
+	relay := NewRelay(repo, noTx{}, RelayConfig{MaxAttempts: 2, BaseBackoff: time.Minute, MaxBackoff: time.Hour}, healthy, failing)
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	relay.now = func() time.Time { return now }
+
+	if _, err := relay.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg := repo.messages[0]
+	if msg.Status != model.OutboxPending || msg.Attempts != 1 {
+		t.Fatalf("expected pending message with 1 attempt, got %s/%d", msg.Status, msg.Attempts)
+	}
+	if delay := msg.NextAttemptAt.Sub(now); delay < 30*time.Second || delay > time.Minute {
+		t.Errorf("expected backoff between 30s and 1m, got %v", delay)
+	}
+	if len(healthy.events) != 1 {
+		t.Errorf("expected healthy sink to receive the event despite the failing one, got %d", len(healthy.events))
+	}
+
+	if _, err := relay.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.Status != model.OutboxDead || msg.LastError == "" {
+		t.Errorf("expected dead-lettered message with last error, got %s %q", msg.Status, msg.LastError)
+	}
+	if len(healthy.events) != 1 || len(failing.events) != 2 {
+		t.Errorf("expected retries to go only to the failing sink, got %d and %d", len(healthy.events), len(failing.events))
+	}
+}
+
+func TestRelay_RecordsEachSinkSeparately(t *testing.T) {
+	repo := &memoryOutbox{}
+	publishEvents(t, repo, 2)
+
+	healthy := &recordingSink{name: "healthy"}
+	flaky := &recordingSink{name: "flaky", err: errors.New("unavailable")}
+	relay := NewRelay(repo, noTx{}, RelayConfig{BatchSize: 10}, healthy, flaky)
+
+	if _, err := relay.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, msg := range repo.messages {
+		if !slices.Equal(msg.DeliveredSinks, []string{"healthy"}) {
+			t.Errorf("expected message %d to be recorded for the healthy sink only, got %v", msg.ID, msg.DeliveredSinks)
+		}
+		// Повтор доступен сразу, без ожидания задержки и аренды
+		msg.NextAttemptAt = time.Time{}
+		delete(repo.leases, msg.ID)
+	}
+
+	flaky.err = nil
+	if _, err := relay.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(healthy.events) != 2 || len(flaky.events) != 4 {
+		t.Errorf("expected healthy sink to get each event once, got %d and %d", len(healthy.events), len(flaky.events))
+	}
+	for _, msg := range repo.messages {
+		if msg.Status != model.OutboxDelivered {
+			t.Errorf("expected message %d to be delivered, got %s", msg.ID, msg.Status)
+		}
+	}
+}
+
+func TestRelay_WritesSinksOutsideTransactionUnderLease(t *testing.T) {
+	repo := &memoryOutbox{}
+	publishEvents(t, repo, 1)
+
+	failing := &recordingSink{name: "failing", err: errors.New("unavailable")}
+	relay := NewRelay(repo, markingTx{}, RelayConfig{LeaseTimeout: time.Minute}, failing)
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	relay.now = func() time.Time { return now }
+	repo.clock = now
+
+	claimed, err := repo.Claim(context.Background(), 10, now.Add(time.Minute))
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected to claim 1 event, got %d, %v", len(claimed), err)
+	}
+
+	// Событие захвачено другой репликой: пока аренда действует, оно пропускается
+	if n, err := relay.ProcessBatch(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected leased event to be skipped, got %d, %v", n, err)
+	}
+
+	repo.clock = now.Add(2 * time.Minute)
+	if n, err := relay.ProcessBatch(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected expired lease to be reclaimed, got %d, %v", n, err)
+	}
+	if failing.inTx {
+		t.Error("expected sink to be written outside the transaction")
+	}
+	if _, ok := repo.leases[1]; ok {
+		t.Error("expected lease to be released after recording the failure")
+	}
+}
+
+func TestRelay_StopDeliversPendingAndClosesSinks(t *testing.T) {
+	repo := &memoryOutbox{}
+	sink := &recordingSink{name: "sink"}
+	relay := NewRelay(repo, noTx{}, RelayConfig{PollInterval: time.Hour}, sink)
+	relay.Start()
+
+	publishEvents(t, repo, 2)
+	relay.Stop()
+
+	if len(sink.events) != 2 {
+		t.Errorf("expected pending events to be delivered on stop, got %d", len(sink.events))
+	}
+	if !sink.closed {
+		t.Error("expected sink to be closed")
+	}
+	if relay.Running() {
+		t.Error("expected relay to be stopped")
+	}
+}
@@ -0,0 +1,94 @@
+package pagination
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macSize - длина подписи курсора; 128 бит достаточно, чтобы подделка была невозможна
+const macSize = 16
+
+// Cursor - содержимое курсора: позиция в ленте и направление перехода от нее
+type Cursor struct {
+	Position model.Keyset
+	Backward bool
+}
+
+// Codec кодирует курсоры в непрозрачные строки и проверяет их подпись (HMAC-SHA256).
+// Подпись включает область действия курсора (scope), например ленту конкретного поста,
+// поэтому курсор одной ленты нельзя подставить в другую
+type Codec struct {
+	secret []byte
+}
+
+func NewCodec(secret string) *Codec {
+	return &Codec{secret: []byte(secret)}
+}
+
+// Encode возвращает курсор в виде base64url(данные).base64url(подпись)
+func (c *Codec) Encode(scope string, cursor Cursor) string {
+	direction := "n"
+	if cursor.Backward {
+		direction = "p"
+	}
+	payload := fmt.Sprintf("%s.%d.%d", direction, cursor.Position.CreatedAt.UnixNano(), cursor.Position.ID)
+	if cursor.Position.Title != "" {
+		// Заголовок идет последним, поэтому точки в нем не мешают разбору
+		payload += "." + cursor.Position.Title
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(scope, payload))
+}
+
+// Decode проверяет подпись и разбирает курсор; любая ошибка возвращается как ErrInvalidCursor
+func (c *Codec) Decode(scope, token string) (Cursor, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return Cursor{}, apperrors.ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Cursor{}, apperrors.ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, c.sign(scope, string(payload))) {
+		return Cursor{}, apperrors.ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(payload), ".", 4)
+	if len(parts) < 3 || (parts[0] != "n" && parts[0] != "p") {
+		return Cursor{}, apperrors.ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, apperrors.ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil || id <= 0 {
+		return Cursor{}, apperrors.ErrInvalidCursor
+	}
+
+	cursor := Cursor{
+		Position: model.Keyset{CreatedAt: time.Unix(0, nanos).UTC(), ID: id},
+		Backward: parts[0] == "p",
+	}
+	if len(parts) == 4 {
+		cursor.Position.Title = parts[3]
+	}
+	return cursor, nil
+}
+
+func (c *Codec) sign(scope, payload string) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write([]byte(payload))
+	return h.Sum(nil)[:macSize]
+}
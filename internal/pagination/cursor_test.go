@@ -0,0 +1,52 @@
+package pagination
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := NewCodec("secret")
+	cursor := Cursor{
+		Position: model.Keyset{CreatedAt: time.Date(2024, 1, 15, 10, 30, 0, 123456000, time.UTC), ID: 42},
+		Backward: true,
+	}
+
+	decoded, err := codec.Decode("posts", codec.Encode("posts", cursor))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !decoded.Position.CreatedAt.Equal(cursor.Position.CreatedAt) || decoded.Position.ID != 42 || !decoded.Backward {
+		t.Errorf("unexpected cursor %+v", decoded)
+	}
+}
+
+func TestCodec_RejectsForeignAndTamperedCursors(t *testing.T) {
+	codec := NewCodec("secret")
+	token := codec.Encode("comments:1", Cursor{Position: model.Keyset{CreatedAt: time.Now(), ID: 7}})
+	payload, mac, _ := strings.Cut(token, ".")
+
+	tests := map[string]struct {
+		codec *Codec
+		scope string
+		token string
+	}{
+		"other scope":  {codec, "comments:2", token},
+		"other secret": {NewCodec("other"), "comments:1", token},
+		"tampered":     {codec, "comments:1", strings.ToUpper(payload) + "." + mac},
+		"no signature": {codec, "comments:1", payload},
+		"garbage":      {codec, "comments:1", "%%%"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := tt.codec.Decode(tt.scope, tt.token); !errors.Is(err, apperrors.ErrInvalidCursor) {
+				t.Errorf("expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package pubsub
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrHubClosed - хаб остановлен (сервер завершает работу)
+	ErrHubClosed = errors.New("pubsub hub closed")
+	// ErrSlowConsumer - подписчик не успевал читать сообщения и был отключен
+	ErrSlowConsumer = errors.New("subscriber buffer overflow")
+)
+
+// Message - сообщение, рассылаемое подписчикам темы
+type Message struct {
+	// ID - монотонный идентификатор в пределах темы, по нему клиент возобновляет поток
+	ID int64
+	// Event - тип сообщения, например post.created
+	Event string
+	// Data - тело сообщения в JSON
+	Data []byte
+}
+
+// Broker - рассылка сообщений подписчикам потоков (SSE, WebSocket). Hub работает в пределах
+// одного процесса; для нескольких реплик Broker реализуется поверх общего канала (например,
+// Postgres LISTEN/NOTIFY), который доставляет сообщения в локальный Hub каждой реплики
+type Broker interface {
+	Publish(topic string, msg Message)
+	Subscribe(topic string) (*Subscription, error)
+	Close()
+}
+
+var _ Broker = (*Hub)(nil)
+
+// Hub - внутрипроцессная рассылка сообщений по темам. Publish никогда не блокируется:
+// если буфер подписчика заполнен, подписка закрывается с ErrSlowConsumer,
+// и клиент должен переподключиться и догрузить пропущенное из БД
+type Hub struct {
+	mu     sync.Mutex
+	topics map[string]map[*Subscription]struct{}
+	buffer int
+	closed bool
+}
+
+// NewHub создает хаб; buffer - сколько сообщений может накопиться у одного подписчика
+func NewHub(buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	return &Hub{
+		topics: make(map[string]map[*Subscription]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Subscribe подписывается на тему. Подписку нужно закрыть вызовом Close
+func (h *Hub) Subscribe(topic string) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+
+	sub := &Subscription{
+		hub:   h,
+		topic: topic,
+		ch:    make(chan Message, h.buffer),
+	}
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*Subscription]struct{})
+	}
+	h.topics[topic][sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish рассылает сообщение всем подписчикам темы
+func (h *Hub) Publish(topic string, msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.topics[topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			h.remove(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+// Subscribers возвращает количество подписчиков темы
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.topics[topic])
+}
+
+// Close закрывает все подписки и запрещает новые
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.topics {
+		for sub := range subs {
+			h.remove(sub, ErrHubClosed)
+		}
+	}
+}
+
+// remove вызывается под h.mu
+func (h *Hub) remove(sub *Subscription, err error) {
+	subs, ok := h.topics[sub.topic]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.topics, sub.topic)
+	}
+	sub.err = err
+	close(sub.ch)
+}
+
+// Subscription - подписка на тему. Канал Messages закрывается, когда подписка завершена;
+// причину возвращает Err
+type Subscription struct {
+	hub   *Hub
+	topic string
+	ch    chan Message
+	err   error
+}
+
+func (s *Subscription) Messages() <-chan Message {
+	return s.ch
+}
+
+// Err возвращает причину закрытия подписки; nil, если ее закрыл сам подписчик
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Close отписывается от темы
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s, nil)
+}
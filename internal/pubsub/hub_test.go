@@ -0,0 +1,74 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHub_PublishToTopicSubscribers(t *testing.T) {
+	hub := NewHub(4)
+	posts, _ := hub.Subscribe("posts")
+	other, _ := hub.Subscribe("posts/1/comments")
+	defer posts.Close()
+	defer other.Close()
+
+	hub.Publish("posts", Message{ID: 1, Event: "post.created", Data: []byte(`{}`)})
+
+	select {
+	case msg := <-posts.Messages():
+		if msg.ID != 1 || msg.Event != "post.created" {
+			t.Errorf("unexpected message: %+v", msg)
+		}
+	default:
+		t.Fatal("expected message for posts subscriber")
+	}
+
+	select {
+	case msg := <-other.Messages():
+		t.Errorf("unexpected message on other topic: %+v", msg)
+	default:
+	}
+}
+
+func TestHub_DropsSlowConsumer(t *testing.T) {
+	hub := NewHub(2)
+	slow, _ := hub.Subscribe("posts")
+
+	for i := range 3 {
+		hub.Publish("posts", Message{ID: int64(i + 1)})
+	}
+
+	var received int
+	for range slow.Messages() {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("expected 2 buffered messages before disconnect, got %d", received)
+	}
+	if !errors.Is(slow.Err(), ErrSlowConsumer) {
+		t.Errorf("expected ErrSlowConsumer, got %v", slow.Err())
+	}
+	if n := hub.Subscribers("posts"); n != 0 {
+		t.Errorf("expected slow subscriber to be removed, got %d subscribers", n)
+	}
+}
+
+func TestHub_CloseEndsSubscriptions(t *testing.T) {
+	hub := NewHub(1)
+	sub, _ := hub.Subscribe("posts")
+
+	hub.Close()
+
+	if _, ok := <-sub.Messages(); ok {
+		t.Error("expected subscription channel to be closed")
+	}
+	if !errors.Is(sub.Err(), ErrHubClosed) {
+		t.Errorf("expected ErrHubClosed, got %v", sub.Err())
+	}
+	if _, err := hub.Subscribe("posts"); !errors.Is(err, ErrHubClosed) {
+		t.Errorf("expected Subscribe to fail after Close, got %v", err)
+	}
+
+	// Closing the subscription after the hub has stopped is safe
+	sub.Close()
+}
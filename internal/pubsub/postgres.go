@@ -0,0 +1,158 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// NotifyChannel - канал Postgres LISTEN/NOTIFY, через который реплики обмениваются сообщениями
+const NotifyChannel = "blog_pubsub"
+
+const (
+	// notifyTimeout - сколько ждать отправки NOTIFY, прежде чем отказаться от рассылки другим репликам
+	notifyTimeout = 2 * time.Second
+	// loadTimeout - сколько ждать загрузки сообщения из журнала по уведомлению другой реплики
+	loadTimeout = 5 * time.Second
+	// receiveQueue - сколько уведомлений может ждать загрузки; при переполнении лишние отбрасываются,
+	// а подписчики догружают их из журнала, заметив пропуск номеров
+	receiveQueue = 1024
+)
+
+// Notifier отправляет сообщение всем репликам; реализуется database.Notifier
+type Notifier interface {
+	Notify(ctx context.Context, channel string, payload []byte) error
+}
+
+// Store загружает сообщение темы из журнала по номеру; реализуется repository.StreamRepo
+type Store interface {
+	Load(ctx context.Context, topic string, id int64) (Message, error)
+}
+
+// envelope - сообщение в канале NOTIFY. Payload NOTIFY ограничен 8000 байт, поэтому сообщения
+// с номером передаются без Data и загружаются получателем из журнала. Data (JSON) передается
+// только для сообщений без номера (присутствие и набор текста), которые в журнал не пишутся
+type envelope struct {
+	Origin string          `json:"origin"`
+	Topic  string          `json:"topic"`
+	ID     int64           `json:"id,omitempty"`
+	Event  string          `json:"event"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// PostgresBroker рассылает сообщения подписчикам всех реплик. Publish сразу доставляет сообщение
+// в локальный Hub и отправляет уведомление через NOTIFY; реплики получают его в Receive, который
+// подключается к database.Listener, загружают сообщение из журнала Store и пересылают своим
+// подписчикам. Собственные сообщения, вернувшиеся через NOTIFY, отбрасываются по идентификатору реплики.
+// Сообщения, пропущенные при обрыве соединения, клиенты догружают из БД при переподключении
+type PostgresBroker struct {
+	hub      *Hub
+	notifier Notifier
+	store    Store
+	origin   string
+
+	received chan envelope
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+var _ Broker = (*PostgresBroker)(nil)
+
+func NewPostgresBroker(hub *Hub, notifier Notifier, store Store) *PostgresBroker {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	broker := &PostgresBroker{
+		hub:      hub,
+		notifier: notifier,
+		store:    store,
+		origin:   hex.EncodeToString(b),
+		received: make(chan envelope, receiveQueue),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go broker.run()
+	return broker
+}
+
+// Publish доставляет сообщение локальным подписчикам и уведомляет другие реплики.
+// Сообщение с номером к этому моменту должно быть зафиксировано в журнале.
+// Ошибка NOTIFY только логируется: локальная доставка уже выполнена
+func (b *PostgresBroker) Publish(topic string, msg Message) {
+	b.hub.Publish(topic, msg)
+
+	e := envelope{Origin: b.origin, Topic: topic, ID: msg.ID, Event: msg.Event}
+	if msg.ID == 0 {
+		e.Data = msg.Data
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("failed to encode pubsub notification", "topic", topic, "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := b.notifier.Notify(ctx, NotifyChannel, payload); err != nil {
+		slog.Warn("failed to fan out message to other replicas", "topic", topic, "event", msg.Event, "error", err)
+	}
+}
+
+// Receive обрабатывает уведомление из канала NotifyChannel. Вызывается в горутине слушателя,
+// поэтому загрузка из журнала выполняется отдельной горутиной
+func (b *PostgresBroker) Receive(payload []byte) {
+	var e envelope
+	if err := json.Unmarshal(payload, &e); err != nil {
+		slog.Error("failed to decode pubsub notification", "error", err)
+		return
+	}
+	if e.Origin == b.origin {
+		return
+	}
+	if e.ID == 0 {
+		b.hub.Publish(e.Topic, Message{Event: e.Event, Data: e.Data})
+		return
+	}
+
+	select {
+	case b.received <- e:
+	default:
+		slog.Warn("pubsub receive queue is full, dropping notification", "topic", e.Topic, "id", e.ID)
+	}
+}
+
+// run загружает сообщения по уведомлениям в порядке их получения и доставляет локальным подписчикам
+func (b *PostgresBroker) run() {
+	defer close(b.done)
+	for {
+		select {
+		case <-b.stop:
+			return
+		case e := <-b.received:
+			ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+			msg, err := b.store.Load(ctx, e.Topic, e.ID)
+			cancel()
+			if err != nil {
+				slog.Error("failed to load pubsub message", "topic", e.Topic, "id", e.ID, "error", err)
+				continue
+			}
+			b.hub.Publish(e.Topic, msg)
+		}
+	}
+}
+
+func (b *PostgresBroker) Subscribe(topic string) (*Subscription, error) {
+	return b.hub.Subscribe(topic)
+}
+
+func (b *PostgresBroker) Close() {
+	b.once.Do(func() {
+		close(b.stop)
+		<-b.done
+	})
+	b.hub.Close()
+}
@@ -0,0 +1,147 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// notifyLimit mirrors the Postgres limit on the NOTIFY payload size
+const notifyLimit = 8000
+
+// loopbackNotifier delivers every NOTIFY to all registered replicas, including the sender,
+// like Postgres does for connections listening on the channel
+type loopbackNotifier struct {
+	replicas []*PostgresBroker
+	err      error
+}
+
+func (n *loopbackNotifier) Notify(ctx context.Context, channel string, payload []byte) error {
+	if n.err != nil {
+		return n.err
+	}
+	if len(payload) >= notifyLimit {
+		return errors.New("payload string too long")
+	}
+	for _, r := range n.replicas {
+		r.Receive(payload)
+	}
+	return nil
+}
+
+// memoryStore is the shared stream journal all replicas load messages from
+type memoryStore struct {
+	mu       sync.Mutex
+	messages map[string]map[int64]Message
+}
+
+func (s *memoryStore) save(topic string, msg Message) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.messages == nil {
+		s.messages = make(map[string]map[int64]Message)
+	}
+	if s.messages[topic] == nil {
+		s.messages[topic] = make(map[int64]Message)
+	}
+	s.messages[topic][msg.ID] = msg
+	return msg
+}
+
+func (s *memoryStore) Load(ctx context.Context, topic string, id int64) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.messages[topic][id]
+	if !ok {
+		return Message{}, errors.New("not found")
+	}
+	return msg, nil
+}
+
+func newReplicas(t *testing.T, store Store) (*PostgresBroker, *PostgresBroker) {
+	t.Helper()
+	notifier := &loopbackNotifier{}
+	a := NewPostgresBroker(NewHub(8), notifier, store)
+	b := NewPostgresBroker(NewHub(8), notifier, store)
+	notifier.replicas = []*PostgresBroker{a, b}
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return a, b
+}
+
+func receive(t *testing.T, sub *Subscription) Message {
+	t.Helper()
+	select {
+	case msg := <-sub.Messages():
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not delivered")
+		return Message{}
+	}
+}
+
+func TestPostgresBroker_FansOutToOtherReplicas(t *testing.T) {
+	store := &memoryStore{}
+	a, b := newReplicas(t, store)
+
+	subA, _ := a.Subscribe("posts")
+	subB, _ := b.Subscribe("posts")
+
+	a.Publish("posts", store.save("posts", Message{ID: 7, Event: "post.created", Data: []byte(`{"id":7}`)}))
+
+	for name, sub := range map[string]*Subscription{"a": subA, "b": subB} {
+		if msg := receive(t, sub); msg.ID != 7 || msg.Event != "post.created" || string(msg.Data) != `{"id":7}` {
+			t.Errorf("replica %s got unexpected message %+v", name, msg)
+		}
+	}
+
+	// The sender's own notification must not be delivered twice
+	select {
+	case msg := <-subA.Messages():
+		t.Errorf("expected no duplicate on sending replica, got %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPostgresBroker_LoadsLargeMessagesFromStore(t *testing.T) {
+	store := &memoryStore{}
+	a, b := newReplicas(t, store)
+	sub, _ := b.Subscribe("posts")
+
+	data := `{"content":"` + strings.Repeat("x", 10000) + `"}`
+	a.Publish("posts", store.save("posts", Message{ID: 1, Event: "post.created", Data: []byte(data)}))
+
+	if msg := receive(t, sub); msg.ID != 1 || string(msg.Data) != data {
+		t.Errorf("expected the full %d-byte post on the other replica, got %d bytes", len(data), len(msg.Data))
+	}
+}
+
+func TestPostgresBroker_SendsUnnumberedMessagesInline(t *testing.T) {
+	a, b := newReplicas(t, &memoryStore{})
+	sub, _ := b.Subscribe("live/presence")
+
+	a.Publish("live/presence", Message{Event: "typing", Data: []byte(`{"post_id":1}`)})
+
+	if msg := receive(t, sub); msg.ID != 0 || string(msg.Data) != `{"post_id":1}` {
+		t.Errorf("unexpected presence message %+v", msg)
+	}
+}
+
+func TestPostgresBroker_DeliversLocallyWhenNotifyFails(t *testing.T) {
+	broker := NewPostgresBroker(NewHub(8), &loopbackNotifier{err: errors.New("connection refused")}, &memoryStore{})
+	defer broker.Close()
+	sub, _ := broker.Subscribe("posts")
+
+	broker.Publish("posts", Message{ID: 1, Event: "post.created", Data: []byte(`{}`)})
+
+	select {
+	case <-sub.Messages():
+	default:
+		t.Fatal("expected local delivery despite NOTIFY failure")
+	}
+}
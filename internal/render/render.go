@@ -0,0 +1,87 @@
+package render
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown преобразует Markdown (CommonMark + GFM) в HTML. Сырой HTML из текста не выводится:
+// goldmark по умолчанию заменяет его комментарием, а остальное отсекает санитайзер
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// policy - строгий белый список HTML. Разрешены только элементы, которые порождает Markdown;
+// ссылки - только http(s) и mailto с rel="nofollow noopener"; у code - лишь класс language-*
+// для подсветки синтаксиса на клиенте. Скрипты, стили, обработчики событий и iframe удаляются
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowElements("p", "br", "hr", "blockquote", "pre", "code", "em", "strong", "del",
+		"h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "li")
+	p.AllowLists()
+	p.AllowTables()
+
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.RequireNoReferrerOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	p.AllowImages()
+	p.AllowAttrs("title").OnElements("a", "img")
+
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	// Флажки списков задач GFM
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$`)).OnElements("input")
+
+	return p
+}
+
+// ValidFormat сообщает, поддерживается ли формат текста
+func ValidFormat(format string) bool {
+	return format == model.ContentFormatPlain || format == model.ContentFormatMarkdown
+}
+
+// HTML преобразует текст в безопасный HTML. Обычный текст экранируется и разбивается на абзацы
+// по пустым строкам, Markdown рендерится и проходит через санитайзер
+func HTML(format, content string) (string, error) {
+	switch format {
+	case model.ContentFormatPlain:
+		return plainHTML(content), nil
+	case model.ContentFormatMarkdown:
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(content), &buf); err != nil {
+			return "", fmt.Errorf("failed to render markdown: %w", err)
+		}
+		return policy.Sanitize(buf.String()), nil
+	default:
+		return "", fmt.Errorf("unsupported content format %q", format)
+	}
+}
+
+func plainHTML(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(content, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML_Markdown(t *testing.T) {
+	src := "# Title\n\nSome **bold** text and a [link](https://example.com).\n\n```go\nfmt.Println(\"<hi>\")\n```\n"
+
+	html, err := HTML("markdown", src)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, want := range []string{
+		"<h1>Title</h1>",
+		"<strong>bold</strong>",
+		`<a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">link</a>`,
+		`<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in output:\n%s", want, html)
+		}
+	}
+}
+
+func TestHTML_MarkdownStripsXSS(t *testing.T) {
+	vectors := []string{
+		"<script>alert(1)</script>",
+		"<img src=x onerror=alert(1)>",
+		"[click](javascript:alert(1))",
+		"![img](javascript:alert(1))",
+		`<a href="https://example.com" onclick="alert(1)">x</a>`,
+		"<iframe src=\"https://evil.example\"></iframe>",
+		"```\" onmouseover=\"alert(1)\n```",
+		"<style>body{display:none}</style>",
+	}
+
+	for _, src := range vectors {
+		html, err := HTML("markdown", src)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		lower := strings.ToLower(html)
+		for _, bad := range []string{"<script", "onerror", "onclick", "onmouseover=", "javascript:", "<iframe", "<style"} {
+			if strings.Contains(lower, bad) {
+				t.Errorf("input %q produced unsafe output %q", src, html)
+			}
+		}
+	}
+}
+
+func TestHTML_Plain(t *testing.T) {
+	html, err := HTML("plain", "Hello <b>world</b>\nsecond line\n\nNext paragraph")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := "<p>Hello &lt;b&gt;world&lt;/b&gt;<br>\nsecond line</p>\n<p>Next paragraph</p>\n"
+	if html != want {
+		t.Errorf("expected %q, got %q", want, html)
+	}
+
+	if _, err := HTML("rtf", "text"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ActivityRepo читает недавние публикации автора - посты и комментарии вместе, включая удаленные.
+// Нужен фильтру спама: удаление и повторная публикация не должны сбрасывать историю автора
+type ActivityRepo struct {
+	db dbtx
+}
+
+func NewActivityRepo(db *sql.DB) *ActivityRepo {
+	return &ActivityRepo{db: newTracedDB(db)}
+}
+
+// RecentContent возвращает тексты постов и комментариев автора, созданных после since, от новых к старым
+func (r *ActivityRepo) RecentContent(ctx context.Context, authorID int, since time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT content FROM (
+			SELECT content, created_at FROM posts WHERE author_id = $1 AND created_at >= $2
+			UNION ALL
+			SELECT content, created_at FROM comments WHERE author_id = $1 AND created_at >= $2
+		) recent
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, authorID, since, limit)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get recent content", err)
+	}
+	defer rows.Close()
+
+	var contents []string
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return nil, wrapError(ctx, "failed to scan recent content", err)
+		}
+		contents = append(contents, content)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate recent content", err)
+	}
+
+	return contents, nil
+}
+
+// CountSince возвращает число постов и комментариев автора, созданных после since
+func (r *ActivityRepo) CountSince(ctx context.Context, authorID int, since time.Time) (int, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM posts WHERE author_id = $1 AND created_at >= $2) +
+			(SELECT COUNT(*) FROM comments WHERE author_id = $1 AND created_at >= $2)
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, authorID, since).Scan(&count); err != nil {
+		return 0, wrapError(ctx, "failed to count recent activity", err)
+	}
+	return count, nil
+}
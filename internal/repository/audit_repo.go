@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// AuditRepo читает журнал аудита из таблицы audit_events.
+// Записи добавляет только PostgresSink журнала событий, таблица защищена от изменений триггером
+type AuditRepo struct {
+	db dbtx
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: newTracedDB(db)}
+}
+
+// List возвращает записи по фильтру от новых к старым, начиная после курсора filter.AfterID
+func (r *AuditRepo) List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = "+arg(filter.ActorID))
+	}
+	if filter.Action != "" {
+		if prefix, ok := strings.CutSuffix(filter.Action, "*"); ok {
+			conditions = append(conditions, "starts_with(event_type, "+arg(prefix)+")")
+		} else {
+			conditions = append(conditions, "event_type = "+arg(filter.Action))
+		}
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = "+arg(filter.TargetType))
+	}
+	if filter.TargetID != 0 {
+		conditions = append(conditions, "target_id = "+arg(filter.TargetID))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "occurred_at >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "occurred_at < "+arg(filter.To))
+	}
+	if filter.AfterID != 0 {
+		conditions = append(conditions, "id < "+arg(filter.AfterID))
+	}
+
+	query := `
+		SELECT id, event_id, event_type, actor_id, target_type, target_id, request_id, payload, occurred_at
+		FROM audit_events`
+	if len(conditions) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(conditions, " AND ")
+	}
+	query += "\n\t\tORDER BY id DESC\n\t\tLIMIT " + arg(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get audit events", err)
+	}
+	defer rows.Close()
+
+	var entries []*model.AuditEntry
+	for rows.Next() {
+		var entry model.AuditEntry
+		var actorID sql.NullInt64
+		var requestID sql.NullString
+		var payload []byte
+
+		err := rows.Scan(
+			&entry.ID, &entry.EventID, &entry.Action, &actorID,
+			&entry.Target.Type, &entry.Target.ID, &requestID, &payload, &entry.OccurredAt,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan audit event", err)
+		}
+
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			entry.ActorID = &id
+		}
+		entry.RequestID = requestID.String
+		entry.Payload = payload
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate audit events", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/cache"
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// load читает значение через загрузчик кеша. Внутри транзакции кеш не используется:
+// транзакция может видеть еще не зафиксированные данные, которые нельзя показывать другим.
+// clone копирует значение, чтобы вызывающий код не мог изменить закешированный объект
+func load[T any](ctx context.Context, l *cache.Loader, key string, fetch func(ctx context.Context) (T, error), clone func(T) T) (T, error) {
+	if inTx(ctx) {
+		return fetch(ctx)
+	}
+
+	v, err := l.Load(ctx, key, func(ctx context.Context) (any, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return clone(v.(T)), nil
+}
+
+// invalidate сбрасывает ключи после фиксации текущей транзакции
+func invalidate(ctx context.Context, l *cache.Loader, keys []string, prefixes []string) {
+	AfterCommit(ctx, func() { l.Invalidate(keys, prefixes) })
+}
+
+// pageKey - часть ключа кеша, однозначно задающая страницу ленты
+func pageKey(page model.PageRequest) string {
+	if page.Position == nil || page.Offset > 0 {
+		return fmt.Sprintf("page:offset:%d:%d", page.Offset, page.Limit)
+	}
+	p := page.Position
+	return fmt.Sprintf("page:%d.%d.%q:%t:%d", p.CreatedAt.UnixNano(), p.ID, p.Title, page.Backward, page.Limit)
+}
+
+func same[T any](v T) T { return v }
+
+func clonePost(p *model.Post) *model.Post {
+	c := *p
+	return &c
+}
+
+func clonePosts(posts []*model.Post) []*model.Post {
+	out := make([]*model.Post, len(posts))
+	for i, p := range posts {
+		out[i] = clonePost(p)
+	}
+	return out
+}
+
+func cloneComment(c *model.Comment) *model.Comment {
+	cc := *c
+	return &cc
+}
+
+func cloneComments(comments []*model.Comment) []*model.Comment {
+	out := make([]*model.Comment, len(comments))
+	for i, c := range comments {
+		out[i] = cloneComment(c)
+	}
+	return out
+}
+
+// CachedPostRepo кеширует чтения постов. Ленты и счетчики сбрасываются при создании поста,
+// правка сбрасывает сам пост и ленты, удаление, восстановление и смена статуса - еще и счетчики.
+// Версии постов, корзина и посты, ожидающие модерации, не кешируются
+type CachedPostRepo struct {
+	PostRepository
+	loader *cache.Loader
+}
+
+func NewCachedPostRepo(repo PostRepository, loader *cache.Loader) *CachedPostRepo {
+	return &CachedPostRepo{PostRepository: repo, loader: loader}
+}
+
+func (r *CachedPostRepo) Create(ctx context.Context, post *model.Post) error {
+	if err := r.PostRepository.Create(ctx, post); err != nil {
+		return err
+	}
+	invalidate(ctx, r.loader,
+		[]string{fmt.Sprintf("author_count:%d", post.AuthorID)},
+		[]string{"all:", "count", fmt.Sprintf("author:%d:", post.AuthorID)})
+	return nil
+}
+
+// Update сбрасывает пост, ленты и счетчики: в лентах виден заголовок и выдержка из текста,
+// а правка, отправленная на модерацию, снимает пост с публикации
+func (r *CachedPostRepo) Update(ctx context.Context, post *model.Post) error {
+	if err := r.PostRepository.Update(ctx, post); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, post)
+	return nil
+}
+
+func (r *CachedPostRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
+	// Автор нужен, чтобы сбросить его ленты; читаем в той же транзакции, минуя кеш
+	post, err := r.PostRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.PostRepository.Delete(ctx, id, deletedAt); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, post)
+	return nil
+}
+
+func (r *CachedPostRepo) Restore(ctx context.Context, id int) error {
+	post, err := r.PostRepository.GetDeletedByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.PostRepository.Restore(ctx, id); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, post)
+	return nil
+}
+
+// DeleteByAuthor затрагивает неизвестный заранее набор постов, поэтому сбрасывает весь кеш постов
+func (r *CachedPostRepo) DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if err := r.PostRepository.DeleteByAuthor(ctx, authorID, deletedAt); err != nil {
+		return err
+	}
+	invalidate(ctx, r.loader, nil, []string{""})
+	return nil
+}
+
+func (r *CachedPostRepo) RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if err := r.PostRepository.RestoreByAuthor(ctx, authorID, deletedAt); err != nil {
+		return err
+	}
+	invalidate(ctx, r.loader, nil, []string{""})
+	return nil
+}
+
+// SetModerateComments меняет только флаг поста, видный в самом посте и в лентах
+func (r *CachedPostRepo) SetModerateComments(ctx context.Context, id int, enabled bool) error {
+	post, err := r.PostRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.PostRepository.SetModerateComments(ctx, id, enabled); err != nil {
+		return err
+	}
+	invalidate(ctx, r.loader,
+		[]string{fmt.Sprintf("id:%d", id)},
+		[]string{"all:", fmt.Sprintf("author:%d:", post.AuthorID)})
+	return nil
+}
+
+// SetStatus публикует пост или снимает его с публикации, что меняет ленты и счетчики
+func (r *CachedPostRepo) SetStatus(ctx context.Context, id int, status string) error {
+	post, err := r.PostRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.PostRepository.SetStatus(ctx, id, status); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, post)
+	return nil
+}
+
+// invalidatePost сбрасывает пост, все ленты и счетчики: удаление и восстановление меняют и их
+func (r *CachedPostRepo) invalidatePost(ctx context.Context, post *model.Post) {
+	invalidate(ctx, r.loader,
+		[]string{fmt.Sprintf("id:%d", post.ID), fmt.Sprintf("author_count:%d", post.AuthorID)},
+		[]string{"all:", "count", fmt.Sprintf("author:%d:", post.AuthorID)})
+}
+
+// GetByID не кеширует посты, ожидающие модерации: загрузчик не сохраняет результат с ошибкой,
+// поэтому такой пост возвращается из загрузки как uncachedPost
+func (r *CachedPostRepo) GetByID(ctx context.Context, id int) (*model.Post, error) {
+	post, err := load(ctx, r.loader, fmt.Sprintf("id:%d", id), func(ctx context.Context) (*model.Post, error) {
+		post, err := r.PostRepository.GetByID(ctx, id)
+		if err == nil && post.Status == model.PostPending {
+			return nil, &uncachedPost{post: post}
+		}
+		return post, err
+	}, clonePost)
+	var uncached *uncachedPost
+	if errors.As(err, &uncached) {
+		return clonePost(uncached.post), nil
+	}
+	return post, err
+}
+
+// uncachedPost передает пост из загрузки мимо кеша
+type uncachedPost struct {
+	post *model.Post
+}
+
+func (e *uncachedPost) Error() string { return "post is not cacheable" }
+
+func (r *CachedPostRepo) GetAll(ctx context.Context, limit, offset int) ([]*model.Post, error) {
+	return load(ctx, r.loader, fmt.Sprintf("all:%d:%d", limit, offset), func(ctx context.Context) ([]*model.Post, error) {
+		return r.PostRepository.GetAll(ctx, limit, offset)
+	}, clonePosts)
+}
+
+func (r *CachedPostRepo) GetTotalCount(ctx context.Context) (int, error) {
+	return load(ctx, r.loader, "count", r.PostRepository.GetTotalCount, same[int])
+}
+
+// Exists использует закешированный пост. Отсутствие поста не кешируется,
+// иначе пост, созданный позже, считался бы отсутствующим до истечения TTL
+func (r *CachedPostRepo) Exists(ctx context.Context, id int) (bool, error) {
+	if inTx(ctx) {
+		return r.PostRepository.Exists(ctx, id)
+	}
+	_, err := r.GetByID(ctx, id)
+	if errors.Is(err, apperrors.ErrPostNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *CachedPostRepo) GetByAuthorID(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error) {
+	return load(ctx, r.loader, fmt.Sprintf("author:%d:%d:%d", authorID, limit, offset), func(ctx context.Context) ([]*model.Post, error) {
+		return r.PostRepository.GetByAuthorID(ctx, authorID, limit, offset)
+	}, clonePosts)
+}
+
+// GetPage кеширует страницы в пространстве ключей ленты, чтобы создание поста сбрасывало и их.
+// Выборки по наличию комментариев не кешируются: они меняются при записи комментариев
+func (r *CachedPostRepo) GetPage(ctx context.Context, filter model.PostFilter, page model.PageRequest) ([]*model.Post, error) {
+	if filter.HasComments != nil {
+		return r.PostRepository.GetPage(ctx, filter, page)
+	}
+	return load(ctx, r.loader, "all:"+filter.Key()+":"+pageKey(page), func(ctx context.Context) ([]*model.Post, error) {
+		return r.PostRepository.GetPage(ctx, filter, page)
+	}, clonePosts)
+}
+
+func (r *CachedPostRepo) GetCount(ctx context.Context, filter model.PostFilter) (int, error) {
+	if filter.HasComments != nil {
+		return r.PostRepository.GetCount(ctx, filter)
+	}
+	return load(ctx, r.loader, "count:"+filter.Key(), func(ctx context.Context) (int, error) {
+		return r.PostRepository.GetCount(ctx, filter)
+	}, same[int])
+}
+
+func (r *CachedPostRepo) GetTotalCountByAuthorID(ctx context.Context, authorID int) (int, error) {
+	return load(ctx, r.loader, fmt.Sprintf("author_count:%d", authorID), func(ctx context.Context) (int, error) {
+		return r.PostRepository.GetTotalCountByAuthorID(ctx, authorID)
+	}, same[int])
+}
+
+// CachedCommentRepo кеширует комментарии и их списки по постам; любая запись сбрасывает
+// все списки поста
+type CachedCommentRepo struct {
+	CommentRepository
+	loader *cache.Loader
+}
+
+func NewCachedCommentRepo(repo CommentRepository, loader *cache.Loader) *CachedCommentRepo {
+	return &CachedCommentRepo{CommentRepository: repo, loader: loader}
+}
+
+func (r *CachedCommentRepo) Create(ctx context.Context, comment *model.Comment) error {
+	if err := r.CommentRepository.Create(ctx, comment); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, comment.PostID, comment.ID)
+	return nil
+}
+
+func (r *CachedCommentRepo) Update(ctx context.Context, comment *model.Comment) error {
+	if err := r.CommentRepository.Update(ctx, comment); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, comment.PostID, comment.ID)
+	return nil
+}
+
+func (r *CachedCommentRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
+	// Пост нужен, чтобы сбросить его списки; читаем в той же транзакции, минуя кеш
+	comment, err := r.CommentRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.CommentRepository.Delete(ctx, id, deletedAt); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, comment.PostID, id)
+	return nil
+}
+
+func (r *CachedCommentRepo) Restore(ctx context.Context, id int) error {
+	comment, err := r.CommentRepository.GetDeletedByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.CommentRepository.Restore(ctx, id); err != nil {
+		return err
+	}
+	r.invalidatePost(ctx, comment.PostID, id)
+	return nil
+}
+
+// DeleteByAuthor затрагивает комментарии к разным постам, поэтому сбрасывает весь кеш комментариев
+func (r *CachedCommentRepo) DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if err := r.CommentRepository.DeleteByAuthor(ctx, authorID, deletedAt); err != nil {
+		return err
+	}
+	invalidate(ctx, r.loader, nil, []string{""})
+	return nil
+}
+
+func (r *CachedCommentRepo) RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if err := r.CommentRepository.RestoreByAuthor(ctx, authorID, deletedAt); err != nil {
+		return err
+	}
+	invalidate(ctx, r.loader, nil, []string{""})
+	return nil
+}
+
+// SetStatus сбрасывает измененные комментарии и списки их постов: одобрение добавляет
+// комментарий в список, отклонение убирает
+func (r *CachedCommentRepo) SetStatus(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error) {
+	comments, err := r.CommentRepository.SetStatus(ctx, ids, status, reason)
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		r.invalidatePost(ctx, comment.PostID, comment.ID)
+	}
+	return comments, nil
+}
+
+func (r *CachedCommentRepo) GetByID(ctx context.Context, id int) (*model.Comment, error) {
+	return load(ctx, r.loader, fmt.Sprintf("id:%d", id), func(ctx context.Context) (*model.Comment, error) {
+		return r.CommentRepository.GetByID(ctx, id)
+	}, cloneComment)
+}
+
+func (r *CachedCommentRepo) GetByPostID(ctx context.Context, postID int, limit, offset int) ([]*model.Comment, error) {
+	return load(ctx, r.loader, fmt.Sprintf("post:%d:list:%d:%d", postID, limit, offset), func(ctx context.Context) ([]*model.Comment, error) {
+		return r.CommentRepository.GetByPostID(ctx, postID, limit, offset)
+	}, cloneComments)
+}
+
+func (r *CachedCommentRepo) GetCountByPostID(ctx context.Context, postID int) (int, error) {
+	return load(ctx, r.loader, fmt.Sprintf("post:%d:count", postID), func(ctx context.Context) (int, error) {
+		return r.CommentRepository.GetCountByPostID(ctx, postID)
+	}, same[int])
+}
+
+func (r *CachedCommentRepo) GetPageByPostID(ctx context.Context, postID int, page model.PageRequest) ([]*model.Comment, error) {
+	return load(ctx, r.loader, fmt.Sprintf("post:%d:", postID)+pageKey(page), func(ctx context.Context) ([]*model.Comment, error) {
+		return r.CommentRepository.GetPageByPostID(ctx, postID, page)
+	}, cloneComments)
+}
+
+func (r *CachedCommentRepo) invalidatePost(ctx context.Context, postID, commentID int) {
+	invalidate(ctx, r.loader, []string{fmt.Sprintf("id:%d", commentID)}, []string{fmt.Sprintf("post:%d:", postID)})
+}
@@ -6,35 +6,41 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type CommentRepo struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewCommentRepo(db *sql.DB) *CommentRepo {
-	return &CommentRepo{db: db}
+	return &CommentRepo{db: newTracedDB(db)}
 }
 
 func (r *CommentRepo) Create(ctx context.Context, comment *model.Comment) error {
 	query := `
-		INSERT INTO comments (post_id, author_id, content, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id
+		INSERT INTO comments (post_id, author_id, content, content_format, content_html, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, version
 	`
 
 	now := time.Now()
 	comment.CreatedAt = now
 	comment.UpdatedAt = now
+	if comment.Status == "" {
+		comment.Status = model.CommentApproved
+	}
 
 	err := r.db.QueryRowContext(ctx, query,
-		comment.PostID, comment.AuthorID, comment.Content,
-		comment.CreatedAt, comment.UpdatedAt,
-	).Scan(&comment.ID)
+		comment.PostID, comment.AuthorID, comment.Content, comment.ContentFormat, comment.ContentHTML,
+		comment.Status, comment.CreatedAt, comment.UpdatedAt,
+	).Scan(&comment.ID, &comment.Version)
 
 	if err != nil {
-		return fmt.Errorf("failed to create comment: %w", err)
+		return wrapError(ctx, "failed to create comment", err)
 	}
 
 	return nil
@@ -42,9 +48,9 @@ func (r *CommentRepo) Create(ctx context.Context, comment *model.Comment) error
 
 func (r *CommentRepo) GetByID(ctx context.Context, id int) (*model.Comment, error) {
 	query := `
-		SELECT id, post_id, author_id, content, created_at, updated_at
+		SELECT id, post_id, author_id, content, content_format, content_html, created_at, updated_at, version, status, moderation_reason
 		FROM comments
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var comment model.Comment
@@ -53,15 +59,177 @@ func (r *CommentRepo) GetByID(ctx context.Context, id int) (*model.Comment, erro
 		&comment.PostID,
 		&comment.AuthorID,
 		&comment.Content,
+		&comment.ContentFormat,
+		&comment.ContentHTML,
 		&comment.CreatedAt,
 		&comment.UpdatedAt,
+		&comment.Version,
+		&comment.Status,
+		&comment.ModerationReason,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, apperrors.ErrCommentNotFound
 		}
-		return nil, fmt.Errorf("failed to get comment: %w", err)
+		return nil, wrapError(ctx, "failed to get comment", err)
+	}
+
+	return &comment, nil
+}
+
+// Update сохраняет новый текст комментария, его HTML и статус модерации, если версия комментария в БД равна
+// comment.Version, и увеличивает версию. Если комментарий успел измениться, возвращает ErrConflict
+func (r *CommentRepo) Update(ctx context.Context, comment *model.Comment) error {
+	query := `
+		UPDATE comments
+		SET content = $1, content_format = $2, content_html = $3, status = $4, moderation_reason = $5, updated_at = $6, version = version + 1
+		WHERE id = $7 AND version = $8 AND deleted_at IS NULL
+		RETURNING version
+	`
+
+	updatedAt := time.Now()
+
+	err := r.db.QueryRowContext(ctx, query,
+		comment.Content, comment.ContentFormat, comment.ContentHTML, comment.Status, comment.ModerationReason, updatedAt, comment.ID, comment.Version,
+	).Scan(&comment.Version)
+	if err == sql.ErrNoRows {
+		var exists bool
+		query = `SELECT EXISTS(SELECT 1 FROM comments WHERE id = $1 AND deleted_at IS NULL)`
+		if err := r.db.QueryRowContext(ctx, query, comment.ID).Scan(&exists); err != nil {
+			return wrapError(ctx, "failed to check comment existence", err)
+		}
+		if exists {
+			return apperrors.ErrConflict
+		}
+		return apperrors.ErrCommentNotFound
+	}
+	if err != nil {
+		return wrapError(ctx, "failed to update comment", err)
+	}
+
+	comment.UpdatedAt = updatedAt
+	return nil
+}
+
+// Delete помечает комментарий удаленным временем deletedAt
+func (r *CommentRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
+	query := `UPDATE comments SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, deletedAt, id)
+	if err != nil {
+		return wrapError(ctx, "failed to delete comment", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrCommentNotFound)
+}
+
+// Restore снимает с комментария пометку удаления
+func (r *CommentRepo) Restore(ctx context.Context, id int) error {
+	query := `UPDATE comments SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return wrapError(ctx, "failed to restore comment", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrCommentNotFound)
+}
+
+// DeleteByAuthor помечает удаленными все комментарии автора
+func (r *CommentRepo) DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	query := `UPDATE comments SET deleted_at = $1 WHERE author_id = $2 AND deleted_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, deletedAt, authorID); err != nil {
+		return wrapError(ctx, "failed to delete author comments", err)
+	}
+	return nil
+}
+
+// RestoreByAuthor восстанавливает комментарии автора, помеченные временем deletedAt
+func (r *CommentRepo) RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	query := `UPDATE comments SET deleted_at = NULL WHERE author_id = $1 AND deleted_at = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, authorID, deletedAt); err != nil {
+		return wrapError(ctx, "failed to restore author comments", err)
+	}
+	return nil
+}
+
+// GetDeleted возвращает удаленные комментарии автора, начиная с удаленных последними
+func (r *CommentRepo) GetDeleted(ctx context.Context, authorID int, limit, offset int) ([]*model.Comment, error) {
+	query := `
+		SELECT id, content, content_format, content_html, post_id, author_id, created_at, updated_at, version, status, moderation_reason, deleted_at
+		FROM comments
+		WHERE author_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, authorID, limit, offset)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get deleted comments", err)
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		var comment model.Comment
+		err := rows.Scan(
+			&comment.ID,
+			&comment.Content,
+			&comment.ContentFormat,
+			&comment.ContentHTML,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+			&comment.Version,
+			&comment.Status,
+			&comment.ModerationReason,
+			&comment.DeletedAt,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan comment", err)
+		}
+		comments = append(comments, &comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate comments", err)
+	}
+
+	return comments, nil
+}
+
+// GetDeletedByID возвращает комментарий из корзины
+func (r *CommentRepo) GetDeletedByID(ctx context.Context, id int) (*model.Comment, error) {
+	query := `
+		SELECT id, post_id, author_id, content, content_format, content_html, created_at, updated_at, version, status, moderation_reason, deleted_at
+		FROM comments
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	var comment model.Comment
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.AuthorID,
+		&comment.Content,
+		&comment.ContentFormat,
+		&comment.ContentHTML,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&comment.Version,
+		&comment.Status,
+		&comment.ModerationReason,
+		&comment.DeletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrCommentNotFound
+		}
+		return nil, wrapError(ctx, "failed to get deleted comment", err)
 	}
 
 	return &comment, nil
@@ -69,16 +237,16 @@ func (r *CommentRepo) GetByID(ctx context.Context, id int) (*model.Comment, erro
 
 func (r *CommentRepo) GetByPostID(ctx context.Context, postID int, limit, offset int) ([]*model.Comment, error) {
 	query := `
-		SELECT id, content, post_id, author_id, created_at, updated_at
+		SELECT id, content, content_format, content_html, post_id, author_id, created_at, updated_at, version, status, moderation_reason
 		FROM comments
-		WHERE post_id = $1
+		WHERE post_id = $1 AND deleted_at IS NULL AND status = 'approved'
 		ORDER BY created_at ASC
 		LIMIT $2 OFFSET $3
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, postID, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get comments by post: %w", err)
+		return nil, wrapError(ctx, "failed to get comments by post", err)
 	}
 	defer rows.Close()
 
@@ -88,19 +256,24 @@ func (r *CommentRepo) GetByPostID(ctx context.Context, postID int, limit, offset
 		err := rows.Scan(
 			&comment.ID,
 			&comment.Content,
+			&comment.ContentFormat,
+			&comment.ContentHTML,
 			&comment.PostID,
 			&comment.AuthorID,
 			&comment.CreatedAt,
 			&comment.UpdatedAt,
+			&comment.Version,
+			&comment.Status,
+			&comment.ModerationReason,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan comment: %w", err)
+			return nil, wrapError(ctx, "failed to scan comment", err)
 		}
 		comments = append(comments, &comment)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate comments: %w", err)
+		return nil, wrapError(ctx, "failed to iterate comments", err)
 	}
 
 	return comments, nil
@@ -108,10 +281,145 @@ func (r *CommentRepo) GetByPostID(ctx context.Context, postID int, limit, offset
 
 func (r *CommentRepo) GetCountByPostID(ctx context.Context, postID int) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM comments WHERE post_id = $1`
+	query := `SELECT COUNT(*) FROM comments WHERE post_id = $1 AND deleted_at IS NULL AND status = 'approved'`
 	err := r.db.QueryRowContext(ctx, query, postID).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count comments: %w", err)
+		return 0, wrapError(ctx, "failed to count comments", err)
+	}
+	return count, nil
+}
+
+// GetPageByPostID возвращает страницу комментариев поста от старых к новым по позиции page.Position
+func (r *CommentRepo) GetPageByPostID(ctx context.Context, postID int, page model.PageRequest) ([]*model.Comment, error) {
+	args := sqlArgs{postID}
+
+	query := `
+		SELECT id, content, content_format, content_html, post_id, author_id, created_at, updated_at, version, status, moderation_reason
+		FROM comments
+		WHERE post_id = $1 AND deleted_at IS NULL AND status = 'approved'`
+	keyset, orderBy := keysetQuery(page, []keyColumn{createdAtKey, idKey}, args.add)
+	if keyset != "" {
+		query += " AND " + keyset
+	}
+	query += "\n\t\tORDER BY " + orderBy + "\n\t\tLIMIT " + args.add(page.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get comments page", err)
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		var comment model.Comment
+		err := rows.Scan(
+			&comment.ID,
+			&comment.Content,
+			&comment.ContentFormat,
+			&comment.ContentHTML,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+			&comment.Version,
+			&comment.Status,
+			&comment.ModerationReason,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan comment", err)
+		}
+		comments = append(comments, &comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate comments", err)
+	}
+
+	return keysetOrder(page, comments), nil
+}
+
+// SetStatus выносит решение модератора по комментариям ids и возвращает измененные комментарии.
+// Комментарии, уже имеющие статус status, и удаленные комментарии не затрагиваются
+func (r *CommentRepo) SetStatus(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error) {
+	query := `
+		UPDATE comments
+		SET status = $1, moderation_reason = $2
+		WHERE id = ANY($3) AND status <> $1 AND deleted_at IS NULL
+		RETURNING id, post_id, author_id, content, content_format, content_html, created_at, updated_at, version, status, moderation_reason
+	`
+
+	return r.query(ctx, "failed to set comment status", query, status, reason, pq.Array(ids))
+}
+
+// CountByAuthorStatus возвращает число неудаленных комментариев автора с указанным статусом
+func (r *CommentRepo) CountByAuthorStatus(ctx context.Context, authorID int, status string) (int, error) {
+	query := `SELECT COUNT(*) FROM comments WHERE author_id = $1 AND status = $2 AND deleted_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, authorID, status).Scan(&count); err != nil {
+		return 0, wrapError(ctx, "failed to count author comments", err)
 	}
 	return count, nil
 }
+
+// GetModeration возвращает комментарии очереди модерации по фильтру, начиная со старых
+func (r *CommentRepo) GetModeration(ctx context.Context, filter model.ModerationFilter) ([]*model.Comment, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"deleted_at IS NULL", "status = " + arg(filter.Status)}
+	if filter.PostID != 0 {
+		conditions = append(conditions, "post_id = "+arg(filter.PostID))
+	}
+	if filter.AuthorID != 0 {
+		conditions = append(conditions, "author_id = "+arg(filter.AuthorID))
+	}
+
+	query := `
+		SELECT id, post_id, author_id, content, content_format, content_html, created_at, updated_at, version, status, moderation_reason
+		FROM comments
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at, id
+		LIMIT ` + arg(filter.Limit) + ` OFFSET ` + arg(filter.Offset)
+
+	return r.query(ctx, "failed to get moderation queue", query, args...)
+}
+
+func (r *CommentRepo) query(ctx context.Context, message, query string, args ...any) ([]*model.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(ctx, message, err)
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		var comment model.Comment
+		err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.Content,
+			&comment.ContentFormat,
+			&comment.ContentHTML,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+			&comment.Version,
+			&comment.Status,
+			&comment.ModerationReason,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan comment", err)
+		}
+		comments = append(comments, &comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate comments", err)
+	}
+
+	return comments, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// dbtx - набор методов *sql.DB, которые используют репозитории
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// tracedDB открывает спан вокруг каждого запроса к БД.
+// Если в контексте есть транзакция из TxManager, запрос выполняется в ней
+type tracedDB struct {
+	db dbtx
+}
+
+func newTracedDB(db dbtx) *tracedDB {
+	return &tracedDB{db: db}
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := tracing.StartDB(ctx, operationName(query), query)
+	result, err := conn(ctx, t.db).ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := tracing.StartDB(ctx, operationName(query), query)
+	rows, err := conn(ctx, t.db).QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := tracing.StartDB(ctx, operationName(query), query)
+	row := conn(ctx, t.db).QueryRowContext(ctx, query, args...)
+
+	// sql.ErrNoRows - штатный результат поиска, а не ошибка запроса
+	err := row.Err()
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	tracing.End(span, err)
+	return row
+}
+
+// operationName возвращает имя спана вида "SELECT posts" по тексту запроса
+func operationName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "db.query"
+	}
+
+	op := strings.ToUpper(fields[0])
+	for i, f := range fields {
+		switch strings.ToUpper(f) {
+		case "FROM", "INTO", "UPDATE":
+			if i+1 < len(fields) {
+				return op + " " + strings.Trim(fields[i+1], "(),")
+			}
+		}
+	}
+	return op
+}
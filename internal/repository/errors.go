@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"context"
+	"fmt"
+)
+
+// wrapError оборачивает ошибку БД, добавляя идентификатор запроса,
+// чтобы ошибку в логах можно было связать с конкретным HTTP-запросом
+func wrapError(ctx context.Context, message string, err error) error {
+	if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+		return fmt.Errorf("%s (request_id=%s): %w", message, requestID, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"advanced-blog-management-system/internal/model"
 	"context"
+	"time"
 )
 
 type UserRepository interface {
@@ -20,7 +21,13 @@ type UserRepository interface {
 
 	Update(ctx context.Context, user *model.User) error
 
-	Delete(ctx context.Context, id int) error
+	UpdateRole(ctx context.Context, id int, role string) error
+
+	Delete(ctx context.Context, id int, deletedAt time.Time) error
+
+	Restore(ctx context.Context, id int) (time.Time, error)
+
+	GetDeleted(ctx context.Context, limit, offset int) ([]*model.User, error)
 }
 
 type PostRepository interface {
@@ -28,6 +35,8 @@ type PostRepository interface {
 
 	GetByID(ctx context.Context, id int) (*model.Post, error)
 
+	Update(ctx context.Context, post *model.Post) error
+
 	GetAll(ctx context.Context, limit, offset int) ([]*model.Post, error)
 
 	GetTotalCount(ctx context.Context) (int, error)
@@ -37,6 +46,34 @@ type PostRepository interface {
 	GetByAuthorID(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error)
 
 	GetTotalCountByAuthorID(ctx context.Context, authorID int) (int, error)
+
+	GetPage(ctx context.Context, filter model.PostFilter, page model.PageRequest) ([]*model.Post, error)
+
+	GetCount(ctx context.Context, filter model.PostFilter) (int, error)
+
+	CreateRevision(ctx context.Context, rev *model.PostRevision) error
+
+	GetRevisions(ctx context.Context, postID int, limit, offset int) ([]*model.PostRevision, error)
+
+	GetRevision(ctx context.Context, postID, revision int) (*model.PostRevision, error)
+
+	Delete(ctx context.Context, id int, deletedAt time.Time) error
+
+	Restore(ctx context.Context, id int) error
+
+	DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error
+
+	RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error
+
+	GetDeleted(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error)
+
+	GetDeletedByID(ctx context.Context, id int) (*model.Post, error)
+
+	SetModerateComments(ctx context.Context, id int, enabled bool) error
+
+	GetPending(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error)
+
+	SetStatus(ctx context.Context, id int, status string) error
 }
 
 type CommentRepository interface {
@@ -44,7 +81,97 @@ type CommentRepository interface {
 
 	GetByID(ctx context.Context, id int) (*model.Comment, error)
 
+	Update(ctx context.Context, comment *model.Comment) error
+
+	Delete(ctx context.Context, id int, deletedAt time.Time) error
+
+	Restore(ctx context.Context, id int) error
+
+	DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error
+
+	RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error
+
+	GetDeleted(ctx context.Context, authorID int, limit, offset int) ([]*model.Comment, error)
+
+	GetDeletedByID(ctx context.Context, id int) (*model.Comment, error)
+
 	GetByPostID(ctx context.Context, postID int, limit, offset int) ([]*model.Comment, error)
 
 	GetCountByPostID(ctx context.Context, postID int) (int, error)
+
+	GetPageByPostID(ctx context.Context, postID int, page model.PageRequest) ([]*model.Comment, error)
+
+	SetStatus(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error)
+
+	CountByAuthorStatus(ctx context.Context, authorID int, status string) (int, error)
+
+	GetModeration(ctx context.Context, filter model.ModerationFilter) ([]*model.Comment, error)
+}
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n *model.Notification) error
+
+	List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*model.Notification, error)
+
+	MarkRead(ctx context.Context, userID int, id int64) error
+}
+
+type ActivityRepository interface {
+	RecentContent(ctx context.Context, authorID int, since time.Time, limit int) ([]string, error)
+
+	CountSince(ctx context.Context, authorID int, since time.Time) (int, error)
+}
+
+type TrashRepository interface {
+	Purge(ctx context.Context, before time.Time, limit int) (map[string]int, error)
+}
+
+type AuditRepository interface {
+	List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error)
+}
+
+type OutboxRepository interface {
+	Publish(ctx context.Context, e model.Event) error
+
+	Claim(ctx context.Context, limit int, lockedUntil time.Time) ([]*model.OutboxMessage, error)
+
+	MarkSinkDelivered(ctx context.Context, sink string, ids []int64) error
+
+	MarkDelivered(ctx context.Context, ids []int64) error
+
+	MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error
+
+	ListByStatus(ctx context.Context, status string, limit, offset int) ([]*model.OutboxMessage, error)
+
+	Requeue(ctx context.Context, id int64) error
+
+	CountByStatus(ctx context.Context) (map[string]int, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+
+	GetByID(ctx context.Context, id int) (*model.Webhook, error)
+
+	List(ctx context.Context) ([]*model.Webhook, error)
+
+	ListActive(ctx context.Context) ([]*model.Webhook, error)
+
+	Update(ctx context.Context, webhook *model.Webhook) error
+
+	Delete(ctx context.Context, id int) error
+
+	DeleteByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error
+
+	RestoreByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error
+
+	CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+
+	ClaimDeliveries(ctx context.Context, limit int, lockedUntil time.Time) ([]*model.WebhookDelivery, error)
+
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+
+	ListDeliveries(ctx context.Context, webhookID int, limit, offset int) ([]*model.WebhookDelivery, error)
+
+	Redeliver(ctx context.Context, webhookID int, deliveryID int64) error
 }
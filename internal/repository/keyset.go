@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sqlArgs накапливает параметры запроса и выдает их плейсхолдеры
+type sqlArgs []any
+
+func (a *sqlArgs) add(v any) string {
+	*a = append(*a, v)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// keyColumn - колонка сортировки ленты и ее значение в позиции страницы
+type keyColumn struct {
+	name  string
+	desc  bool
+	value func(model.Keyset) any
+}
+
+var (
+	createdAtKey = keyColumn{name: "created_at", value: func(k model.Keyset) any { return k.CreatedAt }}
+	titleKey     = keyColumn{name: "title", value: func(k model.Keyset) any { return k.Title }}
+	idKey        = keyColumn{name: "id", value: func(k model.Keyset) any { return k.ID }}
+)
+
+func (c keyColumn) descending(desc bool) keyColumn {
+	c.desc = desc
+	return c
+}
+
+// keysetQuery строит условие и сортировку для страницы ленты. Последней колонкой должен
+// быть уникальный ID. Для предыдущей страницы записи выбираются в обратном порядке от позиции,
+// поэтому результат нужно развернуть через keysetOrder
+func keysetQuery(page model.PageRequest, columns []keyColumn, arg func(any) string) (condition, orderBy string) {
+	order := make([]string, len(columns))
+	for i, c := range columns {
+		if c.desc != page.Backward {
+			order[i] = c.name + " DESC"
+		} else {
+			order[i] = c.name + " ASC"
+		}
+	}
+	orderBy = strings.Join(order, ", ")
+
+	if page.Position == nil || page.Offset > 0 {
+		return "", orderBy
+	}
+
+	op := func(c keyColumn) string {
+		if c.desc != page.Backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	// При одном направлении всех колонок сравнение строк использует составной индекс
+	sameDirection := true
+	for _, c := range columns[1:] {
+		sameDirection = sameDirection && c.desc == columns[0].desc
+	}
+	if sameDirection {
+		names := make([]string, len(columns))
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			names[i] = c.name
+			values[i] = arg(c.value(*page.Position))
+		}
+		return "(" + strings.Join(names, ", ") + ") " + op(columns[0]) + " (" + strings.Join(values, ", ") + ")", orderBy
+	}
+
+	// Иначе: (a op x) OR (a = x AND b op y) OR ...
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = arg(c.value(*page.Position))
+	}
+	alternatives := make([]string, len(columns))
+	for i, c := range columns {
+		var terms []string
+		for j := range i {
+			terms = append(terms, columns[j].name+" = "+values[j])
+		}
+		terms = append(terms, c.name+" "+op(c)+" "+values[i])
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", orderBy
+}
+
+// keysetOrder возвращает записи предыдущей страницы в порядке ленты
+func keysetOrder[T any](page model.PageRequest, items []T) []T {
+	if page.Backward {
+		slices.Reverse(items)
+	}
+	return items
+}
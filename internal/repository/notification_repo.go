@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// NotificationRepo хранит уведомления пользователей в таблице notifications
+type NotificationRepo struct {
+	db dbtx
+}
+
+func NewNotificationRepo(db *sql.DB) *NotificationRepo {
+	return &NotificationRepo{db: newTracedDB(db)}
+}
+
+// Create сохраняет уведомление. Вызванный внутри TxManager.WithinTx, он записывает
+// уведомление в той же транзакции, что и изменение, о котором оно сообщает
+func (r *NotificationRepo) Create(ctx context.Context, n *model.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, type, payload, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	n.CreatedAt = time.Now()
+	err := r.db.QueryRowContext(ctx, query, n.UserID, string(n.Type), []byte(n.Payload), n.CreatedAt).Scan(&n.ID)
+	if err != nil {
+		return wrapError(ctx, "failed to create notification", err)
+	}
+	return nil
+}
+
+// List возвращает уведомления пользователя от новых к старым
+func (r *NotificationRepo) List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*model.Notification, error) {
+	query := `
+		SELECT id, user_id, type, payload, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get notifications", err)
+	}
+	defer rows.Close()
+
+	var notifications []*model.Notification
+	for rows.Next() {
+		var n model.Notification
+		var payload []byte
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &payload, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, wrapError(ctx, "failed to scan notification", err)
+		}
+		n.Payload = payload
+		notifications = append(notifications, &n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate notifications", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkRead отмечает уведомление прочитанным. Чужое уведомление считается ненайденным
+func (r *NotificationRepo) MarkRead(ctx context.Context, userID int, id int64) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $1)
+		WHERE id = $2 AND user_id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id, userID)
+	if err != nil {
+		return wrapError(ctx, "failed to mark notification read", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrNotificationNotFound)
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/model"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type OutboxRepo struct {
+	db dbtx
+}
+
+func NewOutboxRepo(db *sql.DB) *OutboxRepo {
+	return &OutboxRepo{db: newTracedDB(db)}
+}
+
+// Publish сохраняет событие в outbox. Вызванный внутри TxManager.WithinTx,
+// он записывает событие в той же транзакции, что и изменение данных
+func (r *OutboxRepo) Publish(ctx context.Context, e model.Event) error {
+	if e.RequestID == "" {
+		e.RequestID = logger.RequestIDFromContext(ctx)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return wrapError(ctx, "failed to encode event", err)
+	}
+
+	query := `
+		INSERT INTO outbox (event_id, event_type, event)
+		VALUES ($1, $2, $3)
+	`
+	if _, err := r.db.ExecContext(ctx, query, e.ID, string(e.Type), data); err != nil {
+		return wrapError(ctx, "failed to insert outbox event", err)
+	}
+	return nil
+}
+
+// Claim захватывает готовые к доставке события арендой до lockedUntil и возвращает их в порядке записи.
+// Аренда фиксируется сразу, поэтому запись в приемники идет вне транзакции, а другие реплики
+// пропускают захваченные события, пока аренда не истечет
+func (r *OutboxRepo) Claim(ctx context.Context, limit int, lockedUntil time.Time) ([]*model.OutboxMessage, error) {
+	query := `
+		UPDATE outbox o
+		SET locked_until = $2
+		WHERE o.id IN (
+			SELECT id
+			FROM outbox
+			WHERE status = 'pending' AND next_attempt_at <= $1
+				AND (locked_until IS NULL OR locked_until <= $1)
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING o.id, o.event, o.status, o.attempts, o.last_error, o.next_attempt_at, o.created_at, o.delivered_at,
+			ARRAY(SELECT sink FROM outbox_sink_deliveries s WHERE s.outbox_id = o.id)
+	`
+
+	messages, err := r.query(ctx, "failed to claim outbox events", query, time.Now(), lockedUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING не сохраняет порядок подзапроса
+	slices.SortFunc(messages, func(a, b *model.OutboxMessage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return messages, nil
+}
+
+// MarkSinkDelivered запоминает, что приемник принял события; повторная отметка игнорируется
+func (r *OutboxRepo) MarkSinkDelivered(ctx context.Context, sink string, ids []int64) error {
+	query := `
+		INSERT INTO outbox_sink_deliveries (outbox_id, sink, delivered_at)
+		SELECT unnest($1::bigint[]), $2, $3
+		ON CONFLICT (outbox_id, sink) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, pq.Array(ids), sink, time.Now()); err != nil {
+		return wrapError(ctx, "failed to mark outbox events delivered to sink", err)
+	}
+	return nil
+}
+
+// MarkDelivered отмечает события как доставленные
+func (r *OutboxRepo) MarkDelivered(ctx context.Context, ids []int64) error {
+	query := `
+		UPDATE outbox
+		SET status = 'delivered', delivered_at = $1, last_error = NULL, locked_until = NULL
+		WHERE id = ANY($2)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, time.Now(), pq.Array(ids)); err != nil {
+		return wrapError(ctx, "failed to mark outbox events delivered", err)
+	}
+	return nil
+}
+
+// MarkFailed сохраняет неудачную попытку доставки; при dead событие больше не доставляется
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error {
+	status := model.OutboxPending
+	if dead {
+		status = model.OutboxDead
+	}
+
+	query := `
+		UPDATE outbox
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, locked_until = NULL
+		WHERE id = $5
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, status, attempts, nextAttemptAt, lastError, id); err != nil {
+		return wrapError(ctx, "failed to mark outbox event failed", err)
+	}
+	return nil
+}
+
+// ListByStatus возвращает события с указанным статусом, новые первыми
+func (r *OutboxRepo) ListByStatus(ctx context.Context, status string, limit, offset int) ([]*model.OutboxMessage, error) {
+	query := `
+		SELECT id, event, status, attempts, last_error, next_attempt_at, created_at, delivered_at,
+			ARRAY(SELECT sink FROM outbox_sink_deliveries s WHERE s.outbox_id = outbox.id)
+		FROM outbox
+		WHERE status = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	return r.query(ctx, "failed to list outbox events", query, status, limit, offset)
+}
+
+// Requeue возвращает событие из dead-letter в очередь доставки со сброшенным счетчиком попыток
+func (r *OutboxRepo) Requeue(ctx context.Context, id int64) error {
+	query := `
+		UPDATE outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = $1, locked_until = NULL
+		WHERE id = $2 AND status = 'dead'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return wrapError(ctx, "failed to requeue outbox event", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(ctx, "failed to check rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrOutboxEventNotFound
+	}
+
+	return nil
+}
+
+// CountByStatus возвращает число недоставленных событий по статусам (pending, dead)
+func (r *OutboxRepo) CountByStatus(ctx context.Context) (map[string]int, error) {
+	query := `
+		SELECT status, COUNT(*)
+		FROM outbox
+		WHERE status IN ('pending', 'dead')
+		GROUP BY status
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to count outbox events", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, wrapError(ctx, "failed to scan outbox event count", err)
+		}
+		counts[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate outbox event counts", err)
+	}
+
+	return counts, nil
+}
+
+func (r *OutboxRepo) query(ctx context.Context, message, query string, args ...any) ([]*model.OutboxMessage, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(ctx, message, err)
+	}
+	defer rows.Close()
+
+	var messages []*model.OutboxMessage
+	for rows.Next() {
+		var msg model.OutboxMessage
+		var data []byte
+		var lastError sql.NullString
+		var deliveredAt sql.NullTime
+
+		err := rows.Scan(
+			&msg.ID, &data, &msg.Status, &msg.Attempts,
+			&lastError, &msg.NextAttemptAt, &msg.CreatedAt, &deliveredAt,
+			pq.Array(&msg.DeliveredSinks),
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan outbox event", err)
+		}
+		if err := json.Unmarshal(data, &msg.Event); err != nil {
+			return nil, wrapError(ctx, "failed to decode outbox event", err)
+		}
+
+		msg.LastError = lastError.String
+		if deliveredAt.Valid {
+			msg.DeliveredAt = &deliveredAt.Time
+		}
+		messages = append(messages, &msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate outbox events", err)
+	}
+
+	return messages, nil
+}
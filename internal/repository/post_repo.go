@@ -6,56 +6,95 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type PostRepo struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewPostRepo(db *sql.DB) *PostRepo {
-	return &PostRepo{db: db}
+	return &PostRepo{db: newTracedDB(db)}
 }
 
 func (r *PostRepo) Create(ctx context.Context, post *model.Post) error {
 	query := `
-		INSERT INTO posts (title, content, author_id, created_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
+		INSERT INTO posts (title, content, content_format, content_html, summary, author_id, created_at, updated_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, version
 	`
 
 	now := time.Now()
 	post.CreatedAt = now
+	post.UpdatedAt = now
+	if post.Status == "" {
+		post.Status = model.PostPublished
+	}
 
 	err := r.db.QueryRowContext(ctx, query,
-		post.Title, post.Content, post.AuthorID, post.CreatedAt,
-	).Scan(&post.ID)
+		post.Title, post.Content, post.ContentFormat, post.ContentHTML, post.Summary, post.AuthorID, post.CreatedAt, post.UpdatedAt, post.Status,
+	).Scan(&post.ID, &post.Version)
 
 	if err != nil {
-		return fmt.Errorf("failed to create post: %w", err)
+		return wrapError(ctx, "failed to create post", err)
 	}
 
 	return nil
 }
 
+// Update сохраняет заголовок, текст и статус поста, если его версия в БД равна post.Version, и
+// увеличивает версию. Если пост успел изменить другой запрос, возвращает ErrConflict
+func (r *PostRepo) Update(ctx context.Context, post *model.Post) error {
+	query := `
+		UPDATE posts
+		SET title = $1, content = $2, content_format = $3, content_html = $4, status = $5, updated_at = $6, version = version + 1
+		WHERE id = $7 AND version = $8 AND deleted_at IS NULL
+		RETURNING version
+	`
+
+	updatedAt := time.Now()
+
+	err := r.db.QueryRowContext(ctx, query,
+		post.Title, post.Content, post.ContentFormat, post.ContentHTML, post.Status, updatedAt, post.ID, post.Version,
+	).Scan(&post.Version)
+	if err == sql.ErrNoRows {
+		exists, err := r.Exists(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return apperrors.ErrConflict
+		}
+		return apperrors.ErrPostNotFound
+	}
+	if err != nil {
+		return wrapError(ctx, "failed to update post", err)
+	}
+
+	post.UpdatedAt = updatedAt
+	return nil
+}
+
+// GetByID возвращает пост в любом статусе; кому показывать неопубликованный пост, решает сервис
 func (r *PostRepo) GetByID(ctx context.Context, id int) (*model.Post, error) {
 	query := `
-		SELECT id, title, content, author_id, created_at
+		SELECT id, title, content, content_format, content_html, summary, author_id, created_at, updated_at, version, moderate_comments, status
 		FROM posts
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var post model.Post
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&post.ID, &post.Title, &post.Content,
-		&post.AuthorID, &post.CreatedAt,
+		&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.ContentHTML, &post.Summary,
+		&post.AuthorID, &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.ModerateComments, &post.Status,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, apperrors.ErrPostNotFound
 		}
-		return nil, fmt.Errorf("failed to get post: %w", err)
+		return nil, wrapError(ctx, "failed to get post", err)
 	}
 
 	return &post, nil
@@ -63,15 +102,16 @@ func (r *PostRepo) GetByID(ctx context.Context, id int) (*model.Post, error) {
 
 func (r *PostRepo) GetAll(ctx context.Context, limit, offset int) ([]*model.Post, error) {
 	query := `
-		SELECT id, title, content, author_id, created_at
+		SELECT id, title, content, content_format, content_html, summary, author_id, created_at, updated_at, version, moderate_comments, status
 		FROM posts
+		WHERE deleted_at IS NULL AND status = 'published'
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get posts: %w", err)
+		return nil, wrapError(ctx, "failed to get posts", err)
 	}
 	defer rows.Close()
 
@@ -79,41 +119,41 @@ func (r *PostRepo) GetAll(ctx context.Context, limit, offset int) ([]*model.Post
 	for rows.Next() {
 		var post model.Post
 		err := rows.Scan(
-			&post.ID, &post.Title, &post.Content,
-			&post.AuthorID, &post.CreatedAt,
+			&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.ContentHTML, &post.Summary,
+			&post.AuthorID, &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.ModerateComments, &post.Status,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan post: %w", err)
+			return nil, wrapError(ctx, "failed to scan post", err)
 		}
 		posts = append(posts, &post)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate posts: %w", err)
+		return nil, wrapError(ctx, "failed to iterate posts", err)
 	}
 
 	return posts, nil
 }
 
 func (r *PostRepo) GetTotalCount(ctx context.Context) (int, error) {
-	query := `SELECT COUNT(*) FROM posts`
+	query := `SELECT COUNT(*) FROM posts WHERE deleted_at IS NULL AND status = 'published'`
 
 	var count int
 	err := r.db.QueryRowContext(ctx, query).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get total post count: %w", err)
+		return 0, wrapError(ctx, "failed to get total post count", err)
 	}
 
 	return count, nil
 }
 
 func (r *PostRepo) Exists(ctx context.Context, id int) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)`
 
 	var exists bool
 	err := r.db.QueryRowContext(ctx, query, id).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check post existence: %w", err)
+		return false, wrapError(ctx, "failed to check post existence", err)
 	}
 
 	return exists, nil
@@ -121,9 +161,9 @@ func (r *PostRepo) Exists(ctx context.Context, id int) (bool, error) {
 
 func (r *PostRepo) GetByAuthorID(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error) {
 	query := `
-		SELECT id, title, content, author_id, created_at
+		SELECT id, title, content, content_format, content_html, summary, author_id, created_at, updated_at, version, moderate_comments, status
 		FROM posts
-		WHERE author_id = $1
+		WHERE author_id = $1 AND deleted_at IS NULL AND status = 'published'
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -132,7 +172,7 @@ func (r *PostRepo) GetByAuthorID(ctx context.Context, authorID int, limit, offse
 		authorID, limit, offset,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get posts by author: %w", err)
+		return nil, wrapError(ctx, "failed to get posts by author", err)
 	}
 	defer rows.Close()
 
@@ -141,31 +181,346 @@ func (r *PostRepo) GetByAuthorID(ctx context.Context, authorID int, limit, offse
 		var post model.Post
 
 		err := rows.Scan(
-			&post.ID, &post.Title, &post.Content,
-			&post.AuthorID, &post.CreatedAt,
+			&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.ContentHTML, &post.Summary,
+			&post.AuthorID, &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.ModerateComments, &post.Status,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan post: %w", err)
+			return nil, wrapError(ctx, "failed to scan post", err)
 		}
 
 		posts = append(posts, &post)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate posts: %w", err)
+		return nil, wrapError(ctx, "failed to iterate posts", err)
 	}
 
 	return posts, nil
 }
 
 func (r *PostRepo) GetTotalCountByAuthorID(ctx context.Context, authorID int) (int, error) {
-	query := `SELECT COUNT(*) FROM posts WHERE author_id = $1`
+	query := `SELECT COUNT(*) FROM posts WHERE author_id = $1 AND deleted_at IS NULL AND status = 'published'`
 
 	var count int
 	err := r.db.QueryRowContext(ctx, query, authorID).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get total post count by author: %w", err)
+		return 0, wrapError(ctx, "failed to get total post count by author", err)
 	}
 
 	return count, nil
 }
+
+// postSortColumns - белый список полей сортировки ленты: в SQL попадают только эти имена колонок,
+// значения передаются параметрами
+var postSortColumns = map[string]keyColumn{
+	model.PostSortCreatedAt: createdAtKey,
+	model.PostSortTitle:     titleKey,
+}
+
+// GetPage возвращает страницу ленты постов по фильтру: по позиции page.Position или,
+// в режиме смещения, начиная с page.Offset
+func (r *PostRepo) GetPage(ctx context.Context, filter model.PostFilter, page model.PageRequest) ([]*model.Post, error) {
+	columns, err := postKeyColumns(filter.SortOrDefault())
+	if err != nil {
+		return nil, err
+	}
+
+	var args sqlArgs
+	conditions := postFilterConditions(filter, &args)
+	keyset, orderBy := keysetQuery(page, columns, args.add)
+	if keyset != "" {
+		conditions = append(conditions, keyset)
+	}
+
+	query := `
+		SELECT id, title, content, content_format, content_html, summary, author_id, created_at, updated_at, version, moderate_comments, status
+		FROM posts
+		WHERE ` + strings.Join(conditions, " AND ")
+	query += "\n\t\tORDER BY " + orderBy + "\n\t\tLIMIT " + args.add(page.Limit)
+	if page.Offset > 0 {
+		query += " OFFSET " + args.add(page.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get posts page", err)
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		var post model.Post
+		err := rows.Scan(
+			&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.ContentHTML, &post.Summary,
+			&post.AuthorID, &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.ModerateComments, &post.Status,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan post", err)
+		}
+		posts = append(posts, &post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate posts", err)
+	}
+
+	return keysetOrder(page, posts), nil
+}
+
+// GetCount возвращает число постов, подходящих под фильтр
+func (r *PostRepo) GetCount(ctx context.Context, filter model.PostFilter) (int, error) {
+	var args sqlArgs
+	conditions := postFilterConditions(filter, &args)
+	query := `SELECT COUNT(*) FROM posts WHERE ` + strings.Join(conditions, " AND ")
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, wrapError(ctx, "failed to count posts", err)
+	}
+	return count, nil
+}
+
+// postFilterConditions переводит фильтр в условия WHERE; значения передаются параметрами.
+// Первые условия всегда отсекают удаленные и неопубликованные посты
+func postFilterConditions(filter model.PostFilter, args *sqlArgs) []string {
+	conditions := []string{"deleted_at IS NULL", "status = 'published'"}
+	arg := args.add
+	if filter.AuthorID != 0 {
+		conditions = append(conditions, "author_id = "+arg(filter.AuthorID))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(filter.CreatedBefore))
+	}
+	if filter.TitlePrefix != "" {
+		conditions = append(conditions, "starts_with(lower(title), lower("+arg(filter.TitlePrefix)+"))")
+	}
+	if filter.HasComments != nil {
+		exists := "EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.status = 'approved' AND comments.deleted_at IS NULL)"
+		if !*filter.HasComments {
+			exists = "NOT " + exists
+		}
+		conditions = append(conditions, exists)
+	}
+	return conditions
+}
+
+// postKeyColumns строит колонки сортировки по белому списку; ID добавляется последним
+// в направлении последнего поля, чтобы порядок был однозначным
+func postKeyColumns(sort []model.SortField) ([]keyColumn, error) {
+	columns := make([]keyColumn, 0, len(sort)+1)
+	for _, s := range sort {
+		column, ok := postSortColumns[s.Field]
+		if !ok {
+			return nil, apperrors.FieldErrors{"sort": fmt.Sprintf("unknown field %q", s.Field)}
+		}
+		columns = append(columns, column.descending(s.Desc))
+	}
+	return append(columns, idKey.descending(sort[len(sort)-1].Desc)), nil
+}
+
+// Delete помечает пост удаленным временем deletedAt вместе с его комментариями
+func (r *PostRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
+	query := `UPDATE posts SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, deletedAt, id)
+	if err != nil {
+		return wrapError(ctx, "failed to delete post", err)
+	}
+	if err := requireAffected(ctx, result, apperrors.ErrPostNotFound); err != nil {
+		return err
+	}
+
+	query = `UPDATE comments SET deleted_at = $1 WHERE post_id = $2 AND deleted_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, deletedAt, id); err != nil {
+		return wrapError(ctx, "failed to delete post comments", err)
+	}
+
+	return nil
+}
+
+// Restore снимает пометку удаления с поста и с комментариев, удаленных вместе с ним.
+// Комментарии, удаленные раньше поста, остаются в корзине
+func (r *PostRepo) Restore(ctx context.Context, id int) error {
+	query := `
+		UPDATE posts p
+		SET deleted_at = NULL
+		FROM (SELECT id, deleted_at FROM posts WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE) old
+		WHERE p.id = old.id
+		RETURNING old.deleted_at
+	`
+
+	var deletedAt time.Time
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&deletedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrPostNotFound
+		}
+		return wrapError(ctx, "failed to restore post", err)
+	}
+
+	query = `UPDATE comments SET deleted_at = NULL WHERE post_id = $1 AND deleted_at = $2`
+	if _, err := r.db.ExecContext(ctx, query, id, deletedAt); err != nil {
+		return wrapError(ctx, "failed to restore post comments", err)
+	}
+
+	return nil
+}
+
+// DeleteByAuthor помечает удаленными все посты автора и комментарии к ним
+func (r *PostRepo) DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	query := `
+		UPDATE comments SET deleted_at = $1
+		WHERE deleted_at IS NULL
+			AND post_id IN (SELECT id FROM posts WHERE author_id = $2 AND deleted_at IS NULL)
+	`
+	if _, err := r.db.ExecContext(ctx, query, deletedAt, authorID); err != nil {
+		return wrapError(ctx, "failed to delete comments on author posts", err)
+	}
+
+	query = `UPDATE posts SET deleted_at = $1 WHERE author_id = $2 AND deleted_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, deletedAt, authorID); err != nil {
+		return wrapError(ctx, "failed to delete author posts", err)
+	}
+
+	return nil
+}
+
+// RestoreByAuthor восстанавливает посты автора и комментарии к ним, помеченные временем deletedAt
+func (r *PostRepo) RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	query := `
+		UPDATE comments SET deleted_at = NULL
+		WHERE deleted_at = $1
+			AND post_id IN (SELECT id FROM posts WHERE author_id = $2 AND deleted_at = $1)
+	`
+	if _, err := r.db.ExecContext(ctx, query, deletedAt, authorID); err != nil {
+		return wrapError(ctx, "failed to restore comments on author posts", err)
+	}
+
+	query = `UPDATE posts SET deleted_at = NULL WHERE author_id = $2 AND deleted_at = $1`
+	if _, err := r.db.ExecContext(ctx, query, deletedAt, authorID); err != nil {
+		return wrapError(ctx, "failed to restore author posts", err)
+	}
+
+	return nil
+}
+
+// GetDeleted возвращает удаленные посты, начиная с удаленных последними; authorID = 0 - посты всех авторов
+func (r *PostRepo) GetDeleted(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error) {
+	query := `
+		SELECT id, title, content, content_format, content_html, summary, author_id, created_at, updated_at, version, moderate_comments, status, deleted_at
+		FROM posts
+		WHERE deleted_at IS NOT NULL AND ($1 = 0 OR author_id = $1)
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, authorID, limit, offset)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get deleted posts", err)
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		var post model.Post
+		err := rows.Scan(
+			&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.ContentHTML, &post.Summary,
+			&post.AuthorID, &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.ModerateComments, &post.Status, &post.DeletedAt,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan post", err)
+		}
+		posts = append(posts, &post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate posts", err)
+	}
+
+	return posts, nil
+}
+
+// GetDeletedByID возвращает пост из корзины
+func (r *PostRepo) GetDeletedByID(ctx context.Context, id int) (*model.Post, error) {
+	query := `
+		SELECT id, title, content, content_format, content_html, summary, author_id, created_at, updated_at, version, moderate_comments, status, deleted_at
+		FROM posts
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	var post model.Post
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.ContentHTML, &post.Summary,
+		&post.AuthorID, &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.ModerateComments, &post.Status, &post.DeletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrPostNotFound
+		}
+		return nil, wrapError(ctx, "failed to get deleted post", err)
+	}
+
+	return &post, nil
+}
+
+// SetModerateComments включает или выключает модерацию новых комментариев поста
+func (r *PostRepo) SetModerateComments(ctx context.Context, id int, enabled bool) error {
+	query := `UPDATE posts SET moderate_comments = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, enabled, id)
+	if err != nil {
+		return wrapError(ctx, "failed to set post comment moderation", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrPostNotFound)
+}
+
+// GetPending возвращает посты, ожидающие модерации, начиная со старых; authorID = 0 - посты всех авторов
+func (r *PostRepo) GetPending(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error) {
+	query := `
+		SELECT id, title, content, content_format, content_html, summary, author_id, created_at, updated_at, version, moderate_comments, status
+		FROM posts
+		WHERE status = 'pending' AND deleted_at IS NULL AND ($1 = 0 OR author_id = $1)
+		ORDER BY updated_at, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, authorID, limit, offset)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get pending posts", err)
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		var post model.Post
+		err := rows.Scan(
+			&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.ContentHTML, &post.Summary,
+			&post.AuthorID, &post.CreatedAt, &post.UpdatedAt, &post.Version, &post.ModerateComments, &post.Status,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan post", err)
+		}
+		posts = append(posts, &post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate posts", err)
+	}
+
+	return posts, nil
+}
+
+// SetStatus меняет статус модерации поста
+func (r *PostRepo) SetStatus(ctx context.Context, id int, status string) error {
+	query := `UPDATE posts SET status = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, status, id)
+	if err != nil {
+		return wrapError(ctx, "failed to set post status", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrPostNotFound)
+}
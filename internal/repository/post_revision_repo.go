@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CreateRevision добавляет следующую по номеру версию поста. Номер вычисляется в запросе;
+// одновременные правки упорядочивает блокировка строки поста, которую берет Update
+// в той же транзакции, поэтому сохранять версию нужно после изменения поста
+func (r *PostRepo) CreateRevision(ctx context.Context, rev *model.PostRevision) error {
+	query := `
+		INSERT INTO post_revisions (post_id, revision, title, content, content_format, editor_id, restored_from, created_at)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, $7
+		FROM post_revisions
+		WHERE post_id = $1
+		RETURNING revision
+	`
+
+	rev.CreatedAt = time.Now()
+
+	var restoredFrom sql.NullInt64
+	if rev.RestoredFrom > 0 {
+		restoredFrom = sql.NullInt64{Int64: int64(rev.RestoredFrom), Valid: true}
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		rev.PostID, rev.Title, rev.Content, rev.ContentFormat, rev.EditorID, restoredFrom, rev.CreatedAt,
+	).Scan(&rev.Revision)
+	if err != nil {
+		return wrapError(ctx, "failed to create post revision", err)
+	}
+
+	return nil
+}
+
+// GetRevisions возвращает версии поста от новых к старым без текста
+func (r *PostRepo) GetRevisions(ctx context.Context, postID int, limit, offset int) ([]*model.PostRevision, error) {
+	query := `
+		SELECT post_id, revision, title, content_format, editor_id, COALESCE(restored_from, 0), created_at
+		FROM post_revisions
+		WHERE post_id = $1
+			AND EXISTS (SELECT 1 FROM posts WHERE posts.id = post_id AND posts.deleted_at IS NULL)
+		ORDER BY revision DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, postID, limit, offset)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get post revisions", err)
+	}
+	defer rows.Close()
+
+	var revisions []*model.PostRevision
+	for rows.Next() {
+		var rev model.PostRevision
+		err := rows.Scan(
+			&rev.PostID, &rev.Revision, &rev.Title, &rev.ContentFormat, &rev.EditorID, &rev.RestoredFrom, &rev.CreatedAt,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan post revision", err)
+		}
+		revisions = append(revisions, &rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate post revisions", err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision возвращает версию поста целиком
+func (r *PostRepo) GetRevision(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+	query := `
+		SELECT post_id, revision, title, content, content_format, editor_id, COALESCE(restored_from, 0), created_at
+		FROM post_revisions
+		WHERE post_id = $1 AND revision = $2
+			AND EXISTS (SELECT 1 FROM posts WHERE posts.id = post_id AND posts.deleted_at IS NULL)
+	`
+
+	var rev model.PostRevision
+	err := r.db.QueryRowContext(ctx, query, postID, revision).Scan(
+		&rev.PostID, &rev.Revision, &rev.Title, &rev.Content, &rev.ContentFormat, &rev.EditorID, &rev.RestoredFrom, &rev.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrRevisionNotFound
+		}
+		return nil, wrapError(ctx, "failed to get post revision", err)
+	}
+
+	return &rev, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/pubsub"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// StreamRepo хранит журнал событий потоков в таблице stream_events
+type StreamRepo struct {
+	db dbtx
+}
+
+func NewStreamRepo(db *sql.DB) *StreamRepo {
+	return &StreamRepo{db: newTracedDB(db)}
+}
+
+// Append сохраняет событие темы и возвращает его номер. Вызванный внутри TxManager.WithinTx,
+// он блокирует счетчик темы до фиксации: следующая транзакция получит номер только после нее,
+// а при откате номер достанется ей же. Поэтому номера видны читателям без пропусков и в порядке фиксации
+func (r *StreamRepo) Append(ctx context.Context, topic, event string, data []byte) (int64, error) {
+	query := `
+		WITH next AS (
+			INSERT INTO stream_topics (topic, last_seq) VALUES ($1, 1)
+			ON CONFLICT (topic) DO UPDATE SET last_seq = stream_topics.last_seq + 1
+			RETURNING last_seq
+		)
+		INSERT INTO stream_events (topic, seq, event, data, created_at)
+		SELECT $1, last_seq, $2, $3, $4 FROM next
+		RETURNING seq
+	`
+
+	var seq int64
+	if err := r.db.QueryRowContext(ctx, query, topic, event, data, time.Now()).Scan(&seq); err != nil {
+		return 0, wrapError(ctx, "failed to append stream event", err)
+	}
+	return seq, nil
+}
+
+// Load возвращает событие темы с номером id
+func (r *StreamRepo) Load(ctx context.Context, topic string, id int64) (pubsub.Message, error) {
+	query := `
+		SELECT seq, event, data
+		FROM stream_events
+		WHERE topic = $1 AND seq = $2
+	`
+
+	var msg pubsub.Message
+	if err := r.db.QueryRowContext(ctx, query, topic, id).Scan(&msg.ID, &msg.Event, &msg.Data); err != nil {
+		return pubsub.Message{}, wrapError(ctx, "failed to load stream event", err)
+	}
+	return msg, nil
+}
+
+// After возвращает до limit событий темы с номером больше afterID в порядке номеров
+func (r *StreamRepo) After(ctx context.Context, topic string, afterID int64, limit int) ([]pubsub.Message, error) {
+	query := `
+		SELECT seq, event, data
+		FROM stream_events
+		WHERE topic = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, topic, afterID, limit)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get stream events", err)
+	}
+	defer rows.Close()
+
+	var messages []pubsub.Message
+	for rows.Next() {
+		var msg pubsub.Message
+		if err := rows.Scan(&msg.ID, &msg.Event, &msg.Data); err != nil {
+			return nil, wrapError(ctx, "failed to scan stream event", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate stream events", err)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TrashRepo окончательно удаляет записи, пролежавшие в корзине дольше срока хранения
+type TrashRepo struct {
+	db dbtx
+}
+
+func NewTrashRepo(db *sql.DB) *TrashRepo {
+	return &TrashRepo{db: newTracedDB(db)}
+}
+
+// purgeQueries удаляют не больше limit строк, помеченных раньше before. Пользователи идут первыми:
+// внешние ключи ON DELETE CASCADE удаляют их посты, комментарии и вебхуки, а посты - свои комментарии и версии.
+// Журнал событий потоков хранится столько же, сколько корзина; счетчики тем не удаляются,
+// чтобы номера событий не начинались заново
+var purgeQueries = []struct {
+	table string
+	query string
+}{
+	{"users", `DELETE FROM users WHERE id IN (SELECT id FROM users WHERE deleted_at < $1 LIMIT $2)`},
+	{"posts", `DELETE FROM posts WHERE id IN (SELECT id FROM posts WHERE deleted_at < $1 LIMIT $2)`},
+	{"comments", `DELETE FROM comments WHERE id IN (SELECT id FROM comments WHERE deleted_at < $1 LIMIT $2)`},
+	{"stream_events", `DELETE FROM stream_events WHERE (topic, seq) IN (SELECT topic, seq FROM stream_events WHERE created_at < $1 LIMIT $2)`},
+}
+
+// Purge удаляет из каждой таблицы до limit строк, удаленных раньше before, и возвращает
+// число удаленных строк по таблицам (без учета каскадных удалений). Ошибка одной таблицы
+// не останавливает очистку остальных: все ошибки возвращаются вместе
+func (r *TrashRepo) Purge(ctx context.Context, before time.Time, limit int) (map[string]int, error) {
+	purged := make(map[string]int, len(purgeQueries))
+	var errs []error
+	for _, q := range purgeQueries {
+		result, err := r.db.ExecContext(ctx, q.query, before, limit)
+		if err != nil {
+			errs = append(errs, wrapError(ctx, "failed to purge "+q.table, err))
+			continue
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			errs = append(errs, wrapError(ctx, "failed to check rows affected", err))
+			continue
+		}
+		purged[q.table] = int(n)
+	}
+	return purged, errors.Join(errs...)
+}
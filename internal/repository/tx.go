@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TxManager выполняет функцию в транзакции. Репозитории, вызванные с контекстом из fn,
+// автоматически работают внутри этой транзакции
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txKey - ключ контекста для текущей транзакции
+type txKey struct{}
+
+// txState - открытая транзакция и функции, которые нужно выполнить после ее фиксации
+type txState struct {
+	tx          *sql.Tx
+	afterCommit []func()
+}
+
+type SQLTxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *SQLTxManager {
+	return &SQLTxManager{db: db}
+}
+
+// WithinTx фиксирует транзакцию, если fn вернула nil, и откатывает ее в противном случае.
+// Вложенный вызов переиспользует уже открытую транзакцию
+func (m *SQLTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(ctx, "failed to begin transaction", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	state := &txState{tx: tx}
+	if err = fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wrapError(ctx, "failed to commit transaction", err)
+	}
+
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit откладывает fn до фиксации текущей транзакции; при откате fn не вызывается.
+// Вне транзакции fn выполняется сразу
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// inTx сообщает, выполняется ли запрос внутри транзакции
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*txState)
+	return ok
+}
+
+// conn возвращает транзакцию из контекста или исходное подключение
+func conn(ctx context.Context, db dbtx) dbtx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
@@ -5,28 +5,30 @@ import (
 	"advanced-blog-management-system/internal/model"
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 )
 
 // UserRepo представляет репозиторий для работы с пользователями
 type UserRepo struct {
-	db *sql.DB
+	db dbtx
 }
 
 // NewUserRepo создает новый репозиторий пользователей
 func NewUserRepo(db *sql.DB) *UserRepo {
-	return &UserRepo{db: db}
+	return &UserRepo{db: newTracedDB(db)}
 }
 
 // Create создает нового пользователя
 func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
 	query := `
-		INSERT INTO users (username, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (username, email, password, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
 
+	if user.Role == "" {
+		user.Role = model.RoleUser
+	}
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
@@ -35,6 +37,7 @@ func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
 		user.Username,
 		user.Email,
 		user.Password,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
@@ -43,7 +46,7 @@ func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
 		if err == sql.ErrNoRows {
 			return apperrors.ErrUserNotFound
 		}
-		return fmt.Errorf("failed to create user: %w", err)
+		return wrapError(ctx, "failed to create user", err)
 	}
 
 	return nil
@@ -52,9 +55,9 @@ func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
 // GetByID получает пользователя по ID
 func (r *UserRepo) GetByID(ctx context.Context, id int) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT id, username, email, password, role, created_at, updated_at
 		FROM users
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var user model.User
@@ -64,6 +67,7 @@ func (r *UserRepo) GetByID(ctx context.Context, id int) (*model.User, error) {
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -72,7 +76,7 @@ func (r *UserRepo) GetByID(ctx context.Context, id int) (*model.User, error) {
 		if err == sql.ErrNoRows {
 			return nil, apperrors.ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, wrapError(ctx, "failed to get user", err)
 	}
 
 	return &user, nil
@@ -81,9 +85,9 @@ func (r *UserRepo) GetByID(ctx context.Context, id int) (*model.User, error) {
 // GetByEmail получает пользователя по email
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT id, username, email, password, role, created_at, updated_at
 		FROM users
-		WHERE email = $1
+		WHERE email = $1 AND deleted_at IS NULL
 	`
 
 	var user model.User
@@ -93,6 +97,7 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*model.User, e
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -101,7 +106,7 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*model.User, e
 		if err == sql.ErrNoRows {
 			return nil, apperrors.ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
+		return nil, wrapError(ctx, "failed to get user by email", err)
 	}
 
 	return &user, nil
@@ -110,9 +115,9 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*model.User, e
 // GetByUsername получает пользователя по username
 func (r *UserRepo) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT id, username, email, password, role, created_at, updated_at
 		FROM users
-		WHERE username = $1
+		WHERE username = $1 AND deleted_at IS NULL
 	`
 
 	var user model.User
@@ -122,6 +127,7 @@ func (r *UserRepo) GetByUsername(ctx context.Context, username string) (*model.U
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -130,33 +136,34 @@ func (r *UserRepo) GetByUsername(ctx context.Context, username string) (*model.U
 		if err == sql.ErrNoRows {
 			return nil, apperrors.ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user by username: %w", err)
+		return nil, wrapError(ctx, "failed to get user by username", err)
 	}
 
 	return &user, nil
 }
 
-// ExistsByEmail проверяет существование пользователя по email
+// ExistsByEmail проверяет существование пользователя по email. Удаленные пользователи учитываются:
+// email остается занят, пока запись не очищена окончательно
 func (r *UserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
 
 	var exists bool
 	err := r.db.QueryRowContext(ctx, query, email).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check email existence: %w", err)
+		return false, wrapError(ctx, "failed to check email existence", err)
 	}
 
 	return exists, nil
 }
 
-// ExistsByUsername проверяет существование пользователя по username
+// ExistsByUsername проверяет существование пользователя по username, включая удаленных
 func (r *UserRepo) ExistsByUsername(ctx context.Context, username string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`
 
 	var exists bool
 	err := r.db.QueryRowContext(ctx, query, username).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check username existence: %w", err)
+		return false, wrapError(ctx, "failed to check username existence", err)
 	}
 
 	return exists, nil
@@ -167,7 +174,7 @@ func (r *UserRepo) Update(ctx context.Context, user *model.User) error {
 	query := `
 		UPDATE users
 		SET username = $1, email = $2, password = $3, updated_at = $4
-		WHERE id = $5
+		WHERE id = $5 AND deleted_at IS NULL
 	`
 
 	user.UpdatedAt = time.Now()
@@ -181,12 +188,33 @@ func (r *UserRepo) Update(ctx context.Context, user *model.User) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return wrapError(ctx, "failed to update user", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(ctx, "failed to check rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateRole меняет роль пользователя
+func (r *UserRepo) UpdateRole(ctx context.Context, id int, role string) error {
+	query := `UPDATE users SET role = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, role, time.Now(), id)
+	if err != nil {
+		return wrapError(ctx, "failed to update user role", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
+		return wrapError(ctx, "failed to check rows affected", err)
 	}
 
 	if rowsAffected == 0 {
@@ -196,18 +224,18 @@ func (r *UserRepo) Update(ctx context.Context, user *model.User) error {
 	return nil
 }
 
-// Delete удаляет пользователя
-func (r *UserRepo) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM users WHERE id = $1`
+// Delete помечает пользователя удаленным временем deletedAt
+func (r *UserRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
+	query := `UPDATE users SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, deletedAt, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return wrapError(ctx, "failed to delete user", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
+		return wrapError(ctx, "failed to check rows affected", err)
 	}
 
 	if rowsAffected == 0 {
@@ -216,3 +244,68 @@ func (r *UserRepo) Delete(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// Restore снимает с пользователя пометку удаления и возвращает время, которым он был помечен:
+// по нему восстанавливаются удаленные вместе с ним записи
+func (r *UserRepo) Restore(ctx context.Context, id int) (time.Time, error) {
+	query := `
+		UPDATE users u
+		SET deleted_at = NULL
+		FROM (SELECT id, deleted_at FROM users WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING old.deleted_at
+	`
+
+	var deletedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, apperrors.ErrUserNotFound
+		}
+		return time.Time{}, wrapError(ctx, "failed to restore user", err)
+	}
+
+	return deletedAt, nil
+}
+
+// GetDeleted возвращает удаленных пользователей, начиная с удаленных последними
+func (r *UserRepo) GetDeleted(ctx context.Context, limit, offset int) ([]*model.User, error) {
+	query := `
+		SELECT id, username, email, password, role, created_at, updated_at, deleted_at
+		FROM users
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to get deleted users", err)
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		var user model.User
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Password,
+			&user.Role,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.DeletedAt,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan user", err)
+		}
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate users", err)
+	}
+
+	return users, nil
+}
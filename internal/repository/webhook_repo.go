@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type WebhookRepo struct {
+	db dbtx
+}
+
+func NewWebhookRepo(db *sql.DB) *WebhookRepo {
+	return &WebhookRepo{db: newTracedDB(db)}
+}
+
+// Create создает подписку на вебхук
+func (r *WebhookRepo) Create(ctx context.Context, webhook *model.Webhook) error {
+	query := `
+		INSERT INTO webhooks (owner_id, url, secret, events, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		webhook.OwnerID,
+		webhook.URL,
+		webhook.Secret,
+		pq.Array(webhook.Events),
+		webhook.Active,
+		webhook.CreatedAt,
+		webhook.UpdatedAt,
+	).Scan(&webhook.ID)
+
+	if err != nil {
+		return wrapError(ctx, "failed to create webhook", err)
+	}
+
+	return nil
+}
+
+// GetByID получает подписку по ID вместе с секретом
+func (r *WebhookRepo) GetByID(ctx context.Context, id int) (*model.Webhook, error) {
+	query := `
+		SELECT id, owner_id, url, secret, events, active, created_at, updated_at
+		FROM webhooks
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	var webhook model.Webhook
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&webhook.ID,
+		&webhook.OwnerID,
+		&webhook.URL,
+		&webhook.Secret,
+		pq.Array(&webhook.Events),
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrWebhookNotFound
+		}
+		return nil, wrapError(ctx, "failed to get webhook", err)
+	}
+
+	return &webhook, nil
+}
+
+// List возвращает все подписки, кроме попавших в корзину вместе с владельцем
+func (r *WebhookRepo) List(ctx context.Context) ([]*model.Webhook, error) {
+	return r.list(ctx, "WHERE deleted_at IS NULL")
+}
+
+// ListActive возвращает включенные подписки
+func (r *WebhookRepo) ListActive(ctx context.Context) ([]*model.Webhook, error) {
+	return r.list(ctx, "WHERE active AND deleted_at IS NULL")
+}
+
+func (r *WebhookRepo) list(ctx context.Context, where string) ([]*model.Webhook, error) {
+	query := `
+		SELECT id, owner_id, url, secret, events, active, created_at, updated_at
+		FROM webhooks
+		` + where + `
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to list webhooks", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*model.Webhook
+	for rows.Next() {
+		var webhook model.Webhook
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.OwnerID,
+			&webhook.URL,
+			&webhook.Secret,
+			pq.Array(&webhook.Events),
+			&webhook.Active,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+		)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan webhook", err)
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate webhooks", err)
+	}
+
+	return webhooks, nil
+}
+
+// Update обновляет адрес, фильтр событий и признак активности подписки
+func (r *WebhookRepo) Update(ctx context.Context, webhook *model.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, events = $2, active = $3, updated_at = $4
+		WHERE id = $5 AND deleted_at IS NULL
+	`
+
+	webhook.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		webhook.URL,
+		pq.Array(webhook.Events),
+		webhook.Active,
+		webhook.UpdatedAt,
+		webhook.ID,
+	)
+	if err != nil {
+		return wrapError(ctx, "failed to update webhook", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrWebhookNotFound)
+}
+
+// Delete удаляет подписку вместе с журналом ее доставок
+func (r *WebhookRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return wrapError(ctx, "failed to delete webhook", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrWebhookNotFound)
+}
+
+// DeleteByOwner помечает удаленными все подписки владельца; их доставки больше не отправляются
+func (r *WebhookRepo) DeleteByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error {
+	query := `UPDATE webhooks SET deleted_at = $1 WHERE owner_id = $2 AND deleted_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, deletedAt, ownerID); err != nil {
+		return wrapError(ctx, "failed to delete owner webhooks", err)
+	}
+	return nil
+}
+
+// RestoreByOwner восстанавливает подписки владельца, помеченные временем deletedAt
+func (r *WebhookRepo) RestoreByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error {
+	query := `UPDATE webhooks SET deleted_at = NULL WHERE owner_id = $1 AND deleted_at = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, ownerID, deletedAt); err != nil {
+		return wrapError(ctx, "failed to restore owner webhooks", err)
+	}
+	return nil
+}
+
+// CreateDeliveries ставит доставки в очередь. Повторная постановка того же события
+// в ту же подписку игнорируется, поэтому повтор пакета outbox не дублирует запросы
+func (r *WebhookRepo) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	const columns = 5
+	now := time.Now()
+	placeholders := make([]string, 0, len(deliveries))
+	args := make([]any, 0, len(deliveries)*columns)
+	for i, d := range deliveries {
+		base := i * columns
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5))
+		args = append(args, d.WebhookID, d.EventID, string(d.EventType), string(d.Payload), now)
+	}
+
+	query := `INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt_at) VALUES ` +
+		strings.Join(placeholders, ", ") +
+		` ON CONFLICT (webhook_id, event_id) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return wrapError(ctx, "failed to enqueue webhook deliveries", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries захватывает готовые к отправке доставки активных подписок, переводя
+// next_attempt_at на время окончания аренды, и возвращает их вместе с адресом и секретом.
+// Аренда фиксируется сразу, поэтому запросы к получателям идут вне транзакции, а другие
+// реплики пропускают захваченные доставки, пока аренда не истечет
+func (r *WebhookRepo) ClaimDeliveries(ctx context.Context, limit int, lockedUntil time.Time) ([]*model.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $2
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT pd.id
+			FROM webhook_deliveries pd
+			JOIN webhooks pw ON pw.id = pd.webhook_id
+			WHERE pd.status = 'pending' AND pd.next_attempt_at <= $1 AND pw.active AND pw.deleted_at IS NULL
+			ORDER BY pd.next_attempt_at, pd.id
+			LIMIT $3
+			FOR UPDATE OF pd SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			d.response_code, d.response_body, d.last_error, d.duration_ms,
+			d.next_attempt_at, d.created_at, d.delivered_at, w.url, w.secret
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now(), lockedUntil, limit)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to claim webhook deliveries", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows, true)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan webhook delivery", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate webhook deliveries", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (r *WebhookRepo) UpdateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_code = $3, response_body = $4, last_error = $5,
+			duration_ms = $6, next_attempt_at = $7, delivered_at = $8
+		WHERE id = $9
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		d.Status,
+		d.Attempts,
+		d.ResponseCode,
+		nullString(d.ResponseBody),
+		nullString(d.LastError),
+		d.DurationMS,
+		d.NextAttemptAt,
+		d.DeliveredAt,
+		d.ID,
+	)
+	if err != nil {
+		return wrapError(ctx, "failed to update webhook delivery", err)
+	}
+	return nil
+}
+
+// ListDeliveries возвращает журнал доставок подписки, новые первыми
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, webhookID int, limit, offset int) ([]*model.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts,
+			response_code, response_body, last_error, duration_ms,
+			next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, wrapError(ctx, "failed to list webhook deliveries", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows, false)
+		if err != nil {
+			return nil, wrapError(ctx, "failed to scan webhook delivery", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "failed to iterate webhook deliveries", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver ставит доставку в очередь заново со сброшенным счетчиком попыток
+func (r *WebhookRepo) Redeliver(ctx context.Context, webhookID int, deliveryID int64) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = $1, delivered_at = NULL
+		WHERE id = $2 AND webhook_id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), deliveryID, webhookID)
+	if err != nil {
+		return wrapError(ctx, "failed to redeliver webhook", err)
+	}
+
+	return requireAffected(ctx, result, apperrors.ErrDeliveryNotFound)
+}
+
+func scanDelivery(rows *sql.Rows, withTarget bool) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	var eventType string
+	var payload []byte
+	var responseCode, durationMS sql.NullInt32
+	var responseBody, lastError sql.NullString
+	var deliveredAt sql.NullTime
+
+	dest := []any{
+		&d.ID, &d.WebhookID, &d.EventID, &eventType, &payload, &d.Status, &d.Attempts,
+		&responseCode, &responseBody, &lastError, &durationMS,
+		&d.NextAttemptAt, &d.CreatedAt, &deliveredAt,
+	}
+	if withTarget {
+		dest = append(dest, &d.URL, &d.Secret)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	d.EventType = model.EventType(eventType)
+	d.Payload = payload
+	d.ResponseBody = responseBody.String
+	d.LastError = lastError.String
+	if responseCode.Valid {
+		code := int(responseCode.Int32)
+		d.ResponseCode = &code
+	}
+	if durationMS.Valid {
+		ms := int(durationMS.Int32)
+		d.DurationMS = &ms
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return &d, nil
+}
+
+func requireAffected(ctx context.Context, result sql.Result, notFound error) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(ctx, "failed to check rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
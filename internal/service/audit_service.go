@@ -0,0 +1,99 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/tracing"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	// auditExportBatch - размер страницы, которой выгружается журнал при экспорте
+	auditExportBatch = 1000
+)
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// List возвращает страницу журнала аудита и курсор следующей страницы (пустой, если страниц больше нет)
+func (s *AuditService) List(ctx context.Context, filter model.AuditFilter, cursor string) (_ []*model.AuditEntry, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.List")
+	defer func() { tracing.End(span, err) }()
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	if cursor != "" {
+		if filter.AfterID, err = decodeAuditCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	var next string
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next = encodeAuditCursor(entries[limit-1].ID)
+	}
+	return entries, next, nil
+}
+
+// Export последовательно передает в fn все записи по фильтру, не загружая журнал в память целиком
+func (s *AuditService) Export(ctx context.Context, filter model.AuditFilter, fn func(*model.AuditEntry) error) (err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.Export")
+	defer func() { tracing.End(span, err) }()
+
+	filter.Limit = auditExportBatch
+	for {
+		entries, err := s.repo.List(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to list audit events: %w", err)
+		}
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		if len(entries) < filter.Limit {
+			return nil
+		}
+		filter.AfterID = entries[len(entries)-1].ID
+	}
+}
+
+func encodeAuditCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeAuditCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, apperrors.ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, apperrors.ErrInvalidCursor
+	}
+	return id, nil
+}
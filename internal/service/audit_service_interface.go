@@ -0,0 +1,12 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+)
+
+type AuditServiceInterface interface {
+	List(ctx context.Context, filter model.AuditFilter, cursor string) ([]*model.AuditEntry, string, error)
+
+	Export(ctx context.Context, filter model.AuditFilter, fn func(*model.AuditEntry) error) error
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"errors"
+	"testing"
+)
+
+// mockAuditRepo отдает записи с ID от total до 1, как AuditRepo при сортировке по убыванию
+type mockAuditRepo struct {
+	total int64
+	calls []model.AuditFilter
+}
+
+func (m *mockAuditRepo) List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error) {
+	m.calls = append(m.calls, filter)
+
+	start := m.total
+	if filter.AfterID != 0 {
+		start = filter.AfterID - 1
+	}
+
+	var entries []*model.AuditEntry
+	for id := start; id > 0 && len(entries) < filter.Limit; id-- {
+		entries = append(entries, &model.AuditEntry{ID: id})
+	}
+	return entries, nil
+}
+
+func TestAuditService_List_Paginates(t *testing.T) {
+	service := NewAuditService(&mockAuditRepo{total: 5})
+
+	first, cursor, err := service.List(context.Background(), model.AuditFilter{Limit: 2}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(first) != 2 || first[0].ID != 5 || cursor == "" {
+		t.Fatalf("unexpected first page: %d entries, cursor %q", len(first), cursor)
+	}
+
+	second, _, err := service.List(context.Background(), model.AuditFilter{Limit: 2}, cursor)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(second) != 2 || second[0].ID != 3 {
+		t.Fatalf("expected second page to start at 3, got %+v", second)
+	}
+
+	last, next, err := service.List(context.Background(), model.AuditFilter{Limit: 10}, "")
+	if err != nil || len(last) != 5 || next != "" {
+		t.Errorf("expected single full page without cursor, got %d entries, cursor %q, err %v", len(last), next, err)
+	}
+}
+
+func TestAuditService_List_InvalidCursor(t *testing.T) {
+	service := NewAuditService(&mockAuditRepo{})
+
+	_, _, err := service.List(context.Background(), model.AuditFilter{}, "not a cursor!")
+	if !errors.Is(err, apperrors.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestAuditService_Export_ReadsAllPages(t *testing.T) {
+	repo := &mockAuditRepo{total: auditExportBatch + 10}
+	service := NewAuditService(repo)
+
+	count := 0
+	err := service.Export(context.Background(), model.AuditFilter{}, func(e *model.AuditEntry) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != auditExportBatch+10 || len(repo.calls) != 2 {
+		t.Errorf("expected %d entries in 2 pages, got %d in %d", auditExportBatch+10, count, len(repo.calls))
+	}
+}
@@ -2,70 +2,389 @@ package service
 
 import (
 	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/logger"
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
 	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/spam"
+	"advanced-blog-management-system/internal/tracing"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// ModerationConfig задает, какие новые комментарии проходят модерацию
+type ModerationConfig struct {
+	// Mode - model.ModerationAll отправляет на модерацию все комментарии; при model.ModerationOff
+	// модерируются только комментарии постов с включенной модерацией
+	Mode string
+	// TrustedAfter - число одобренных комментариев, после которого комментарии автора
+	// публикуются без модерации; 0 отключает автоодобрение
+	TrustedAfter int
+}
+
 type CommentService struct {
 	repo       repository.CommentRepository
 	postRepo   repository.PostRepository
+	userRepo   repository.UserRepository
+	tx         repository.TxManager
+	events     EventPublisher
+	stream     Broadcaster
+	moderation ModerationConfig
+	filter     ContentFilter
 }
 
-func NewCommentService(repo repository.CommentRepository, postRepo repository.PostRepository) *CommentService {
+func NewCommentService(repo repository.CommentRepository, postRepo repository.PostRepository, userRepo repository.UserRepository, tx repository.TxManager, events EventPublisher, stream Broadcaster, moderation ModerationConfig, filter ContentFilter) *CommentService {
 	return &CommentService{
-		repo:     repo,
-		postRepo: postRepo,
+		repo:       repo,
+		postRepo:   postRepo,
+		userRepo:   userRepo,
+		tx:         tx,
+		events:     events,
+		stream:     stream,
+		moderation: moderation,
+		filter:     filter,
 	}
 }
 
-func (s *CommentService) Create(ctx context.Context, userID, postID int, content string) (*model.Comment, error) {
+// Create добавляет комментарий к посту; format - формат текста (plain, если пуст).
+// Если комментарий должен пройти модерацию, он создается в статусе pending и виден только
+// автору и модераторам до одобрения. Подозрительный по мнению фильтра спама комментарий
+// отправляется на модерацию в любом режиме, явный спам отклоняется с ErrContentRejected
+func (s *CommentService) Create(ctx context.Context, userID, postID int, content, format string) (_ *model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.Create")
+	defer func() { tracing.End(span, err) }()
+
 	if postID <= 0 {
 		return nil, apperrors.ErrInvalidPostID
 	}
 
-	exists, err := s.postRepo.Exists(ctx, postID)
+	post, err := s.postRepo.GetByID(ctx, postID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check post existence: %w", err)
+		return nil, err
 	}
-	if !exists {
+	// Пост, ожидающий модерации, скрыт от читателей, поэтому комментировать его нельзя
+	if post.Status == model.PostPending {
 		return nil, apperrors.ErrPostNotFound
 	}
 
-	content = strings.TrimSpace(content)
-	if content == "" {
-		return nil, fmt.Errorf("content cannot be empty")
+	content, err = normalizeCommentContent(content)
+	if err != nil {
+		return nil, err
 	}
-	if len(content) > 1000 {
-		return nil, fmt.Errorf("content exceeds 1000 characters")
+	format, html, err := renderContent(format, content)
+	if err != nil {
+		return nil, err
 	}
 
 	comment := &model.Comment{
-		PostID:   postID,
-		AuthorID: userID,
-		Content:  content,
+		PostID:        postID,
+		AuthorID:      userID,
+		Content:       content,
+		ContentFormat: format,
+		ContentHTML:   html,
+	}
+	verdict, err := checkContent(ctx, s.filter, spam.KindComment, userID, content)
+	if err != nil {
+		return nil, err
+	}
+	if verdict.Action == spam.Moderate {
+		comment.Status = model.CommentPending
+	} else if comment.Status, err = s.moderationStatus(ctx, userID, post); err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Create(ctx, comment); err != nil {
-		return nil, fmt.Errorf("failed to create comment: %w", err)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, comment); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		if verdict.Action == spam.Moderate {
+			target := model.EventTarget{Type: "comment", ID: comment.ID}
+			if err := s.events.Publish(ctx, flaggedEvent(userID, target, verdict)); err != nil {
+				return err
+			}
+		}
+		if err := s.events.Publish(ctx, model.NewCommentCreatedEvent(comment)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, comment, model.EventCommentCreated)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	logger.FromContext(ctx).Debug("comment created", "comment_id", comment.ID, "post_id", postID, "status", comment.Status)
+
 	return comment, nil
 }
 
-func (s *CommentService) GetByPost(ctx context.Context, postID, limit, offset int) ([]*model.Comment, int, error) {
-	if postID <= 0 {
-		return nil, 0, apperrors.ErrInvalidPostID
+// moderationStatus выбирает статус нового комментария userID к посту post. Без модерации
+// публикуются комментарии автора поста, модераторов и пользователей с достаточным числом
+// одобренных комментариев
+func (s *CommentService) moderationStatus(ctx context.Context, userID int, post *model.Post) (string, error) {
+	if s.moderation.Mode != model.ModerationAll && !post.ModerateComments {
+		return model.CommentApproved, nil
+	}
+	if post.AuthorID == userID {
+		return model.CommentApproved, nil
+	}
+
+	moderator, err := isEditor(ctx, s.userRepo, userID)
+	if err != nil {
+		return "", err
+	}
+	if moderator {
+		return model.CommentApproved, nil
+	}
+
+	if s.moderation.TrustedAfter > 0 {
+		approved, err := s.repo.CountByAuthorStatus(ctx, userID, model.CommentApproved)
+		if err != nil {
+			return "", fmt.Errorf("failed to count approved comments: %w", err)
+		}
+		if approved >= s.moderation.TrustedAfter {
+			return model.CommentApproved, nil
+		}
+	}
+
+	return model.CommentPending, nil
+}
+
+// broadcast рассылает изменение подписчикам поста. Неодобренные комментарии в поток не попадают
+func (s *CommentService) broadcast(ctx context.Context, comment *model.Comment, eventType model.EventType) error {
+	if comment.Status != model.CommentApproved {
+		return nil
+	}
+	return broadcast(ctx, s.stream, CommentsTopic(comment.PostID), eventType, comment)
+}
+
+// GetForUpdate возвращает комментарий поста его автору, например чтобы сверить If-Match
+// перед правкой. Править комментарий может только автор, поэтому остальным он не отдается
+func (s *CommentService) GetForUpdate(ctx context.Context, userID, postID, commentID int) (_ *model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetForUpdate")
+	defer func() { tracing.End(span, err) }()
+
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.PostID != postID {
+		return nil, apperrors.ErrCommentNotFound
+	}
+	if comment.AuthorID != userID {
+		return nil, apperrors.ErrForbidden
+	}
+	return comment, nil
+}
+
+// Update меняет текст комментария поста; редактировать может только автор.
+// Пустой format сохраняет прежний формат комментария. version - версия комментария, которую
+// видел клиент; если она устарела, возвращается ConflictError с актуальной копией.
+// Новый текст проверяется фильтром спама: подозрительная правка возвращает комментарий
+// на модерацию, как и правка отклоненного комментария, который модератор должен пересмотреть
+func (s *CommentService) Update(ctx context.Context, userID, postID, commentID int, content, format string, version int) (_ *model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	content, err = normalizeCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+	if version <= 0 {
+		return nil, apperrors.FieldErrors{"version": "expected positive version"}
+	}
+
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.PostID != postID {
+		return nil, apperrors.ErrCommentNotFound
+	}
+	if comment.AuthorID != userID {
+		return nil, apperrors.ErrForbidden
+	}
+	if comment.Version != version {
+		return nil, s.conflict(ctx, comment.ID)
+	}
+
+	if format == "" {
+		format = comment.ContentFormat
+	}
+	format, html, err := renderContent(format, content)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict, err := checkContent(ctx, s.filter, spam.KindComment, userID, content)
+	if err != nil {
+		return nil, err
+	}
+
+	wasApproved := comment.Status == model.CommentApproved
+	comment.Content = content
+	comment.ContentFormat = format
+	comment.ContentHTML = html
+	if verdict.Action == spam.Moderate || comment.Status == model.CommentRejected {
+		comment.Status = model.CommentPending
+		comment.ModerationReason = ""
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, comment); err != nil {
+			return err
+		}
+		if verdict.Action == spam.Moderate {
+			target := model.EventTarget{Type: "comment", ID: comment.ID}
+			if err := s.events.Publish(ctx, flaggedEvent(userID, target, verdict)); err != nil {
+				return err
+			}
+		}
+		if err := s.events.Publish(ctx, model.NewCommentUpdatedEvent(userID, comment)); err != nil {
+			return err
+		}
+		// Для подписчиков поста комментарий, вернувшийся на модерацию, удален
+		if wasApproved && comment.Status != model.CommentApproved {
+			return broadcast(ctx, s.stream, CommentsTopic(comment.PostID), model.EventCommentDeleted, comment)
+		}
+		return s.broadcast(ctx, comment, model.EventCommentUpdated)
+	})
+	if errors.Is(err, apperrors.ErrConflict) {
+		return nil, s.conflict(ctx, comment.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Debug("comment updated", "comment_id", comment.ID, "post_id", comment.PostID, "status", comment.Status)
+
+	return comment, nil
+}
+
+// conflict возвращает ConflictError с актуальной копией комментария, прочитанной мимо кеша
+func (s *CommentService) conflict(ctx context.Context, commentID int) error {
+	var current *model.Comment
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = s.repo.GetByID(ctx, commentID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return &apperrors.ConflictError{Current: current}
+}
+
+// Delete переносит комментарий поста в корзину; удалить может только автор
+func (s *CommentService) Delete(ctx context.Context, userID, postID, commentID int) (err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
 	}
+	if comment.PostID != postID {
+		return apperrors.ErrCommentNotFound
+	}
+	if comment.AuthorID != userID {
+		return apperrors.ErrForbidden
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, comment.ID, time.Now()); err != nil {
+			return err
+		}
+		if err := s.events.Publish(ctx, model.NewCommentDeletedEvent(userID, comment)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, comment, model.EventCommentDeleted)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Debug("comment deleted", "comment_id", comment.ID, "post_id", comment.PostID)
+
+	return nil
+}
+
+// Restore возвращает комментарий из корзины; восстановить может только автор.
+// Комментарий удаленного поста восстанавливается только вместе с постом
+func (s *CommentService) Restore(ctx context.Context, userID, commentID int) (_ *model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.Restore")
+	defer func() { tracing.End(span, err) }()
 
-	exists, err := s.postRepo.Exists(ctx, postID)
+	comment, err := s.repo.GetDeletedByID(ctx, commentID)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to check post existence: %w", err)
+		return nil, err
+	}
+	if comment.AuthorID != userID {
+		return nil, apperrors.ErrForbidden
+	}
+
+	exists, err := s.postRepo.Exists(ctx, comment.PostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post existence: %w", err)
 	}
 	if !exists {
-		return nil, 0, apperrors.ErrPostNotFound
+		return nil, apperrors.ErrParentDeleted
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, comment.ID); err != nil {
+			return err
+		}
+		comment.DeletedAt = nil
+		if err := s.events.Publish(ctx, model.NewCommentRestoredEvent(userID, comment)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, comment, model.EventCommentRestored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Debug("comment restored", "comment_id", comment.ID, "post_id", comment.PostID)
+
+	return comment, nil
+}
+
+// ListDeleted возвращает комментарии пользователя из корзины, от недавно удаленных к старым
+func (s *CommentService) ListDeleted(ctx context.Context, userID, limit, offset int) (_ []*model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.ListDeleted")
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	comments, err := s.repo.GetDeleted(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted comments: %w", err)
+	}
+	return comments, nil
+}
+
+// GetByPost возвращает комментарии поста; обсуждение поста на модерации видно тем же
+// пользователям, что и сам пост
+func (s *CommentService) GetByPost(ctx context.Context, postID, requestorID, limit, offset int) (_ []*model.Comment, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetByPost")
+	defer func() { tracing.End(span, err) }()
+
+	if postID <= 0 {
+		return nil, 0, apperrors.ErrInvalidPostID
+	}
+
+	if _, err := visiblePost(ctx, s.postRepo, s.userRepo, postID, requestorID); err != nil {
+		return nil, 0, err
 	}
 
 	if limit < 1 {
@@ -91,3 +410,62 @@ func (s *CommentService) GetByPost(ctx context.Context, postID, limit, offset in
 
 	return comments, total, nil
 }
+
+// ListPage возвращает страницу комментариев поста по позиции, от старых к новым
+func (s *CommentService) ListPage(ctx context.Context, postID, requestorID int, page model.PageRequest) (_ []*model.Comment, _ model.PageInfo, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.ListPage")
+	defer func() { tracing.End(span, err) }()
+
+	if postID <= 0 {
+		return nil, model.PageInfo{}, apperrors.ErrInvalidPostID
+	}
+
+	if _, err := visiblePost(ctx, s.postRepo, s.userRepo, postID, requestorID); err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	page, limit := normalizePage(page, 10, 100)
+	comments, err := s.repo.GetPageByPostID(ctx, postID, page)
+	if err != nil {
+		return nil, model.PageInfo{}, fmt.Errorf("failed to get comments page: %w", err)
+	}
+	comments, info := cutPage(comments, page, limit, commentKeyset)
+
+	if page.IncludeTotal {
+		total, err := s.repo.GetCountByPostID(ctx, postID)
+		if err != nil {
+			return nil, model.PageInfo{}, fmt.Errorf("failed to get total count: %w", err)
+		}
+		info.Total = &total
+	}
+
+	return comments, info, nil
+}
+
+// StreamAfter возвращает события потока комментариев поста с номером больше afterID в порядке номеров
+func (s *CommentService) StreamAfter(ctx context.Context, postID, requestorID int, afterID int64, limit int) (_ []pubsub.Message, err error) {
+	ctx, span := tracing.Start(ctx, "CommentService.StreamAfter")
+	defer func() { tracing.End(span, err) }()
+
+	if postID <= 0 {
+		return nil, apperrors.ErrInvalidPostID
+	}
+
+	if _, err := visiblePost(ctx, s.postRepo, s.userRepo, postID, requestorID); err != nil {
+		return nil, err
+	}
+
+	return streamAfter(ctx, s.stream, CommentsTopic(postID), afterID, limit)
+}
+
+// normalizeCommentContent обрезает пробелы и проверяет длину текста комментария
+func normalizeCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("content cannot be empty")
+	}
+	if len(content) > 1000 {
+		return "", fmt.Errorf("content exceeds 1000 characters")
+	}
+	return content, nil
+}
@@ -2,11 +2,26 @@ package service
 
 import (
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
 	"context"
 )
 
 type CommentServiceInterface interface {
-	Create(ctx context.Context, userID, postID int, content string) (*model.Comment, error)
+	Create(ctx context.Context, userID, postID int, content, format string) (*model.Comment, error)
 
-	GetByPost(ctx context.Context, postID, limit, offset int) ([]*model.Comment, int, error)
+	GetForUpdate(ctx context.Context, userID, postID, commentID int) (*model.Comment, error)
+
+	Update(ctx context.Context, userID, postID, commentID int, content, format string, version int) (*model.Comment, error)
+
+	Delete(ctx context.Context, userID, postID, commentID int) error
+
+	Restore(ctx context.Context, userID, commentID int) (*model.Comment, error)
+
+	ListDeleted(ctx context.Context, userID, limit, offset int) ([]*model.Comment, error)
+
+	GetByPost(ctx context.Context, postID, requestorID, limit, offset int) ([]*model.Comment, int, error)
+
+	ListPage(ctx context.Context, postID, requestorID int, page model.PageRequest) ([]*model.Comment, model.PageInfo, error)
+
+	StreamAfter(ctx context.Context, postID, requestorID int, afterID int64, limit int) ([]pubsub.Message, error)
 }
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"advanced-blog-management-system/internal/errors/apperrors"
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/spam"
 
 	"context"
 	"errors"
@@ -13,8 +15,19 @@ import (
 type mockCommentRepo struct {
 	createFunc             func(ctx context.Context, comment *model.Comment) error
 	getByIDFunc            func(ctx context.Context, id int) (*model.Comment, error)
+	updateFunc             func(ctx context.Context, comment *model.Comment) error
+	deleteFunc             func(ctx context.Context, id int, deletedAt time.Time) error
+	restoreFunc            func(ctx context.Context, id int) error
+	deleteByAuthorFunc     func(ctx context.Context, authorID int, deletedAt time.Time) error
+	restoreByAuthorFunc    func(ctx context.Context, authorID int, deletedAt time.Time) error
+	getDeletedFunc         func(ctx context.Context, authorID int, limit, offset int) ([]*model.Comment, error)
+	getDeletedByIDFunc     func(ctx context.Context, id int) (*model.Comment, error)
 	getByPostIDFunc        func(ctx context.Context, postID int, limit, offset int) ([]*model.Comment, error)
 	getCountByPostIDFunc   func(ctx context.Context, postID int) (int, error)
+	getPageFunc            func(ctx context.Context, postID int, page model.PageRequest) ([]*model.Comment, error)
+	setStatusFunc          func(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error)
+	countByAuthorStatusFunc func(ctx context.Context, authorID int, status string) (int, error)
+	getModerationFunc      func(ctx context.Context, filter model.ModerationFilter) ([]*model.Comment, error)
 }
 
 func (m *mockCommentRepo) Create(ctx context.Context, comment *model.Comment) error {
@@ -31,6 +44,55 @@ func (m *mockCommentRepo) GetByID(ctx context.Context, id int) (*model.Comment,
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockCommentRepo) Update(ctx context.Context, comment *model.Comment) error {
+	if m.updateFunc != nil {
+		return m.updateFunc(ctx, comment)
+	}
+	return nil
+}
+
+func (m *mockCommentRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, id, deletedAt)
+	}
+	return nil
+}
+
+func (m *mockCommentRepo) Restore(ctx context.Context, id int) error {
+	if m.restoreFunc != nil {
+		return m.restoreFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *mockCommentRepo) DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if m.deleteByAuthorFunc != nil {
+		return m.deleteByAuthorFunc(ctx, authorID, deletedAt)
+	}
+	return nil
+}
+
+func (m *mockCommentRepo) RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if m.restoreByAuthorFunc != nil {
+		return m.restoreByAuthorFunc(ctx, authorID, deletedAt)
+	}
+	return nil
+}
+
+func (m *mockCommentRepo) GetDeleted(ctx context.Context, authorID int, limit, offset int) ([]*model.Comment, error) {
+	if m.getDeletedFunc != nil {
+		return m.getDeletedFunc(ctx, authorID, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *mockCommentRepo) GetDeletedByID(ctx context.Context, id int) (*model.Comment, error) {
+	if m.getDeletedByIDFunc != nil {
+		return m.getDeletedByIDFunc(ctx, id)
+	}
+	return nil, apperrors.ErrCommentNotFound
+}
+
 func (m *mockCommentRepo) GetByPostID(ctx context.Context, postID int, limit, offset int) ([]*model.Comment, error) {
 	if m.getByPostIDFunc != nil {
 		return m.getByPostIDFunc(ctx, postID, limit, offset)
@@ -45,6 +107,34 @@ func (m *mockCommentRepo) GetCountByPostID(ctx context.Context, postID int) (int
 	return 0, nil
 }
 
+func (m *mockCommentRepo) GetPageByPostID(ctx context.Context, postID int, page model.PageRequest) ([]*model.Comment, error) {
+	if m.getPageFunc != nil {
+		return m.getPageFunc(ctx, postID, page)
+	}
+	return nil, nil
+}
+
+func (m *mockCommentRepo) SetStatus(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error) {
+	if m.setStatusFunc != nil {
+		return m.setStatusFunc(ctx, ids, status, reason)
+	}
+	return nil, nil
+}
+
+func (m *mockCommentRepo) CountByAuthorStatus(ctx context.Context, authorID int, status string) (int, error) {
+	if m.countByAuthorStatusFunc != nil {
+		return m.countByAuthorStatusFunc(ctx, authorID, status)
+	}
+	return 0, nil
+}
+
+func (m *mockCommentRepo) GetModeration(ctx context.Context, filter model.ModerationFilter) ([]*model.Comment, error) {
+	if m.getModerationFunc != nil {
+		return m.getModerationFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
 func TestCommentService_Create_Success(t *testing.T) {
 	mockCommentRepo := &mockCommentRepo{
 		createFunc: func(ctx context.Context, comment *model.Comment) error {
@@ -55,14 +145,14 @@ func TestCommentService_Create_Success(t *testing.T) {
 		},
 	}
 	mockPostRepo := &mockPostRepo{
-		existsFunc: func(ctx context.Context, id int) (bool, error) {
-			return true, nil
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
 		},
 	}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	result, err := service.Create(context.Background(), 1, 1, "Test comment content")
+	result, err := service.Create(context.Background(), 1, 1, "Test comment content", "")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -80,9 +170,9 @@ func TestCommentService_Create_InvalidPostID(t *testing.T) {
 	mockCommentRepo := &mockCommentRepo{}
 	mockPostRepo := &mockPostRepo{}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	_, err := service.Create(context.Background(), 1, 0, "Test comment")
+	_, err := service.Create(context.Background(), 1, 0, "Test comment", "")
 	if err == nil {
 		t.Fatal("expected error for invalid post ID, got nil")
 	}
@@ -91,14 +181,14 @@ func TestCommentService_Create_InvalidPostID(t *testing.T) {
 func TestCommentService_Create_PostNotFound(t *testing.T) {
 	mockCommentRepo := &mockCommentRepo{}
 	mockPostRepo := &mockPostRepo{
-		existsFunc: func(ctx context.Context, id int) (bool, error) {
-			return false, nil
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return nil, apperrors.ErrPostNotFound
 		},
 	}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	_, err := service.Create(context.Background(), 1, 1, "Test comment")
+	_, err := service.Create(context.Background(), 1, 1, "Test comment", "")
 	if err == nil {
 		t.Fatal("expected error for post not found, got nil")
 	}
@@ -107,14 +197,14 @@ func TestCommentService_Create_PostNotFound(t *testing.T) {
 func TestCommentService_Create_EmptyContent(t *testing.T) {
 	mockCommentRepo := &mockCommentRepo{}
 	mockPostRepo := &mockPostRepo{
-		existsFunc: func(ctx context.Context, id int) (bool, error) {
-			return true, nil
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
 		},
 	}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	_, err := service.Create(context.Background(), 1, 1, "   ")
+	_, err := service.Create(context.Background(), 1, 1, "   ", "")
 	if err == nil {
 		t.Fatal("expected error for empty content, got nil")
 	}
@@ -123,15 +213,15 @@ func TestCommentService_Create_EmptyContent(t *testing.T) {
 func TestCommentService_Create_ContentTooLong(t *testing.T) {
 	mockCommentRepo := &mockCommentRepo{}
 	mockPostRepo := &mockPostRepo{
-		existsFunc: func(ctx context.Context, id int) (bool, error) {
-			return true, nil
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
 		},
 	}
 
 	longContent := string(make([]byte, 1001))
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	_, err := service.Create(context.Background(), 1, 1, longContent)
+	_, err := service.Create(context.Background(), 1, 1, longContent, "")
 	if err == nil {
 		t.Fatal("expected error for content too long, got nil")
 	}
@@ -151,14 +241,14 @@ func TestCommentService_GetByPost_Success(t *testing.T) {
 		},
 	}
 	mockPostRepo := &mockPostRepo{
-		existsFunc: func(ctx context.Context, id int) (bool, error) {
-			return true, nil
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
 		},
 	}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	comments, total, err := service.GetByPost(context.Background(), 1, 10, 0)
+	comments, total, err := service.GetByPost(context.Background(), 1, 0, 10, 0)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -176,9 +266,9 @@ func TestCommentService_GetByPost_InvalidPostID(t *testing.T) {
 	mockCommentRepo := &mockCommentRepo{}
 	mockPostRepo := &mockPostRepo{}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	_, _, err := service.GetByPost(context.Background(), 0, 10, 0)
+	_, _, err := service.GetByPost(context.Background(), 0, 0, 10, 0)
 	if err == nil {
 		t.Fatal("expected error for invalid post ID, got nil")
 	}
@@ -187,14 +277,14 @@ func TestCommentService_GetByPost_InvalidPostID(t *testing.T) {
 func TestCommentService_GetByPost_PostNotFound(t *testing.T) {
 	mockCommentRepo := &mockCommentRepo{}
 	mockPostRepo := &mockPostRepo{
-		existsFunc: func(ctx context.Context, id int) (bool, error) {
-			return false, nil
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return nil, apperrors.ErrPostNotFound
 		},
 	}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
-	_, _, err := service.GetByPost(context.Background(), 1, 10, 0)
+	_, _, err := service.GetByPost(context.Background(), 1, 0, 10, 0)
 	if err == nil {
 		t.Fatal("expected error for post not found, got nil")
 	}
@@ -217,22 +307,301 @@ func TestCommentService_GetByPost_LimitBounds(t *testing.T) {
 		},
 	}
 	mockPostRepo := &mockPostRepo{
-		existsFunc: func(ctx context.Context, id int) (bool, error) {
-			return true, nil
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
 		},
 	}
 
-	service := NewCommentService(mockCommentRepo, mockPostRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
 
 	// Test with limit < 1
-	_, _, err := service.GetByPost(context.Background(), 1, 0, -1)
+	_, _, err := service.GetByPost(context.Background(), 1, 0, 0, -1)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
 	// Test with limit > 100
-	_, _, err = service.GetByPost(context.Background(), 1, 200, 10)
+	_, _, err = service.GetByPost(context.Background(), 1, 0, 200, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCommentService_Update_OnlyAuthor(t *testing.T) {
+	mockCommentRepo := &mockCommentRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Comment, error) {
+			return &model.Comment{ID: id, PostID: 3, AuthorID: 1, Content: "old", Version: 1, Status: model.CommentApproved}, nil
+		},
+	}
+	stream := &mockBroadcaster{}
+	service := NewCommentService(mockCommentRepo, &mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, stream, ModerationConfig{}, &mockContentFilter{})
+
+	if _, err := service.Update(context.Background(), 2, 3, 5, "hijacked", "", 1); !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for non-author, got %v", err)
+	}
+
+	if _, err := service.Update(context.Background(), 1, 4, 5, "wrong post", "", 1); !errors.Is(err, apperrors.ErrCommentNotFound) {
+		t.Errorf("expected ErrCommentNotFound for comment of another post, got %v", err)
+	}
+
+	comment, err := service.Update(context.Background(), 1, 3, 5, "  edited  ", "", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if comment.Content != "edited" {
+		t.Errorf("expected trimmed content, got %q", comment.Content)
+	}
+	if messages := stream.messages[CommentsTopic(3)]; len(messages) != 1 || messages[0].Event != string(model.EventCommentUpdated) {
+		t.Errorf("expected comment.updated broadcast, got %v", stream.messages)
+	}
+}
+
+func TestCommentService_Delete_PublishesEvent(t *testing.T) {
+	var deletedID int
+	mockCommentRepo := &mockCommentRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Comment, error) {
+			return &model.Comment{ID: id, PostID: 3, AuthorID: 1}, nil
+		},
+		deleteFunc: func(ctx context.Context, id int, deletedAt time.Time) error {
+			deletedID = id
+			return nil
+		},
+	}
+	publisher := &mockEventPublisher{}
+	service := NewCommentService(mockCommentRepo, &mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, publisher, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+
+	if err := service.Delete(context.Background(), 1, 3, 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deletedID != 5 {
+		t.Errorf("expected comment 5 to be deleted, got %d", deletedID)
+	}
+	if types := publisher.types(); len(types) != 1 || types[0] != model.EventCommentDeleted {
+		t.Errorf("expected comment.deleted event, got %v", types)
+	}
+}
+
+func TestCommentService_Restore_PostDeleted(t *testing.T) {
+	restored := false
+	mockCommentRepo := &mockCommentRepo{
+		getDeletedByIDFunc: func(ctx context.Context, id int) (*model.Comment, error) {
+			return &model.Comment{ID: id, PostID: 3, AuthorID: 1}, nil
+		},
+		restoreFunc: func(ctx context.Context, id int) error {
+			restored = true
+			return nil
+		},
+	}
+	mockPostRepo := &mockPostRepo{
+		existsFunc: func(ctx context.Context, id int) (bool, error) {
+			return false, nil
+		},
+	}
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+
+	_, err := service.Restore(context.Background(), 1, 5)
+	if !errors.Is(err, apperrors.ErrParentDeleted) {
+		t.Fatalf("expected ErrParentDeleted, got %v", err)
+	}
+	if restored {
+		t.Error("comment must not be restored while its post is deleted")
+	}
+}
+
+func TestCommentService_Restore_OnlyAuthor(t *testing.T) {
+	mockCommentRepo := &mockCommentRepo{
+		getDeletedByIDFunc: func(ctx context.Context, id int) (*model.Comment, error) {
+			return &model.Comment{ID: id, PostID: 3, AuthorID: 1}, nil
+		},
+	}
+	service := NewCommentService(mockCommentRepo, &mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+
+	if _, err := service.Restore(context.Background(), 2, 5); !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestCommentService_Update_StaleVersion(t *testing.T) {
+	mockCommentRepo := &mockCommentRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Comment, error) {
+			return &model.Comment{ID: id, PostID: 3, AuthorID: 1, Content: "old", Version: 2}, nil
+		},
+	}
+	service := NewCommentService(mockCommentRepo, &mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+
+	_, err := service.Update(context.Background(), 1, 3, 5, "edited", "", 1)
+
+	var conflict *apperrors.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if current, ok := conflict.Current.(*model.Comment); !ok || current.Version != 2 {
+		t.Errorf("expected current copy at version 2, got %+v", conflict.Current)
+	}
+
+	var fields apperrors.FieldErrors
+	if _, err := service.Update(context.Background(), 1, 3, 5, "edited", "", 0); !errors.As(err, &fields) {
+		t.Errorf("expected field error for missing version, got %v", err)
+	}
+}
+
+func TestCommentService_Create_SpamFilter(t *testing.T) {
+	mockCommentRepo := &mockCommentRepo{
+		createFunc: func(ctx context.Context, comment *model.Comment) error {
+			comment.ID = 8
+			return nil
+		},
+	}
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
+		},
+	}
+	filter := &mockContentFilter{verdict: spam.Verdict{Action: spam.Moderate, Score: 5, Hits: []spam.Hit{{Rule: "banned_words", Score: 5}}}}
+	publisher := &mockEventPublisher{}
+	stream := &mockBroadcaster{}
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, stream, ModerationConfig{Mode: model.ModerationOff}, filter)
+
+	comment, err := service.Create(context.Background(), 2, 4, "Online casino", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if comment.Status != model.CommentPending {
+		t.Errorf("expected suspicious comment to be held for moderation, got %s", comment.Status)
+	}
+	if types := publisher.types(); len(types) != 2 || types[0] != model.EventContentFlagged {
+		t.Errorf("expected content.flagged event, got %v", types)
+	}
+	if len(stream.messages) != 0 {
+		t.Errorf("expected no broadcast for pending comment, got %v", stream.messages)
+	}
+
+	filter.verdict = spam.Verdict{Action: spam.Reject, Score: 10}
+	if _, err := service.Create(context.Background(), 2, 4, "Online casino, cheap viagra", ""); !errors.Is(err, apperrors.ErrContentRejected) {
+		t.Errorf("expected ErrContentRejected, got %v", err)
+	}
+}
+
+func TestCommentService_Create_PendingPost(t *testing.T) {
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1, Status: model.PostPending}, nil
+		},
+	}
+	mockCommentRepo := &mockCommentRepo{
+		createFunc: func(ctx context.Context, comment *model.Comment) error {
+			t.Fatal("comment must not be saved on a pending post")
+			return nil
+		},
+	}
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+
+	if _, err := service.Create(context.Background(), 2, 4, "Nice post", ""); !errors.Is(err, apperrors.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestCommentService_ReadsOfPendingPost(t *testing.T) {
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1, Status: model.PostPending}, nil
+		},
+	}
+	users := usersWithRoles(map[int]string{1: model.RoleUser, 2: model.RoleUser, 3: model.RoleEditor})
+	service := NewCommentService(&mockCommentRepo{}, mockPostRepo, users, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+	ctx := context.Background()
+
+	// The discussion of a held post is hidden together with the post
+	for _, requestorID := range []int{0, 2} {
+		if _, _, err := service.GetByPost(ctx, 4, requestorID, 10, 0); !errors.Is(err, apperrors.ErrPostNotFound) {
+			t.Errorf("expected comments to be hidden from user %d, got %v", requestorID, err)
+		}
+		if _, _, err := service.ListPage(ctx, 4, requestorID, model.PageRequest{Limit: 10}); !errors.Is(err, apperrors.ErrPostNotFound) {
+			t.Errorf("expected comments page to be hidden from user %d, got %v", requestorID, err)
+		}
+		if _, err := service.StreamAfter(ctx, 4, requestorID, 0, 100); !errors.Is(err, apperrors.ErrPostNotFound) {
+			t.Errorf("expected comment stream to be hidden from user %d, got %v", requestorID, err)
+		}
+	}
+	for _, requestorID := range []int{1, 3} {
+		if _, _, err := service.ListPage(ctx, 4, requestorID, model.PageRequest{Limit: 10}); err != nil {
+			t.Errorf("expected comments page to be visible to user %d, got %v", requestorID, err)
+		}
+		if _, err := service.StreamAfter(ctx, 4, requestorID, 0, 100); err != nil {
+			t.Errorf("expected comment stream to be visible to user %d, got %v", requestorID, err)
+		}
+	}
+}
+
+func TestCommentService_Update_SpamFilter(t *testing.T) {
+	var stored model.Comment
+	mockCommentRepo := &mockCommentRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Comment, error) {
+			c := stored
+			return &c, nil
+		},
+		updateFunc: func(ctx context.Context, comment *model.Comment) error {
+			stored = *comment
+			stored.Version++
+			return nil
+		},
+	}
+	filter := &mockContentFilter{verdict: spam.Verdict{Action: spam.Reject, Score: 10}}
+	publisher := &mockEventPublisher{}
+	stream := &mockBroadcaster{}
+	service := NewCommentService(mockCommentRepo, &mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, publisher, stream, ModerationConfig{}, filter)
+
+	stored = model.Comment{ID: 5, PostID: 3, AuthorID: 1, Version: 1, Status: model.CommentApproved}
+	if _, err := service.Update(context.Background(), 1, 3, 5, "Online casino, cheap viagra", "", 1); !errors.Is(err, apperrors.ErrContentRejected) {
+		t.Fatalf("expected ErrContentRejected, got %v", err)
+	}
+	if len(filter.inputs) != 1 || filter.inputs[0].Kind != spam.KindComment {
+		t.Errorf("expected edited text to be checked, got %+v", filter.inputs)
+	}
+
+	// A suspicious edit of a published comment sends it back to moderation
+	filter.verdict = spam.Verdict{Action: spam.Moderate, Score: 5, Hits: []spam.Hit{{Rule: "banned_words", Score: 5}}}
+	comment, err := service.Update(context.Background(), 1, 3, 5, "Online casino", "", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if comment.Status != model.CommentPending {
+		t.Errorf("expected comment to be pending after a suspicious edit, got %s", comment.Status)
+	}
+	if types := publisher.types(); len(types) != 2 || types[0] != model.EventContentFlagged || types[1] != model.EventCommentUpdated {
+		t.Errorf("expected content.flagged and comment.updated events, got %v", types)
+	}
+	if messages := stream.messages[CommentsTopic(3)]; len(messages) != 1 || messages[0].Event != string(model.EventCommentDeleted) {
+		t.Errorf("expected the comment to be removed from the stream, got %v", stream.messages)
+	}
+
+	// Editing a rejected comment puts it back in the queue for another review
+	filter.verdict = spam.Verdict{}
+	stored.Status, stored.ModerationReason = model.CommentRejected, "off-topic"
+	comment, err = service.Update(context.Background(), 1, 3, 5, "On-topic now", "", stored.Version)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
+	if comment.Status != model.CommentPending || comment.ModerationReason != "" {
+		t.Errorf("expected rejected comment to return to pending, got %+v", comment)
+	}
+}
+
+func TestCommentService_GetForUpdate_OnlyAuthor(t *testing.T) {
+	mockCommentRepo := &mockCommentRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Comment, error) {
+			return &model.Comment{ID: id, PostID: 1, AuthorID: 2, Version: 3}, nil
+		},
+	}
+	service := NewCommentService(mockCommentRepo, &mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+
+	if c, err := service.GetForUpdate(context.Background(), 2, 1, 7); err != nil || c.Version != 3 {
+		t.Errorf("expected author to get comment at version 3, got %+v, %v", c, err)
+	}
+	if _, err := service.GetForUpdate(context.Background(), 3, 1, 7); !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for another user, got %v", err)
+	}
+	if _, err := service.GetForUpdate(context.Background(), 2, 5, 7); !errors.Is(err, apperrors.ErrCommentNotFound) {
+		t.Errorf("expected ErrCommentNotFound for another post, got %v", err)
+	}
 }
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/render"
+	"advanced-blog-management-system/internal/spam"
+	"context"
+	"fmt"
+)
+
+// ContentFilter проверяет новые публикации на спам; реализуется spam.Filter
+type ContentFilter interface {
+	Check(ctx context.Context, in spam.Input) (*spam.Verdict, error)
+}
+
+// checkContent прогоняет текст через фильтр спама. Отклоненный текст возвращает
+// ErrContentRejected; решение Moderate обрабатывает вызывающий сервис
+func checkContent(ctx context.Context, filter ContentFilter, kind spam.Kind, authorID int, text string) (*spam.Verdict, error) {
+	verdict, err := filter.Check(ctx, spam.Input{Kind: kind, AuthorID: authorID, Text: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check content: %w", err)
+	}
+	if verdict.Action == spam.Reject {
+		return nil, apperrors.ErrContentRejected
+	}
+	return verdict, nil
+}
+
+// flaggedEvent - событие о публикации, которую фильтр спама счел подозрительной
+func flaggedEvent(authorID int, target model.EventTarget, verdict *spam.Verdict) model.Event {
+	rules := make([]string, 0, len(verdict.Hits))
+	for _, hit := range verdict.Hits {
+		rules = append(rules, hit.Rule)
+	}
+	return model.NewContentFlaggedEvent(authorID, target, string(verdict.Action), verdict.Score, rules)
+}
+
+// renderContent проверяет формат текста (пустой означает plain) и рендерит HTML при записи,
+// чтобы чтения не тратили время на Markdown и санитайзер
+func renderContent(format, content string) (string, string, error) {
+	if format == "" {
+		format = model.ContentFormatPlain
+	}
+	if !render.ValidFormat(format) {
+		return "", "", apperrors.FieldErrors{"content_format": "expected plain or markdown"}
+	}
+
+	html, err := render.HTML(format, content)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render content: %w", err)
+	}
+	return format, html, nil
+}
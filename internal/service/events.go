@@ -0,0 +1,22 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/model"
+	"context"
+)
+
+// EventPublisher публикует доменные события; реализуется repository.OutboxRepo.
+// Вызванный внутри TxManager.WithinTx, Publish становится частью транзакции,
+// поэтому событие сохраняется тогда и только тогда, когда сохранены данные
+type EventPublisher interface {
+	Publish(ctx context.Context, e model.Event) error
+}
+
+// publishBestEffort публикует событие, которое не сопровождает изменение данных (например, вход),
+// поэтому ошибка записи только логируется и не прерывает операцию
+func publishBestEffort(ctx context.Context, events EventPublisher, e model.Event) {
+	if err := events.Publish(ctx, e); err != nil {
+		logger.FromContext(ctx).Error("failed to publish event", "event_type", e.Type, "error", err)
+	}
+}
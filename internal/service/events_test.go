@@ -0,0 +1,284 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
+	"advanced-blog-management-system/internal/spam"
+	"advanced-blog-management-system/pkg/auth"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockEventPublisher is a mock implementation of EventPublisher that records published events
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []model.Event
+	err    error
+}
+
+func (m *mockEventPublisher) Publish(ctx context.Context, e model.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, e)
+	return nil
+}
+
+// mockBroadcaster is a mock implementation of Broadcaster that numbers stream messages per topic
+// the way the stream log does and records them by topic
+type mockBroadcaster struct {
+	mu       sync.Mutex
+	messages map[string][]pubsub.Message
+}
+
+func (m *mockBroadcaster) Broadcast(ctx context.Context, topic string, eventType model.EventType, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.messages == nil {
+		m.messages = make(map[string][]pubsub.Message)
+	}
+	id := int64(len(m.messages[topic]) + 1)
+	m.messages[topic] = append(m.messages[topic], pubsub.Message{ID: id, Event: string(eventType), Data: data})
+	return nil
+}
+
+func (m *mockBroadcaster) After(ctx context.Context, topic string, afterID int64, limit int) ([]pubsub.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var messages []pubsub.Message
+	for _, msg := range m.messages[topic] {
+		if msg.ID > afterID && len(messages) < limit {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// mockTxManager is a mock implementation of TxManager that runs fn without a transaction
+type mockTxManager struct {
+	calls int
+}
+
+func (m *mockTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	return fn(ctx)
+}
+
+// mockContentFilter is a mock implementation of ContentFilter; the zero value allows everything
+type mockContentFilter struct {
+	verdict spam.Verdict
+	inputs  []spam.Input
+}
+
+func (m *mockContentFilter) Check(ctx context.Context, in spam.Input) (*spam.Verdict, error) {
+	m.inputs = append(m.inputs, in)
+	verdict := m.verdict
+	if verdict.Action == "" {
+		verdict.Action = spam.Allow
+	}
+	return &verdict, nil
+}
+
+func (m *mockEventPublisher) types() []model.EventType {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	types := make([]model.EventType, 0, len(m.events))
+	for _, e := range m.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestPostService_Create_PublishesEvent(t *testing.T) {
+	mockPostRepo := &mockPostRepo{
+		createFunc: func(ctx context.Context, post *model.Post) error {
+			post.ID = 7
+			return nil
+		},
+	}
+	publisher := &mockEventPublisher{}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, &mockBroadcaster{}, &mockContentFilter{})
+
+	_, err := service.Create(context.Background(), 3, &model.PostCreateRequest{Title: "Title", Content: "Content"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(publisher.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(publisher.events))
+	}
+	e := publisher.events[0]
+	if e.Type != model.EventPostCreated || e.ActorID != 3 || e.Target.ID != 7 || e.Target.Type != "post" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestCommentService_Create_PublishesEvent(t *testing.T) {
+	mockCommentRepo := &mockCommentRepo{
+		createFunc: func(ctx context.Context, comment *model.Comment) error {
+			comment.ID = 5
+			return nil
+		},
+	}
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
+		},
+	}
+	publisher := &mockEventPublisher{}
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, &mockBroadcaster{}, ModerationConfig{}, &mockContentFilter{})
+
+	if _, err := service.Create(context.Background(), 2, 1, "Nice post", ""); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	types := publisher.types()
+	if len(types) != 1 || types[0] != model.EventCommentCreated {
+		t.Errorf("expected comment.created event, got %v", types)
+	}
+}
+
+func TestPostService_Create_NoEventOnFailure(t *testing.T) {
+	publisher := &mockEventPublisher{}
+	service := NewPostService(&mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, publisher, &mockBroadcaster{}, &mockContentFilter{})
+
+	_, err := service.Create(context.Background(), 1, &model.PostCreateRequest{Title: "", Content: "Content"})
+	if err == nil {
+		t.Fatal("expected validation error, got nil")
+	}
+
+	if len(publisher.events) != 0 {
+		t.Errorf("expected no events on failure, got %v", publisher.types())
+	}
+}
+
+func TestUserService_Login_PublishesFailedAndSuccessEvents(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("password123")
+	mockRepo := &mockUserRepo{
+		getByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			return &model.User{ID: 1, Username: "testuser", Email: email, Password: hashedPassword}, nil
+		},
+	}
+	publisher := &mockEventPublisher{}
+	service := NewUserService(mockRepo, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, auth.NewJWTManager("test-secret", 24), &mockTxManager{}, publisher)
+
+	_, _ = service.Login(context.Background(), &model.UserLoginRequest{Email: "test@example.com", Password: "wrong"})
+	_, _ = service.Login(context.Background(), &model.UserLoginRequest{Email: "test@example.com", Password: "password123"})
+
+	types := publisher.types()
+	if len(types) != 2 || types[0] != model.EventLoginFailed || types[1] != model.EventUserLoggedIn {
+		t.Errorf("expected [user.login_failed user.logged_in], got %v", types)
+	}
+}
+
+func TestPostService_Create_FailsWhenOutboxWriteFails(t *testing.T) {
+	tx := &mockTxManager{}
+	publisher := &mockEventPublisher{err: errors.New("outbox unavailable")}
+	service := NewPostService(&mockPostRepo{}, &mockUserRepo{}, tx, publisher, &mockBroadcaster{}, &mockContentFilter{})
+
+	_, err := service.Create(context.Background(), 1, &model.PostCreateRequest{Title: "Title", Content: "Content"})
+	if err == nil {
+		t.Fatal("expected error when event cannot be stored, got nil")
+	}
+	if tx.calls != 1 {
+		t.Errorf("expected post and event to be written in one transaction, got %d calls", tx.calls)
+	}
+}
+
+func TestUserService_Login_SucceedsWhenEventWriteFails(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("password123")
+	mockRepo := &mockUserRepo{
+		getByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			return &model.User{ID: 1, Username: "testuser", Email: email, Password: hashedPassword}, nil
+		},
+	}
+	publisher := &mockEventPublisher{err: errors.New("outbox unavailable")}
+	service := NewUserService(mockRepo, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, auth.NewJWTManager("test-secret", 24), &mockTxManager{}, publisher)
+
+	if _, err := service.Login(context.Background(), &model.UserLoginRequest{Email: "test@example.com", Password: "password123"}); err != nil {
+		t.Errorf("expected login to succeed, got %v", err)
+	}
+}
+
+func TestCommentService_Create_BroadcastsToPostTopic(t *testing.T) {
+	mockCommentRepo := &mockCommentRepo{
+		createFunc: func(ctx context.Context, comment *model.Comment) error {
+			comment.ID = 9
+			return nil
+		},
+	}
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1}, nil
+		},
+	}
+	stream := &mockBroadcaster{}
+	service := NewCommentService(mockCommentRepo, mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, stream, ModerationConfig{}, &mockContentFilter{})
+
+	if _, err := service.Create(context.Background(), 2, 4, "Nice post", ""); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	messages := stream.messages[CommentsTopic(4)]
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message on %s, got %v", CommentsTopic(4), stream.messages)
+	}
+	// The message ID is the stream sequence number, the comment itself travels in Data
+	if messages[0].ID != 1 || messages[0].Event != string(model.EventCommentCreated) || !strings.Contains(string(messages[0].Data), `"id":9`) {
+		t.Errorf("unexpected message: %+v", messages[0])
+	}
+}
+
+// fakeStreamLog numbers appended events like the stream_events table does
+type fakeStreamLog struct {
+	last map[string]int64
+}
+
+func (l *fakeStreamLog) Append(ctx context.Context, topic, event string, data []byte) (int64, error) {
+	if l.last == nil {
+		l.last = make(map[string]int64)
+	}
+	l.last[topic]++
+	return l.last[topic], nil
+}
+
+func (l *fakeStreamLog) After(ctx context.Context, topic string, afterID int64, limit int) ([]pubsub.Message, error) {
+	return nil, nil
+}
+
+func TestStream_BroadcastPublishesLoggedSequence(t *testing.T) {
+	hub := pubsub.NewHub(8)
+	sub, _ := hub.Subscribe(TopicPosts)
+	stream := NewStream(&fakeStreamLog{last: map[string]int64{TopicPosts: 41}}, hub)
+
+	if err := broadcast(context.Background(), stream, TopicPosts, model.EventPostCreated, map[string]int{"id": 7}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	select {
+	case msg := <-sub.Messages():
+		if msg.ID != 42 || msg.Event != string(model.EventPostCreated) || string(msg.Data) != `{"id":7}` {
+			t.Errorf("unexpected message: %+v", msg)
+		}
+	default:
+		t.Fatal("expected message to be published")
+	}
+}
+
+func TestPostService_Create_NoBroadcastWhenTransactionFails(t *testing.T) {
+	stream := &mockBroadcaster{}
+	publisher := &mockEventPublisher{err: errors.New("outbox unavailable")}
+	service := NewPostService(&mockPostRepo{}, &mockUserRepo{}, &mockTxManager{}, publisher, stream, &mockContentFilter{})
+
+	if _, err := service.Create(context.Background(), 1, &model.PostCreateRequest{Title: "Title", Content: "Content"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if len(stream.messages) != 0 {
+		t.Errorf("expected no stream messages for rolled back post, got %v", stream.messages)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/tracing"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ModerationService ведет очередь модерации комментариев. Решения принимают пользователи
+// с ролью editor или admin; авторы видят в очереди только собственные комментарии
+type ModerationService struct {
+	comments      repository.CommentRepository
+	users         repository.UserRepository
+	notifications repository.NotificationRepository
+	tx            repository.TxManager
+	events        EventPublisher
+	stream        Broadcaster
+}
+
+func NewModerationService(comments repository.CommentRepository, users repository.UserRepository, notifications repository.NotificationRepository, tx repository.TxManager, events EventPublisher, stream Broadcaster) *ModerationService {
+	return &ModerationService{
+		comments:      comments,
+		users:         users,
+		notifications: notifications,
+		tx:            tx,
+		events:        events,
+		stream:        stream,
+	}
+}
+
+// List возвращает комментарии со статусом filter.Status (pending, если пуст), начиная со старых.
+// Модераторам доступны комментарии всех авторов, остальным - только собственные
+func (s *ModerationService) List(ctx context.Context, userID int, filter model.ModerationFilter) (_ []*model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.List")
+	defer func() { tracing.End(span, err) }()
+
+	switch filter.Status {
+	case "":
+		filter.Status = model.CommentPending
+	case model.CommentPending, model.CommentApproved, model.CommentRejected:
+	default:
+		return nil, apperrors.FieldErrors{"status": "expected one of pending, approved, rejected"}
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	moderator, err := isEditor(ctx, s.users, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !moderator {
+		filter.AuthorID = userID
+	}
+
+	comments, err := s.comments.GetModeration(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderation queue: %w", err)
+	}
+	return comments, nil
+}
+
+// Approve публикует комментарии req.IDs. Возвращает комментарии, статус которых изменился;
+// уже одобренные и удаленные комментарии пропускаются
+func (s *ModerationService) Approve(ctx context.Context, moderatorID int, req *model.ModerationRequest) (_ []*model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.Approve")
+	defer func() { tracing.End(span, err) }()
+
+	return s.decide(ctx, moderatorID, req, model.CommentApproved)
+}
+
+// Reject отклоняет комментарии req.IDs с причиной req.Reason, которую увидят их авторы.
+// Отклонить можно и уже опубликованный комментарий: он пропадет из списков поста
+func (s *ModerationService) Reject(ctx context.Context, moderatorID int, req *model.ModerationRequest) (_ []*model.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.Reject")
+	defer func() { tracing.End(span, err) }()
+
+	return s.decide(ctx, moderatorID, req, model.CommentRejected)
+}
+
+// decide сохраняет решение модератора, уведомления авторам и события в одной транзакции
+func (s *ModerationService) decide(ctx context.Context, moderatorID int, req *model.ModerationRequest, status string) ([]*model.Comment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	reason := ""
+	if status == model.CommentRejected {
+		reason = strings.TrimSpace(req.Reason)
+	}
+
+	moderator, err := isEditor(ctx, s.users, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !moderator {
+		return nil, apperrors.ErrForbidden
+	}
+
+	var comments []*model.Comment
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		comments, err = s.comments.SetStatus(ctx, req.IDs, status, reason)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if comment.AuthorID != moderatorID {
+				if err := s.notifications.Create(ctx, model.NewCommentModeratedNotification(comment)); err != nil {
+					return err
+				}
+			}
+			if err := s.events.Publish(ctx, model.NewCommentModeratedEvent(moderatorID, comment)); err != nil {
+				return err
+			}
+		}
+		return s.broadcast(ctx, comments, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("comments moderated", "status", status, "count", len(comments))
+
+	return comments, nil
+}
+
+// broadcast рассылает решение подписчикам постов: одобренный комментарий для них новый и
+// получает следующий номер в потоке поста, даже если создан раньше уже показанных, а отклоненный -
+// удаленный. Темы блокируются в порядке ID поста, чтобы параллельные решения не взаимоблокировались
+func (s *ModerationService) broadcast(ctx context.Context, comments []*model.Comment, status string) error {
+	eventType := model.EventCommentCreated
+	if status == model.CommentRejected {
+		eventType = model.EventCommentDeleted
+	}
+
+	ordered := slices.Clone(comments)
+	slices.SortStableFunc(ordered, func(a, b *model.Comment) int { return cmp.Compare(a.PostID, b.PostID) })
+	for _, comment := range ordered {
+		if err := broadcast(ctx, s.stream, CommentsTopic(comment.PostID), eventType, comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type NotificationService struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationService(repo repository.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// List возвращает уведомления пользователя от новых к старым
+func (s *NotificationService) List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) (_ []*model.Notification, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.List")
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	notifications, err := s.repo.List(ctx, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// MarkRead отмечает уведомление пользователя прочитанным
+func (s *NotificationService) MarkRead(ctx context.Context, userID int, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkRead")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.MarkRead(ctx, userID, id)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+)
+
+type ModerationServiceInterface interface {
+	List(ctx context.Context, userID int, filter model.ModerationFilter) ([]*model.Comment, error)
+
+	Approve(ctx context.Context, moderatorID int, req *model.ModerationRequest) ([]*model.Comment, error)
+
+	Reject(ctx context.Context, moderatorID int, req *model.ModerationRequest) ([]*model.Comment, error)
+}
+
+type NotificationServiceInterface interface {
+	List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*model.Notification, error)
+
+	MarkRead(ctx context.Context, userID int, id int64) error
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mockNotificationRepo is a mock implementation of NotificationRepository
+type mockNotificationRepo struct {
+	created []*model.Notification
+}
+
+func (m *mockNotificationRepo) Create(ctx context.Context, n *model.Notification) error {
+	m.created = append(m.created, n)
+	return nil
+}
+
+func (m *mockNotificationRepo) List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]*model.Notification, error) {
+	return nil, nil
+}
+
+func (m *mockNotificationRepo) MarkRead(ctx context.Context, userID int, id int64) error {
+	return nil
+}
+
+func usersWithRoles(roles map[int]string) *mockUserRepo {
+	return &mockUserRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.User, error) {
+			role, ok := roles[id]
+			if !ok {
+				return nil, apperrors.ErrUserNotFound
+			}
+			return &model.User{ID: id, Role: role}, nil
+		},
+	}
+}
+
+func TestCommentService_Create_ModerationStatus(t *testing.T) {
+	users := usersWithRoles(map[int]string{1: model.RoleUser, 2: model.RoleUser, 3: model.RoleEditor, 4: model.RoleUser})
+	tests := []struct {
+		name       string
+		moderation ModerationConfig
+		post       model.Post
+		userID     int
+		want       string
+	}{
+		{"moderation off", ModerationConfig{Mode: model.ModerationOff}, model.Post{AuthorID: 1}, 2, model.CommentApproved},
+		{"global moderation", ModerationConfig{Mode: model.ModerationAll}, model.Post{AuthorID: 1}, 2, model.CommentPending},
+		{"post moderation", ModerationConfig{Mode: model.ModerationOff}, model.Post{AuthorID: 1, ModerateComments: true}, 2, model.CommentPending},
+		{"post author", ModerationConfig{Mode: model.ModerationAll}, model.Post{AuthorID: 1}, 1, model.CommentApproved},
+		{"editor", ModerationConfig{Mode: model.ModerationAll}, model.Post{AuthorID: 1}, 3, model.CommentApproved},
+		{"trusted user", ModerationConfig{Mode: model.ModerationAll, TrustedAfter: 3}, model.Post{AuthorID: 1}, 4, model.CommentApproved},
+		{"not yet trusted", ModerationConfig{Mode: model.ModerationAll, TrustedAfter: 3}, model.Post{AuthorID: 1}, 2, model.CommentPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments := &mockCommentRepo{
+				countByAuthorStatusFunc: func(ctx context.Context, authorID int, status string) (int, error) {
+					if status != model.CommentApproved {
+						t.Errorf("expected approved comments to be counted, got %s", status)
+					}
+					if authorID == 4 {
+						return 3, nil
+					}
+					return 2, nil
+				},
+			}
+			posts := &mockPostRepo{
+				getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+					post := tt.post
+					post.ID = id
+					return &post, nil
+				},
+			}
+			stream := &mockBroadcaster{}
+			service := NewCommentService(comments, posts, users, &mockTxManager{}, &mockEventPublisher{}, stream, tt.moderation, &mockContentFilter{})
+
+			comment, err := service.Create(context.Background(), tt.userID, 7, "Nice post", "")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if comment.Status != tt.want {
+				t.Errorf("expected status %s, got %s", tt.want, comment.Status)
+			}
+
+			broadcasted := len(stream.messages[CommentsTopic(7)]) == 1
+			if broadcasted != (tt.want == model.CommentApproved) {
+				t.Errorf("expected broadcast only for approved comments, got %v", stream.messages)
+			}
+		})
+	}
+}
+
+func TestModerationService_List_AuthorsSeeOwnComments(t *testing.T) {
+	var got model.ModerationFilter
+	comments := &mockCommentRepo{
+		getModerationFunc: func(ctx context.Context, filter model.ModerationFilter) ([]*model.Comment, error) {
+			got = filter
+			return nil, nil
+		},
+	}
+	users := usersWithRoles(map[int]string{1: model.RoleUser, 2: model.RoleAdmin})
+	service := NewModerationService(comments, users, &mockNotificationRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{})
+
+	if _, err := service.List(context.Background(), 1, model.ModerationFilter{AuthorID: 5}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.AuthorID != 1 || got.Status != model.CommentPending || got.Limit != 20 {
+		t.Errorf("expected own pending comments for regular user, got %+v", got)
+	}
+
+	if _, err := service.List(context.Background(), 2, model.ModerationFilter{AuthorID: 5, Status: model.CommentRejected}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.AuthorID != 5 || got.Status != model.CommentRejected {
+		t.Errorf("expected moderator filter to be kept, got %+v", got)
+	}
+
+	var fieldErrs apperrors.FieldErrors
+	if _, err := service.List(context.Background(), 2, model.ModerationFilter{Status: "spam"}); !errors.As(err, &fieldErrs) {
+		t.Errorf("expected FieldErrors for unknown status, got %v", err)
+	}
+}
+
+func TestModerationService_Approve_OnlyModerators(t *testing.T) {
+	comments := &mockCommentRepo{
+		setStatusFunc: func(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error) {
+			t.Fatal("status must not change for a regular user")
+			return nil, nil
+		},
+	}
+	users := usersWithRoles(map[int]string{1: model.RoleUser})
+	service := NewModerationService(comments, users, &mockNotificationRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{})
+
+	if _, err := service.Approve(context.Background(), 1, &model.ModerationRequest{IDs: []int{10}}); !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestModerationService_Reject_NotifiesAuthors(t *testing.T) {
+	comments := &mockCommentRepo{
+		setStatusFunc: func(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error) {
+			var changed []*model.Comment
+			for _, id := range ids {
+				changed = append(changed, &model.Comment{ID: id, PostID: 3, AuthorID: id, Status: status, ModerationReason: reason})
+			}
+			return changed, nil
+		},
+	}
+	users := usersWithRoles(map[int]string{2: model.RoleEditor})
+	notifications := &mockNotificationRepo{}
+	publisher := &mockEventPublisher{}
+	stream := &mockBroadcaster{}
+	service := NewModerationService(comments, users, notifications, &mockTxManager{}, publisher, stream)
+
+	// Comment 2 belongs to the moderator, who is not notified about own decision
+	rejected, err := service.Reject(context.Background(), 2, &model.ModerationRequest{IDs: []int{1, 2}, Reason: "  off-topic "})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rejected) != 2 || rejected[0].ModerationReason != "off-topic" {
+		t.Fatalf("expected 2 rejected comments with trimmed reason, got %+v", rejected)
+	}
+
+	if len(notifications.created) != 1 || notifications.created[0].UserID != 1 || notifications.created[0].Type != model.EventCommentRejected {
+		t.Errorf("expected one comment.rejected notification for user 1, got %+v", notifications.created)
+	}
+	if types := publisher.types(); len(types) != 2 || types[0] != model.EventCommentRejected {
+		t.Errorf("expected comment.rejected events, got %v", types)
+	}
+	if messages := stream.messages[CommentsTopic(3)]; len(messages) != 2 || messages[0].Event != string(model.EventCommentDeleted) {
+		t.Errorf("expected rejected comments to be removed from the stream, got %v", stream.messages)
+	}
+}
+
+func TestModerationService_Approve_StreamsAfterLaterComment(t *testing.T) {
+	comments := &mockCommentRepo{
+		createFunc: func(ctx context.Context, comment *model.Comment) error {
+			comment.ID = 11
+			return nil
+		},
+		setStatusFunc: func(ctx context.Context, ids []int, status, reason string) ([]*model.Comment, error) {
+			return []*model.Comment{{ID: 10, PostID: 3, AuthorID: 1, Status: status}}, nil
+		},
+	}
+	posts := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 2}, nil
+		},
+		existsFunc: func(ctx context.Context, id int) (bool, error) {
+			return true, nil
+		},
+	}
+	users := usersWithRoles(map[int]string{1: model.RoleUser, 2: model.RoleEditor})
+	stream := &mockBroadcaster{}
+	commentService := NewCommentService(comments, posts, users, &mockTxManager{}, &mockEventPublisher{}, stream, ModerationConfig{Mode: model.ModerationOff}, &mockContentFilter{})
+	moderation := NewModerationService(comments, users, &mockNotificationRepo{}, &mockTxManager{}, &mockEventPublisher{}, stream)
+
+	// Comment 11 is streamed first, then the older pending comment 10 is approved
+	if _, err := commentService.Create(context.Background(), 2, 3, "Later comment", ""); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := moderation.Approve(context.Background(), 2, &model.ModerationRequest{IDs: []int{10}}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// A client that has seen comment 11 resumes after its event number and still gets comment 10
+	missed, err := commentService.StreamAfter(context.Background(), 3, 0, 1, 100)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(missed) != 1 || missed[0].ID != 2 || missed[0].Event != string(model.EventCommentCreated) || !strings.Contains(string(missed[0].Data), `"id":10`) {
+		t.Errorf("expected approved comment 10 as event 2, got %+v", missed)
+	}
+}
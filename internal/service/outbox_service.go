@@ -0,0 +1,47 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/tracing"
+	"context"
+	"fmt"
+)
+
+type OutboxService struct {
+	repo repository.OutboxRepository
+}
+
+func NewOutboxService(repo repository.OutboxRepository) *OutboxService {
+	return &OutboxService{repo: repo}
+}
+
+// List возвращает события outbox с указанным статусом, по умолчанию - dead-letter
+func (s *OutboxService) List(ctx context.Context, status string, limit, offset int) (_ []*model.OutboxMessage, err error) {
+	ctx, span := tracing.Start(ctx, "OutboxService.List")
+	defer func() { tracing.End(span, err) }()
+
+	if status == "" {
+		status = model.OutboxDead
+	}
+
+	messages, err := s.repo.ListByStatus(ctx, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+	return messages, nil
+}
+
+// Retry возвращает событие из dead-letter в очередь доставки
+func (s *OutboxService) Retry(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "OutboxService.Retry")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.repo.Requeue(ctx, id); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("outbox event requeued", "outbox_id", id)
+	return nil
+}
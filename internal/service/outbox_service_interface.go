@@ -0,0 +1,12 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+)
+
+type OutboxServiceInterface interface {
+	List(ctx context.Context, status string, limit, offset int) ([]*model.OutboxMessage, error)
+
+	Retry(ctx context.Context, id int64) error
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"slices"
+)
+
+// normalizePage приводит размер страницы к допустимому диапазону и запрашивает у хранилища
+// на одну запись больше, чтобы понять, есть ли следующая страница в направлении обхода
+func normalizePage(page model.PageRequest, defaultLimit, maxLimit int) (model.PageRequest, int) {
+	if page.Limit <= 0 {
+		page.Limit = defaultLimit
+	}
+	if page.Limit > maxLimit {
+		page.Limit = maxLimit
+	}
+	limit := page.Limit
+	page.Limit++
+	return page, limit
+}
+
+// cutPage обрезает лишнюю запись и вычисляет позиции соседних страниц. Записи приходят
+// в порядке ленты, поэтому при обходе назад лишняя запись - первая
+func cutPage[T any](items []T, page model.PageRequest, limit int, keyset func(T) model.Keyset) ([]T, model.PageInfo) {
+	more := len(items) > limit
+	if more {
+		if page.Backward {
+			items = items[len(items)-limit:]
+		} else {
+			items = items[:limit]
+		}
+	}
+
+	var info model.PageInfo
+	if len(items) == 0 {
+		// Пустая страница за пределами ленты: вернуться можно к той же позиции
+		if page.Position != nil {
+			position := *page.Position
+			if page.Backward {
+				info.Next = &position
+			} else {
+				info.Prev = &position
+			}
+		}
+		return items, info
+	}
+
+	first, last := keyset(items[0]), keyset(items[len(items)-1])
+	if page.Backward {
+		info.Next = &last
+		if more {
+			info.Prev = &first
+		}
+	} else {
+		if page.Position != nil {
+			info.Prev = &first
+		}
+		if more {
+			info.Next = &last
+		}
+	}
+	return items, info
+}
+
+// postKeyset возвращает функцию позиции поста для заданного порядка ленты;
+// заголовок попадает в позицию (и в курсор), только если по нему сортируют
+func postKeyset(sort []model.SortField) func(*model.Post) model.Keyset {
+	byTitle := slices.ContainsFunc(sort, func(s model.SortField) bool { return s.Field == model.PostSortTitle })
+	return func(p *model.Post) model.Keyset {
+		k := model.Keyset{CreatedAt: p.CreatedAt, ID: p.ID}
+		if byTitle {
+			k.Title = p.Title
+		}
+		return k
+	}
+}
+
+func commentKeyset(c *model.Comment) model.Keyset {
+	return model.Keyset{CreatedAt: c.CreatedAt, ID: c.ID}
+}
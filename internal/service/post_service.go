@@ -1,52 +1,139 @@
 package service
 
 import (
+	"advanced-blog-management-system/internal/diff"
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/logger"
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
 	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/spam"
+	"advanced-blog-management-system/internal/tracing"
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type PostService struct {
 	postRepo repository.PostRepository
 	userRepo repository.UserRepository
+	tx       repository.TxManager
+	events   EventPublisher
+	stream   Broadcaster
+	filter   ContentFilter
 }
 
-func NewPostService(postRepo repository.PostRepository, userRepo repository.UserRepository) *PostService {
+func NewPostService(postRepo repository.PostRepository, userRepo repository.UserRepository, tx repository.TxManager, events EventPublisher, stream Broadcaster, filter ContentFilter) *PostService {
 	return &PostService{
 		postRepo: postRepo,
 		userRepo: userRepo,
+		tx:       tx,
+		events:   events,
+		stream:   stream,
+		filter:   filter,
 	}
 }
 
-func (s *PostService) Create(ctx context.Context, userID int, req *model.PostCreateRequest) (*model.Post, error) {
+func (s *PostService) Create(ctx context.Context, userID int, req *model.PostCreateRequest) (_ *model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.Create")
+	defer func() { tracing.End(span, err) }()
+
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
 
+	format, html, err := renderContent(req.ContentFormat, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подозрительный пост создается в статусе pending и не попадает в ленты и поток до одобрения
+	verdict, err := checkContent(ctx, s.filter, spam.KindPost, userID, postText(req.Title, req.Summary, req.Content))
+	if err != nil {
+		return nil, err
+	}
+
 	post := &model.Post{
-		Title:    req.Title,
-		Content:  req.Content,
-		AuthorID: userID,
+		Title:         req.Title,
+		Content:       req.Content,
+		ContentFormat: format,
+		ContentHTML:   html,
+		Summary:       req.Summary,
+		AuthorID:      userID,
+		Status:        model.PostPublished,
+	}
+	if verdict.Action == spam.Moderate {
+		post.Status = model.PostPending
 	}
 
-	if err := s.postRepo.Create(ctx, post); err != nil {
-		return nil, fmt.Errorf("failed to create post: %w", err)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.postRepo.Create(ctx, post); err != nil {
+			return fmt.Errorf("failed to create post: %w", err)
+		}
+		if err := s.postRepo.CreateRevision(ctx, newRevision(post, userID, 0)); err != nil {
+			return fmt.Errorf("failed to save post revision: %w", err)
+		}
+		// Пост на модерации не публикуется во внешние приемники: post.created отправит одобрение
+		if post.Status == model.PostPending {
+			target := model.EventTarget{Type: "post", ID: post.ID}
+			return s.events.Publish(ctx, flaggedEvent(userID, target, verdict))
+		}
+		if err := s.events.Publish(ctx, model.NewPostCreatedEvent(post)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, post, model.EventPostCreated)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	logger.FromContext(ctx).Debug("post created", "post_id", post.ID, "status", post.Status)
+
 	return post, nil
 }
 
-func (s *PostService) GetByID(ctx context.Context, id int, requestorID int) (*model.Post, error) {
-	post, err := s.postRepo.GetByID(ctx, id)
+// GetByID возвращает пост. Пост, ожидающий модерации, виден только автору и модераторам,
+// для остальных его нет
+func (s *PostService) GetByID(ctx context.Context, id int, requestorID int) (_ *model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetByID")
+	defer func() { tracing.End(span, err) }()
+
+	return s.visiblePost(ctx, id, requestorID)
+}
+
+// visiblePost читает пост с проверкой видимости для requestorID
+func (s *PostService) visiblePost(ctx context.Context, id int, requestorID int) (*model.Post, error) {
+	return visiblePost(ctx, s.postRepo, s.userRepo, id, requestorID)
+}
+
+// visiblePost читает пост с проверкой видимости: пост на модерации виден только автору
+// и модераторам, остальным возвращается ErrPostNotFound. Через нее проходят все чтения,
+// раскрывающие пост или его обсуждение: версии, комментарии, потоки
+func visiblePost(ctx context.Context, posts repository.PostRepository, users repository.UserRepository, id, requestorID int) (*model.Post, error) {
+	post, err := posts.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if post.Status == model.PostPending && post.AuthorID != requestorID {
+		editor, err := isEditor(ctx, users, requestorID)
+		if err != nil {
+			return nil, err
+		}
+		if !editor {
+			return nil, apperrors.ErrPostNotFound
+		}
+	}
+
 	return post, nil
 }
 
-func (s *PostService) GetAll(ctx context.Context, limit, offset int) ([]*model.Post, int, error) {
+func (s *PostService) GetAll(ctx context.Context, limit, offset int) (_ []*model.Post, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetAll")
+	defer func() { tracing.End(span, err) }()
+
 	if limit <= 0 {
 		limit = 10
 	}
@@ -70,7 +157,10 @@ func (s *PostService) GetAll(ctx context.Context, limit, offset int) ([]*model.P
 	return posts, total, nil
 }
 
-func (s *PostService) GetByAuthor(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, int, error) {
+func (s *PostService) GetByAuthor(ctx context.Context, authorID int, limit, offset int) (_ []*model.Post, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetByAuthor")
+	defer func() { tracing.End(span, err) }()
+
 	if limit <= 0 {
 		limit = 10
 	}
@@ -93,3 +183,500 @@ func (s *PostService) GetByAuthor(ctx context.Context, authorID int, limit, offs
 
 	return posts, total, nil
 }
+
+// ListPage возвращает страницу ленты постов по позиции (keyset-пагинация): в отличие от
+// LIMIT/OFFSET, новые посты не сдвигают уже открытые страницы
+func (s *PostService) ListPage(ctx context.Context, filter model.PostFilter, page model.PageRequest) (_ []*model.Post, _ model.PageInfo, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListPage")
+	defer func() { tracing.End(span, err) }()
+
+	page, limit := normalizePage(page, 10, 100)
+	posts, err := s.postRepo.GetPage(ctx, filter, page)
+	if err != nil {
+		return nil, model.PageInfo{}, fmt.Errorf("failed to get posts page: %w", err)
+	}
+	posts, info := cutPage(posts, page, limit, postKeyset(filter.SortOrDefault()))
+
+	if page.IncludeTotal {
+		total, err := s.postRepo.GetCount(ctx, filter)
+		if err != nil {
+			return nil, model.PageInfo{}, fmt.Errorf("failed to get total post count: %w", err)
+		}
+		info.Total = &total
+	}
+
+	return posts, info, nil
+}
+
+// StreamAfter возвращает события потока постов с номером больше afterID в порядке номеров
+func (s *PostService) StreamAfter(ctx context.Context, afterID int64, limit int) (_ []pubsub.Message, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.StreamAfter")
+	defer func() { tracing.End(span, err) }()
+
+	return streamAfter(ctx, s.stream, TopicPosts, afterID, limit)
+}
+
+// Update изменяет заголовок и текст поста и сохраняет их как новую версию. Править пост может
+// автор, редакторы и администраторы. Правка без изменений не создает версию. Если req.Version
+// устарела, возвращается ConflictError с актуальной копией поста
+func (s *PostService) Update(ctx context.Context, userID, postID int, req *model.PostUpdateRequest) (_ *model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanEdit(ctx, userID, post); err != nil {
+		return nil, err
+	}
+	if post.Version != req.Version {
+		return nil, s.conflict(ctx, postID)
+	}
+
+	format := req.ContentFormat
+	if format == "" {
+		format = post.ContentFormat
+	}
+	return s.saveRevision(ctx, userID, post, req.Title, req.Content, format, 0)
+}
+
+// RestoreRevision возвращает посту заголовок и текст версии revision. Восстановление
+// сохраняется новой версией, поэтому история не переписывается
+func (s *PostService) RestoreRevision(ctx context.Context, userID, postID, revision int) (_ *model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.RestoreRevision")
+	defer func() { tracing.End(span, err) }()
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanEdit(ctx, userID, post); err != nil {
+		return nil, err
+	}
+
+	rev, err := s.postRepo.GetRevision(ctx, postID, revision)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveRevision(ctx, userID, post, rev.Title, rev.Content, rev.ContentFormat, rev.Revision)
+}
+
+// saveRevision применяет к посту новый заголовок и текст и в одной транзакции сохраняет
+// пост, версию и событие post.updated. Новый текст проверяется фильтром спама так же, как
+// при создании: подозрительная правка снимает пост с публикации до одобрения модератором
+func (s *PostService) saveRevision(ctx context.Context, userID int, post *model.Post, title, content, format string, restoredFrom int) (*model.Post, error) {
+	if post.Title == title && post.Content == content && post.ContentFormat == format {
+		return post, nil
+	}
+
+	format, html, err := renderContent(format, content)
+	if err != nil {
+		return nil, err
+	}
+	verdict, err := checkContent(ctx, s.filter, spam.KindPost, userID, postText(title, post.Summary, content))
+	if err != nil {
+		return nil, err
+	}
+
+	wasPublished := post.Status != model.PostPending
+	post.Title = title
+	post.Content = content
+	post.ContentFormat = format
+	post.ContentHTML = html
+	if verdict.Action == spam.Moderate {
+		post.Status = model.PostPending
+	}
+
+	rev := newRevision(post, userID, restoredFrom)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Пост изменяется первым: блокировка его строки упорядочивает нумерацию версий
+		if err := s.postRepo.Update(ctx, post); err != nil {
+			return fmt.Errorf("failed to update post: %w", err)
+		}
+		if err := s.postRepo.CreateRevision(ctx, rev); err != nil {
+			return fmt.Errorf("failed to save post revision: %w", err)
+		}
+		if verdict.Action == spam.Moderate {
+			target := model.EventTarget{Type: "post", ID: post.ID}
+			if err := s.events.Publish(ctx, flaggedEvent(userID, target, verdict)); err != nil {
+				return err
+			}
+		}
+		// Правки поста на модерации не публикуются во внешние приемники, как и сам пост
+		if post.Status == model.PostPending {
+			// Для подписчиков потока пост, снятый с публикации, удален
+			if wasPublished {
+				return broadcast(ctx, s.stream, TopicPosts, model.EventPostDeleted, post)
+			}
+			return nil
+		}
+		if err := s.events.Publish(ctx, model.NewPostUpdatedEvent(userID, post, rev)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, post, model.EventPostUpdated)
+	})
+	if errors.Is(err, apperrors.ErrConflict) {
+		return nil, s.conflict(ctx, post.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Debug("post updated", "post_id", post.ID, "revision", rev.Revision, "status", post.Status)
+
+	return post, nil
+}
+
+// conflict возвращает ConflictError с актуальной копией поста. Копия читается в транзакции,
+// чтобы запрос шел в БД мимо кеша
+func (s *PostService) conflict(ctx context.Context, postID int) error {
+	var current *model.Post
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = s.postRepo.GetByID(ctx, postID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return &apperrors.ConflictError{Current: current}
+}
+
+// broadcast рассылает изменение подписчикам ленты. Посты, ожидающие модерации, в поток не попадают
+func (s *PostService) broadcast(ctx context.Context, post *model.Post, eventType model.EventType) error {
+	if post.Status == model.PostPending {
+		return nil
+	}
+	return broadcast(ctx, s.stream, TopicPosts, eventType, post)
+}
+
+// postText - текст поста, который проверяет фильтр спама
+func postText(title, summary, content string) string {
+	return strings.Join([]string{title, summary, content}, "\n")
+}
+
+// checkCanEdit разрешает правку автору поста, редакторам и администраторам. Роль читается
+// из БД, а не из токена, чтобы снятие роли действовало сразу
+func (s *PostService) checkCanEdit(ctx context.Context, userID int, post *model.Post) error {
+	if post.AuthorID == userID {
+		return nil
+	}
+	editor, err := s.isEditor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !editor {
+		return apperrors.ErrForbidden
+	}
+	return nil
+}
+
+// isEditor сообщает, может ли пользователь править чужие посты (роль editor или admin)
+func (s *PostService) isEditor(ctx context.Context, userID int) (bool, error) {
+	return isEditor(ctx, s.userRepo, userID)
+}
+
+// isEditor сообщает, есть ли у пользователя роль editor или admin: такие пользователи правят
+// чужие посты и модерируют комментарии
+func isEditor(ctx context.Context, users repository.UserRepository, userID int) (bool, error) {
+	user, err := users.GetByID(ctx, userID)
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.Role == model.RoleEditor || user.Role == model.RoleAdmin, nil
+}
+
+// Delete переносит пост в корзину вместе с его комментариями. Удалить пост может тот же
+// круг пользователей, что и править его
+func (s *PostService) Delete(ctx context.Context, userID, postID int) (err error) {
+	ctx, span := tracing.Start(ctx, "PostService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCanEdit(ctx, userID, post); err != nil {
+		return err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.postRepo.Delete(ctx, post.ID, time.Now()); err != nil {
+			return err
+		}
+		if err := s.events.Publish(ctx, model.NewPostDeletedEvent(userID, post)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, post, model.EventPostDeleted)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("post deleted", "post_id", post.ID)
+
+	return nil
+}
+
+// Restore возвращает пост из корзины вместе с комментариями, удаленными одновременно с ним.
+// Пост удаленного автора восстанавливается только вместе с автором
+func (s *PostService) Restore(ctx context.Context, userID, postID int) (_ *model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.Restore")
+	defer func() { tracing.End(span, err) }()
+
+	post, err := s.postRepo.GetDeletedByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanEdit(ctx, userID, post); err != nil {
+		return nil, err
+	}
+
+	_, err = s.userRepo.GetByID(ctx, post.AuthorID)
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return nil, apperrors.ErrParentDeleted
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author: %w", err)
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.postRepo.Restore(ctx, post.ID); err != nil {
+			return err
+		}
+		post.DeletedAt = nil
+		if err := s.events.Publish(ctx, model.NewPostRestoredEvent(userID, post)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, post, model.EventPostRestored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("post restored", "post_id", post.ID)
+
+	return post, nil
+}
+
+// SetCommentModeration включает или выключает модерацию новых комментариев поста.
+// Переключить ее может тот же круг пользователей, что и править пост
+func (s *PostService) SetCommentModeration(ctx context.Context, userID, postID int, enabled bool) (_ *model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.SetCommentModeration")
+	defer func() { tracing.End(span, err) }()
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanEdit(ctx, userID, post); err != nil {
+		return nil, err
+	}
+
+	if err := s.postRepo.SetModerateComments(ctx, post.ID, enabled); err != nil {
+		return nil, err
+	}
+	post.ModerateComments = enabled
+
+	logger.FromContext(ctx).Info("post comment moderation changed", "post_id", post.ID, "enabled", enabled)
+
+	return post, nil
+}
+
+// ListDeleted возвращает посты из корзины: редакторам и администраторам - все,
+// остальным - только собственные
+func (s *PostService) ListDeleted(ctx context.Context, userID, limit, offset int) (_ []*model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListDeleted")
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	authorID := userID
+	editor, err := s.isEditor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if editor {
+		authorID = 0
+	}
+
+	posts, err := s.postRepo.GetDeleted(ctx, authorID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted posts: %w", err)
+	}
+	return posts, nil
+}
+
+// ListPending возвращает посты, ожидающие модерации, начиная со старых: модераторам - все,
+// остальным - только собственные
+func (s *PostService) ListPending(ctx context.Context, userID, limit, offset int) (_ []*model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListPending")
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	authorID := userID
+	editor, err := s.isEditor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if editor {
+		authorID = 0
+	}
+
+	posts, err := s.postRepo.GetPending(ctx, authorID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending posts: %w", err)
+	}
+	return posts, nil
+}
+
+// Approve публикует пост из очереди модерации; решение принимают пользователи с ролью editor
+// или admin. Для подписчиков ленты одобренный пост новый. Отклонить пост можно, удалив его
+func (s *PostService) Approve(ctx context.Context, moderatorID, postID int) (_ *model.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.Approve")
+	defer func() { tracing.End(span, err) }()
+
+	editor, err := s.isEditor(ctx, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !editor {
+		return nil, apperrors.ErrForbidden
+	}
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status != model.PostPending {
+		return post, nil
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.postRepo.SetStatus(ctx, post.ID, model.PostPublished); err != nil {
+			return err
+		}
+		post.Status = model.PostPublished
+		if err := s.events.Publish(ctx, model.NewPostApprovedEvent(moderatorID, post)); err != nil {
+			return err
+		}
+		// Во внешних приемниках пост появляется только после одобрения
+		if err := s.events.Publish(ctx, model.NewPostCreatedEvent(post)); err != nil {
+			return err
+		}
+		return s.broadcast(ctx, post, model.EventPostCreated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("post approved", "post_id", post.ID)
+
+	return post, nil
+}
+
+func newRevision(post *model.Post, editorID, restoredFrom int) *model.PostRevision {
+	return &model.PostRevision{
+		PostID:        post.ID,
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		EditorID:      editorID,
+		RestoredFrom:  restoredFrom,
+	}
+}
+
+// ListRevisions возвращает версии поста от новых к старым, без текста. История поста
+// на модерации видна тем же пользователям, что и сам пост
+func (s *PostService) ListRevisions(ctx context.Context, postID, requestorID int, limit, offset int) (_ []*model.PostRevision, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListRevisions")
+	defer func() { tracing.End(span, err) }()
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	if _, err := s.visiblePost(ctx, postID, requestorID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.postRepo.GetRevisions(ctx, postID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetRevision возвращает версию поста целиком
+func (s *PostService) GetRevision(ctx context.Context, postID, revision, requestorID int) (_ *model.PostRevision, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetRevision")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.visiblePost(ctx, postID, requestorID); err != nil {
+		return nil, err
+	}
+
+	return s.postRepo.GetRevision(ctx, postID, revision)
+}
+
+// DiffRevisions сравнивает текст двух версий поста построчно
+func (s *PostService) DiffRevisions(ctx context.Context, postID, from, to, requestorID int) (_ *model.RevisionDiff, err error) {
+	ctx, span := tracing.Start(ctx, "PostService.DiffRevisions")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.visiblePost(ctx, postID, requestorID); err != nil {
+		return nil, err
+	}
+
+	fromRev, err := s.postRepo.GetRevision(ctx, postID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := s.postRepo.GetRevision(ctx, postID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RevisionDiff{
+		PostID:    postID,
+		From:      from,
+		To:        to,
+		FromTitle: fromRev.Title,
+		ToTitle:   toRev.Title,
+		Diff: diff.Unified(
+			fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to),
+			fromRev.Content, toRev.Content,
+		),
+	}, nil
+}
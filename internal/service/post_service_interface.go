@@ -2,6 +2,7 @@ package service
 
 import (
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
 	"context"
 )
 
@@ -13,4 +14,30 @@ type PostServiceInterface interface {
 	GetAll(ctx context.Context, limit, offset int) ([]*model.Post, int, error)
 
 	GetByAuthor(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, int, error)
+
+	ListPage(ctx context.Context, filter model.PostFilter, page model.PageRequest) ([]*model.Post, model.PageInfo, error)
+
+	StreamAfter(ctx context.Context, afterID int64, limit int) ([]pubsub.Message, error)
+
+	Update(ctx context.Context, userID, postID int, req *model.PostUpdateRequest) (*model.Post, error)
+
+	RestoreRevision(ctx context.Context, userID, postID, revision int) (*model.Post, error)
+
+	Delete(ctx context.Context, userID, postID int) error
+
+	Restore(ctx context.Context, userID, postID int) (*model.Post, error)
+
+	ListDeleted(ctx context.Context, userID, limit, offset int) ([]*model.Post, error)
+
+	SetCommentModeration(ctx context.Context, userID, postID int, enabled bool) (*model.Post, error)
+
+	ListPending(ctx context.Context, userID, limit, offset int) ([]*model.Post, error)
+
+	Approve(ctx context.Context, moderatorID, postID int) (*model.Post, error)
+
+	ListRevisions(ctx context.Context, postID, requestorID int, limit, offset int) ([]*model.PostRevision, error)
+
+	GetRevision(ctx context.Context, postID, revision, requestorID int) (*model.PostRevision, error)
+
+	DiffRevisions(ctx context.Context, postID, from, to, requestorID int) (*model.RevisionDiff, error)
 }
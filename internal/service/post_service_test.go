@@ -1,10 +1,13 @@
 package service
 
 import (
+	"advanced-blog-management-system/internal/errors/apperrors"
 	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/spam"
 
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -18,6 +21,21 @@ type mockPostRepo struct {
 	existsFunc                   func(ctx context.Context, id int) (bool, error)
 	getByAuthorIDFunc            func(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error)
 	getTotalCountByAuthorIDFunc func(ctx context.Context, authorID int) (int, error)
+	getPageFunc                  func(ctx context.Context, filter model.PostFilter, page model.PageRequest) ([]*model.Post, error)
+	getCountFunc                 func(ctx context.Context, filter model.PostFilter) (int, error)
+	updateFunc                   func(ctx context.Context, post *model.Post) error
+	createRevisionFunc           func(ctx context.Context, rev *model.PostRevision) error
+	getRevisionsFunc             func(ctx context.Context, postID int, limit, offset int) ([]*model.PostRevision, error)
+	getRevisionFunc              func(ctx context.Context, postID, revision int) (*model.PostRevision, error)
+	deleteFunc                   func(ctx context.Context, id int, deletedAt time.Time) error
+	restoreFunc                  func(ctx context.Context, id int) error
+	deleteByAuthorFunc           func(ctx context.Context, authorID int, deletedAt time.Time) error
+	restoreByAuthorFunc          func(ctx context.Context, authorID int, deletedAt time.Time) error
+	getDeletedFunc               func(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error)
+	getDeletedByIDFunc           func(ctx context.Context, id int) (*model.Post, error)
+	setModerateCommentsFunc      func(ctx context.Context, id int, enabled bool) error
+	getPendingFunc               func(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error)
+	setStatusFunc                func(ctx context.Context, id int, status string) error
 }
 
 func (m *mockPostRepo) Create(ctx context.Context, post *model.Post) error {
@@ -48,6 +66,27 @@ func (m *mockPostRepo) GetTotalCount(ctx context.Context) (int, error) {
 	return 0, nil
 }
 
+func (m *mockPostRepo) SetModerateComments(ctx context.Context, id int, enabled bool) error {
+	if m.setModerateCommentsFunc != nil {
+		return m.setModerateCommentsFunc(ctx, id, enabled)
+	}
+	return nil
+}
+
+func (m *mockPostRepo) GetPending(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error) {
+	if m.getPendingFunc != nil {
+		return m.getPendingFunc(ctx, authorID, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *mockPostRepo) SetStatus(ctx context.Context, id int, status string) error {
+	if m.setStatusFunc != nil {
+		return m.setStatusFunc(ctx, id, status)
+	}
+	return nil
+}
+
 func (m *mockPostRepo) Exists(ctx context.Context, id int) (bool, error) {
 	if m.existsFunc != nil {
 		return m.existsFunc(ctx, id)
@@ -69,6 +108,90 @@ func (m *mockPostRepo) GetTotalCountByAuthorID(ctx context.Context, authorID int
 	return 0, nil
 }
 
+func (m *mockPostRepo) GetPage(ctx context.Context, filter model.PostFilter, page model.PageRequest) ([]*model.Post, error) {
+	if m.getPageFunc != nil {
+		return m.getPageFunc(ctx, filter, page)
+	}
+	return nil, nil
+}
+
+func (m *mockPostRepo) GetCount(ctx context.Context, filter model.PostFilter) (int, error) {
+	if m.getCountFunc != nil {
+		return m.getCountFunc(ctx, filter)
+	}
+	return 0, nil
+}
+
+func (m *mockPostRepo) Update(ctx context.Context, post *model.Post) error {
+	if m.updateFunc != nil {
+		return m.updateFunc(ctx, post)
+	}
+	return nil
+}
+
+func (m *mockPostRepo) CreateRevision(ctx context.Context, rev *model.PostRevision) error {
+	if m.createRevisionFunc != nil {
+		return m.createRevisionFunc(ctx, rev)
+	}
+	return nil
+}
+
+func (m *mockPostRepo) GetRevisions(ctx context.Context, postID int, limit, offset int) ([]*model.PostRevision, error) {
+	if m.getRevisionsFunc != nil {
+		return m.getRevisionsFunc(ctx, postID, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *mockPostRepo) GetRevision(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+	if m.getRevisionFunc != nil {
+		return m.getRevisionFunc(ctx, postID, revision)
+	}
+	return nil, apperrors.ErrRevisionNotFound
+}
+
+func (m *mockPostRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, id, deletedAt)
+	}
+	return nil
+}
+
+func (m *mockPostRepo) Restore(ctx context.Context, id int) error {
+	if m.restoreFunc != nil {
+		return m.restoreFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *mockPostRepo) DeleteByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if m.deleteByAuthorFunc != nil {
+		return m.deleteByAuthorFunc(ctx, authorID, deletedAt)
+	}
+	return nil
+}
+
+func (m *mockPostRepo) RestoreByAuthor(ctx context.Context, authorID int, deletedAt time.Time) error {
+	if m.restoreByAuthorFunc != nil {
+		return m.restoreByAuthorFunc(ctx, authorID, deletedAt)
+	}
+	return nil
+}
+
+func (m *mockPostRepo) GetDeleted(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error) {
+	if m.getDeletedFunc != nil {
+		return m.getDeletedFunc(ctx, authorID, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *mockPostRepo) GetDeletedByID(ctx context.Context, id int) (*model.Post, error) {
+	if m.getDeletedByIDFunc != nil {
+		return m.getDeletedByIDFunc(ctx, id)
+	}
+	return nil, apperrors.ErrPostNotFound
+}
+
 func TestPostService_Create_Success(t *testing.T) {
 	mockPostRepo := &mockPostRepo{
 		createFunc: func(ctx context.Context, post *model.Post) error {
@@ -79,7 +202,7 @@ func TestPostService_Create_Success(t *testing.T) {
 	}
 	mockUserRepo := &mockUserRepo{} // Not used in create
 
-	service := NewPostService(mockPostRepo, mockUserRepo)
+	service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
 
 	req := &model.PostCreateRequest{
 		Title:   "Test Title",
@@ -104,7 +227,7 @@ func TestPostService_Create_InvalidRequest(t *testing.T) {
 	mockPostRepo := &mockPostRepo{}
 	mockUserRepo := &mockUserRepo{}
 
-	service := NewPostService(mockPostRepo, mockUserRepo)
+	service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
 
 	req := &model.PostCreateRequest{
 		Title:   "",
@@ -132,7 +255,7 @@ func TestPostService_GetByID_Success(t *testing.T) {
 	}
 	mockUserRepo := &mockUserRepo{}
 
-	service := NewPostService(mockPostRepo, mockUserRepo)
+	service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
 
 	result, err := service.GetByID(context.Background(), 1, 1)
 	if err != nil {
@@ -152,7 +275,7 @@ func TestPostService_GetByID_NotFound(t *testing.T) {
 	}
 	mockUserRepo := &mockUserRepo{}
 
-	service := NewPostService(mockPostRepo, mockUserRepo)
+	service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
 
 	_, err := service.GetByID(context.Background(), 1, 1)
 	if err == nil {
@@ -175,7 +298,7 @@ func TestPostService_GetAll(t *testing.T) {
 	}
 	mockUserRepo := &mockUserRepo{}
 
-	service := NewPostService(mockPostRepo, mockUserRepo)
+	service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
 
 	posts, total, err := service.GetAll(context.Background(), 10, 0)
 	if err != nil {
@@ -205,7 +328,7 @@ func TestPostService_GetByAuthor(t *testing.T) {
 	}
 	mockUserRepo := &mockUserRepo{}
 
-	service := NewPostService(mockPostRepo, mockUserRepo)
+	service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
 
 	posts, total, err := service.GetByAuthor(context.Background(), 1, 10, 0)
 	if err != nil {
@@ -220,3 +343,536 @@ func TestPostService_GetByAuthor(t *testing.T) {
 		t.Errorf("expected total 1, got %d", total)
 	}
 }
+
+func TestPostService_ListPage(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	post := func(id int) *model.Post {
+		return &model.Post{ID: id, CreatedAt: base.Add(time.Duration(id) * time.Minute)}
+	}
+
+	var got model.PageRequest
+	mockPostRepo := &mockPostRepo{
+		getPageFunc: func(ctx context.Context, filter model.PostFilter, page model.PageRequest) ([]*model.Post, error) {
+			got = page
+			// Newest first; the repository returns one extra row when more pages exist
+			return []*model.Post{post(5), post(4), post(3)}, nil
+		},
+	}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	posts, info, err := service.ListPage(context.Background(), model.PostFilter{}, model.PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Limit != 3 {
+		t.Errorf("expected repository to be asked for limit+1 rows, got %d", got.Limit)
+	}
+	if len(posts) != 2 || posts[0].ID != 5 || posts[1].ID != 4 {
+		t.Fatalf("unexpected first page %+v", posts)
+	}
+	if info.Prev != nil || info.Next == nil || info.Next.ID != 4 {
+		t.Errorf("expected only next position at post 4, got %+v", info)
+	}
+	if info.Total != nil {
+		t.Error("expected total to be omitted unless requested")
+	}
+
+	// Going back from post 3: the extra row is the newest one and must be dropped
+	position := model.Keyset{CreatedAt: post(3).CreatedAt, ID: 3}
+	posts, info, err = service.ListPage(context.Background(), model.PostFilter{}, model.PageRequest{Position: &position, Backward: true, Limit: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != 4 || posts[1].ID != 3 {
+		t.Fatalf("unexpected previous page %+v", posts)
+	}
+	if info.Prev == nil || info.Prev.ID != 4 || info.Next == nil || info.Next.ID != 3 {
+		t.Errorf("expected prev at 4 and next at 3, got %+v", info)
+	}
+}
+
+func TestPostService_Update_SavesRevision(t *testing.T) {
+	var updated *model.Post
+	var saved *model.PostRevision
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, Title: "Old", Content: "old text", ContentFormat: model.ContentFormatMarkdown, AuthorID: 1, Version: 1}, nil
+		},
+		updateFunc: func(ctx context.Context, post *model.Post) error {
+			updated = post
+			return nil
+		},
+		createRevisionFunc: func(ctx context.Context, rev *model.PostRevision) error {
+			rev.Revision = 2
+			saved = rev
+			return nil
+		},
+	}
+	publisher := &mockEventPublisher{}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, &mockBroadcaster{}, &mockContentFilter{})
+
+	post, err := service.Update(context.Background(), 1, 5, &model.PostUpdateRequest{Title: "New", Content: "**new** text", Version: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if updated == nil || post.Title != "New" || post.ContentFormat != model.ContentFormatMarkdown {
+		t.Fatalf("expected post to be updated keeping its format, got %+v", post)
+	}
+	if post.ContentHTML != "<p><strong>new</strong> text</p>\n" {
+		t.Errorf("expected content to be re-rendered, got %q", post.ContentHTML)
+	}
+	if saved == nil || saved.PostID != 5 || saved.EditorID != 1 || saved.Content != "**new** text" || saved.RestoredFrom != 0 {
+		t.Errorf("unexpected revision %+v", saved)
+	}
+	if types := publisher.types(); len(types) != 1 || types[0] != model.EventPostUpdated {
+		t.Errorf("expected post.updated event, got %v", types)
+	}
+}
+
+func TestPostService_Update_Permissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		wantErr error
+	}{
+		{"plain user", model.RoleUser, apperrors.ErrForbidden},
+		{"editor", model.RoleEditor, nil},
+		{"admin", model.RoleAdmin, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := 0
+			mockPostRepo := &mockPostRepo{
+				getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+					return &model.Post{ID: id, Title: "Title", Content: "text", ContentFormat: model.ContentFormatPlain, AuthorID: 1, Version: 1}, nil
+				},
+				updateFunc: func(ctx context.Context, post *model.Post) error {
+					updates++
+					return nil
+				},
+			}
+			mockUserRepo := &mockUserRepo{
+				getByIDFunc: func(ctx context.Context, id int) (*model.User, error) {
+					return &model.User{ID: id, Role: tt.role}, nil
+				},
+			}
+			service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+			_, err := service.Update(context.Background(), 2, 5, &model.PostUpdateRequest{Title: "Title", Content: "edited", Version: 1})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			wantUpdates := 0
+			if tt.wantErr == nil {
+				wantUpdates = 1
+			}
+			if updates != wantUpdates {
+				t.Errorf("expected %d updates, got %d", wantUpdates, updates)
+			}
+		})
+	}
+}
+
+func TestPostService_Update_NoChanges(t *testing.T) {
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, Title: "Title", Content: "text", ContentFormat: model.ContentFormatPlain, AuthorID: 1, Version: 1}, nil
+		},
+		updateFunc: func(ctx context.Context, post *model.Post) error {
+			t.Error("expected unchanged post not to be saved")
+			return nil
+		},
+	}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	if _, err := service.Update(context.Background(), 1, 5, &model.PostUpdateRequest{Title: "Title", Content: "text", Version: 1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestPostService_RestoreRevision(t *testing.T) {
+	var saved *model.PostRevision
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, Title: "Current", Content: "current", ContentFormat: model.ContentFormatPlain, AuthorID: 1, Version: 1}, nil
+		},
+		getRevisionFunc: func(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+			return &model.PostRevision{PostID: postID, Revision: revision, Title: "First", Content: "first", ContentFormat: model.ContentFormatPlain}, nil
+		},
+		createRevisionFunc: func(ctx context.Context, rev *model.PostRevision) error {
+			saved = rev
+			return nil
+		},
+	}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	post, err := service.RestoreRevision(context.Background(), 1, 5, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if post.Title != "First" || post.Content != "first" {
+		t.Errorf("expected revision 1 to be restored, got %+v", post)
+	}
+	if saved == nil || saved.RestoredFrom != 1 {
+		t.Errorf("expected restore to be saved as a new revision, got %+v", saved)
+	}
+}
+
+func TestPostService_DiffRevisions(t *testing.T) {
+	contents := map[int]string{1: "one\ntwo\n", 2: "one\n2\n"}
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1, Status: model.PostPublished}, nil
+		},
+		getRevisionFunc: func(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+			content, ok := contents[revision]
+			if !ok {
+				return nil, apperrors.ErrRevisionNotFound
+			}
+			return &model.PostRevision{PostID: postID, Revision: revision, Title: "Title", Content: content}, nil
+		},
+	}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	d, err := service.DiffRevisions(context.Background(), 5, 1, 2, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := "--- revision 1\n+++ revision 2\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n"
+	if d.Diff != want {
+		t.Errorf("expected diff %q, got %q", want, d.Diff)
+	}
+
+	if _, err := service.DiffRevisions(context.Background(), 5, 1, 3, 0); !errors.Is(err, apperrors.ErrRevisionNotFound) {
+		t.Errorf("expected ErrRevisionNotFound, got %v", err)
+	}
+}
+
+func TestPostService_Delete_PublishesEvent(t *testing.T) {
+	var deletedID int
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, Title: "Title", AuthorID: 1, Version: 1}, nil
+		},
+		deleteFunc: func(ctx context.Context, id int, deletedAt time.Time) error {
+			deletedID = id
+			return nil
+		},
+	}
+	publisher := &mockEventPublisher{}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, &mockBroadcaster{}, &mockContentFilter{})
+
+	if err := service.Delete(context.Background(), 1, 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deletedID != 5 {
+		t.Errorf("expected post 5 to be deleted, got %d", deletedID)
+	}
+	if types := publisher.types(); len(types) != 1 || types[0] != model.EventPostDeleted {
+		t.Errorf("expected post.deleted event, got %v", types)
+	}
+}
+
+func TestPostService_Restore_AuthorDeleted(t *testing.T) {
+	restored := false
+	mockPostRepo := &mockPostRepo{
+		getDeletedByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			now := time.Now()
+			return &model.Post{ID: id, AuthorID: 1, DeletedAt: &now}, nil
+		},
+		restoreFunc: func(ctx context.Context, id int) error {
+			restored = true
+			return nil
+		},
+	}
+	mockUserRepo := &mockUserRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.User, error) {
+			if id == 1 {
+				return nil, apperrors.ErrUserNotFound
+			}
+			return &model.User{ID: id, Role: model.RoleAdmin}, nil
+		},
+	}
+	service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	_, err := service.Restore(context.Background(), 2, 5)
+	if !errors.Is(err, apperrors.ErrParentDeleted) {
+		t.Fatalf("expected ErrParentDeleted, got %v", err)
+	}
+	if restored {
+		t.Error("post must not be restored while its author is deleted")
+	}
+}
+
+func TestPostService_ListDeleted_Scope(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		authorID int
+	}{
+		{"plain user sees own posts", model.RoleUser, 7},
+		{"editor sees all posts", model.RoleEditor, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAuthorID := -1
+			mockPostRepo := &mockPostRepo{
+				getDeletedFunc: func(ctx context.Context, authorID int, limit, offset int) ([]*model.Post, error) {
+					gotAuthorID = authorID
+					return nil, nil
+				},
+			}
+			mockUserRepo := &mockUserRepo{
+				getByIDFunc: func(ctx context.Context, id int) (*model.User, error) {
+					return &model.User{ID: id, Role: tt.role}, nil
+				},
+			}
+			service := NewPostService(mockPostRepo, mockUserRepo, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+			if _, err := service.ListDeleted(context.Background(), 7, 10, 0); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if gotAuthorID != tt.authorID {
+				t.Errorf("expected author filter %d, got %d", tt.authorID, gotAuthorID)
+			}
+		})
+	}
+}
+
+func TestPostService_Update_StaleVersion(t *testing.T) {
+	updates := 0
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, Title: "Title", Content: "text", ContentFormat: model.ContentFormatPlain, AuthorID: 1, Version: 3}, nil
+		},
+		updateFunc: func(ctx context.Context, post *model.Post) error {
+			updates++
+			return nil
+		},
+	}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	_, err := service.Update(context.Background(), 1, 5, &model.PostUpdateRequest{Title: "Title", Content: "edited", Version: 2})
+
+	var conflict *apperrors.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if current, ok := conflict.Current.(*model.Post); !ok || current.Version != 3 {
+		t.Errorf("expected current copy at version 3, got %+v", conflict.Current)
+	}
+	if updates != 0 {
+		t.Errorf("expected no updates, got %d", updates)
+	}
+}
+
+func TestPostService_Update_ConcurrentWrite(t *testing.T) {
+	version := 1
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, Title: "Title", Content: "text", ContentFormat: model.ContentFormatPlain, AuthorID: 1, Version: version}, nil
+		},
+		updateFunc: func(ctx context.Context, post *model.Post) error {
+			// Another editor saves between the read and the conditional UPDATE
+			version = 2
+			return apperrors.ErrConflict
+		},
+	}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	_, err := service.Update(context.Background(), 1, 5, &model.PostUpdateRequest{Title: "Title", Content: "edited", Version: 1})
+
+	var conflict *apperrors.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if current, ok := conflict.Current.(*model.Post); !ok || current.Version != 2 {
+		t.Errorf("expected current copy at version 2, got %+v", conflict.Current)
+	}
+}
+
+func TestPostService_Create_SpamFilter(t *testing.T) {
+	var created bool
+	mockPostRepo := &mockPostRepo{
+		createFunc: func(ctx context.Context, post *model.Post) error {
+			created = true
+			post.ID = 3
+			return nil
+		},
+	}
+	filter := &mockContentFilter{verdict: spam.Verdict{Action: spam.Reject, Score: 12}}
+	publisher := &mockEventPublisher{}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, &mockBroadcaster{}, filter)
+	req := &model.PostCreateRequest{Title: "Cheap watches", Content: "Visit shop.xyz"}
+
+	if _, err := service.Create(context.Background(), 1, req); !errors.Is(err, apperrors.ErrContentRejected) {
+		t.Fatalf("expected ErrContentRejected, got %v", err)
+	}
+	if created {
+		t.Error("expected rejected post not to be saved")
+	}
+	if len(filter.inputs) != 1 || filter.inputs[0].Kind != spam.KindPost || !strings.Contains(filter.inputs[0].Text, "Cheap watches") {
+		t.Errorf("expected post title and content to be checked, got %+v", filter.inputs)
+	}
+
+	// A suspicious post is flagged and held for moderation without reaching the stream
+	filter.verdict = spam.Verdict{Action: spam.Moderate, Score: 6, Hits: []spam.Hit{{Rule: "link_limit", Score: 6}}}
+	stream := &mockBroadcaster{}
+	service = NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, stream, filter)
+	post, err := service.Create(context.Background(), 1, req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if post.Status != model.PostPending {
+		t.Errorf("expected suspicious post to be pending, got %s", post.Status)
+	}
+	// Held posts must not reach webhooks and other outbox sinks until approved
+	types := publisher.types()
+	if len(types) != 1 || types[0] != model.EventContentFlagged {
+		t.Errorf("expected only content.flagged event, got %v", types)
+	}
+	if len(stream.messages) != 0 {
+		t.Errorf("expected no broadcast for pending post, got %v", stream.messages)
+	}
+}
+
+func TestPostService_GetByID_PendingVisibleToAuthorAndEditors(t *testing.T) {
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1, Status: model.PostPending}, nil
+		},
+	}
+	users := usersWithRoles(map[int]string{1: model.RoleUser, 2: model.RoleUser, 3: model.RoleEditor})
+	service := NewPostService(mockPostRepo, users, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+
+	for _, requestorID := range []int{0, 2} {
+		if _, err := service.GetByID(context.Background(), 5, requestorID); !errors.Is(err, apperrors.ErrPostNotFound) {
+			t.Errorf("expected pending post to be hidden from user %d, got %v", requestorID, err)
+		}
+	}
+	for _, requestorID := range []int{1, 3} {
+		if _, err := service.GetByID(context.Background(), 5, requestorID); err != nil {
+			t.Errorf("expected pending post to be visible to user %d, got %v", requestorID, err)
+		}
+	}
+}
+
+func TestPostService_Revisions_PendingVisibleToAuthorAndEditors(t *testing.T) {
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1, Status: model.PostPending}, nil
+		},
+		getRevisionFunc: func(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+			return &model.PostRevision{PostID: postID, Revision: revision, Title: "Cheap watches", Content: "Visit shop.xyz"}, nil
+		},
+	}
+	users := usersWithRoles(map[int]string{1: model.RoleUser, 2: model.RoleUser, 3: model.RoleEditor})
+	service := NewPostService(mockPostRepo, users, &mockTxManager{}, &mockEventPublisher{}, &mockBroadcaster{}, &mockContentFilter{})
+	ctx := context.Background()
+
+	// Anonymous readers and other users must not see the held text through the history
+	for _, requestorID := range []int{0, 2} {
+		if _, err := service.ListRevisions(ctx, 5, requestorID, 20, 0); !errors.Is(err, apperrors.ErrPostNotFound) {
+			t.Errorf("expected revisions to be hidden from user %d, got %v", requestorID, err)
+		}
+		if _, err := service.GetRevision(ctx, 5, 1, requestorID); !errors.Is(err, apperrors.ErrPostNotFound) {
+			t.Errorf("expected revision to be hidden from user %d, got %v", requestorID, err)
+		}
+		if _, err := service.DiffRevisions(ctx, 5, 1, 2, requestorID); !errors.Is(err, apperrors.ErrPostNotFound) {
+			t.Errorf("expected diff to be hidden from user %d, got %v", requestorID, err)
+		}
+	}
+	for _, requestorID := range []int{1, 3} {
+		if _, err := service.GetRevision(ctx, 5, 1, requestorID); err != nil {
+			t.Errorf("expected revision to be visible to user %d, got %v", requestorID, err)
+		}
+		if _, err := service.DiffRevisions(ctx, 5, 1, 2, requestorID); err != nil {
+			t.Errorf("expected diff to be visible to user %d, got %v", requestorID, err)
+		}
+	}
+}
+
+func TestPostService_Update_SpamFilter(t *testing.T) {
+	var updated *model.Post
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, Title: "Old", Content: "old text", Summary: "About", AuthorID: 1, Version: 1, Status: model.PostPublished}, nil
+		},
+		updateFunc: func(ctx context.Context, post *model.Post) error {
+			updated = post
+			return nil
+		},
+		getRevisionFunc: func(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+			return &model.PostRevision{PostID: postID, Revision: revision, Title: "Cheap watches", Content: "Visit shop.xyz"}, nil
+		},
+	}
+	filter := &mockContentFilter{verdict: spam.Verdict{Action: spam.Reject, Score: 12}}
+	publisher := &mockEventPublisher{}
+	stream := &mockBroadcaster{}
+	service := NewPostService(mockPostRepo, &mockUserRepo{}, &mockTxManager{}, publisher, stream, filter)
+
+	// Restoring an old revision goes through the same check as an edit
+	if _, err := service.RestoreRevision(context.Background(), 1, 5, 1); !errors.Is(err, apperrors.ErrContentRejected) {
+		t.Fatalf("expected ErrContentRejected, got %v", err)
+	}
+	if updated != nil {
+		t.Error("expected rejected edit not to be saved")
+	}
+	if len(filter.inputs) != 1 || filter.inputs[0].Kind != spam.KindPost || !strings.Contains(filter.inputs[0].Text, "About") {
+		t.Errorf("expected title, summary and content to be checked, got %+v", filter.inputs)
+	}
+
+	// A suspicious edit takes the post off the feeds until a moderator approves it
+	filter.verdict = spam.Verdict{Action: spam.Moderate, Score: 6, Hits: []spam.Hit{{Rule: "link_limit", Score: 6}}}
+	post, err := service.Update(context.Background(), 1, 5, &model.PostUpdateRequest{Title: "New", Content: "Visit shop.xyz", Version: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if post.Status != model.PostPending || updated == nil || updated.Status != model.PostPending {
+		t.Errorf("expected edited post to be pending, got %+v", post)
+	}
+	if types := publisher.types(); len(types) != 1 || types[0] != model.EventContentFlagged {
+		t.Errorf("expected only content.flagged event, got %v", types)
+	}
+	if messages := stream.messages[TopicPosts]; len(messages) != 1 || messages[0].Event != string(model.EventPostDeleted) {
+		t.Errorf("expected the post to be removed from the stream, got %v", stream.messages)
+	}
+}
+
+func TestPostService_Approve(t *testing.T) {
+	var status string
+	mockPostRepo := &mockPostRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Post, error) {
+			return &model.Post{ID: id, AuthorID: 1, Status: model.PostPending}, nil
+		},
+		setStatusFunc: func(ctx context.Context, id int, s string) error {
+			status = s
+			return nil
+		},
+	}
+	users := usersWithRoles(map[int]string{1: model.RoleUser, 2: model.RoleEditor})
+	publisher := &mockEventPublisher{}
+	stream := &mockBroadcaster{}
+	service := NewPostService(mockPostRepo, users, &mockTxManager{}, publisher, stream, &mockContentFilter{})
+
+	if _, err := service.Approve(context.Background(), 1, 5); !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("expected authors not to approve own posts, got %v", err)
+	}
+
+	post, err := service.Approve(context.Background(), 2, 5)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if status != model.PostPublished || post.Status != model.PostPublished {
+		t.Errorf("expected post to be published, got status %q", status)
+	}
+	if types := publisher.types(); len(types) != 2 || types[0] != model.EventPostApproved || types[1] != model.EventPostCreated {
+		t.Errorf("expected post.approved and post.created events, got %v", types)
+	}
+	if messages := stream.messages[TopicPosts]; len(messages) != 1 || messages[0].Event != string(model.EventPostCreated) {
+		t.Errorf("expected approved post to reach the stream as new, got %v", stream.messages)
+	}
+}
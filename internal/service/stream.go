@@ -0,0 +1,90 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/pubsub"
+	"advanced-blog-management-system/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// TopicPosts - тема хаба с новыми постами
+const TopicPosts = "posts"
+
+// CommentsTopic возвращает тему хаба с новыми комментариями поста
+func CommentsTopic(postID int) string {
+	return "posts/" + strconv.Itoa(postID) + "/comments"
+}
+
+// StreamLog - журнал событий потоков; реализуется repository.StreamRepo
+type StreamLog interface {
+	Append(ctx context.Context, topic, event string, data []byte) (int64, error)
+	After(ctx context.Context, topic string, afterID int64, limit int) ([]pubsub.Message, error)
+}
+
+// Broadcaster рассылает изменения подписчикам потоков в реальном времени. Вызванный внутри
+// TxManager.WithinTx, Broadcast становится частью транзакции: событие получает номер в журнале
+// темы, а подписчики получают его только после фиксации. Номера в теме идут без пропусков
+// в порядке фиксации, поэтому клиент возобновляет поток с номера последнего события через After
+type Broadcaster interface {
+	Broadcast(ctx context.Context, topic string, eventType model.EventType, data []byte) error
+	After(ctx context.Context, topic string, afterID int64, limit int) ([]pubsub.Message, error)
+}
+
+// Stream - Broadcaster поверх журнала StreamLog и брокера сообщений
+type Stream struct {
+	log    StreamLog
+	broker pubsub.Broker
+}
+
+var _ Broadcaster = (*Stream)(nil)
+
+func NewStream(log StreamLog, broker pubsub.Broker) *Stream {
+	return &Stream{log: log, broker: broker}
+}
+
+func (s *Stream) Broadcast(ctx context.Context, topic string, eventType model.EventType, data []byte) error {
+	id, err := s.log.Append(ctx, topic, string(eventType), data)
+	if err != nil {
+		return err
+	}
+	repository.AfterCommit(ctx, func() {
+		s.broker.Publish(topic, pubsub.Message{ID: id, Event: string(eventType), Data: data})
+	})
+	return nil
+}
+
+func (s *Stream) After(ctx context.Context, topic string, afterID int64, limit int) ([]pubsub.Message, error) {
+	return s.log.After(ctx, topic, afterID, limit)
+}
+
+// broadcast вызывается в транзакции изменения, чтобы событие получило номер в порядке фиксации,
+// а подписчики не увидели откатанные данные
+func broadcast(ctx context.Context, b Broadcaster, topic string, eventType model.EventType, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode stream message: %w", err)
+	}
+	if err := b.Broadcast(ctx, topic, eventType, data); err != nil {
+		return fmt.Errorf("failed to broadcast %s: %w", eventType, err)
+	}
+	return nil
+}
+
+// streamAfter нормализует параметры догрузки потока и читает события темы после afterID
+func streamAfter(ctx context.Context, b Broadcaster, topic string, afterID int64, limit int) ([]pubsub.Message, error) {
+	if afterID < 0 {
+		afterID = 0
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	messages, err := b.After(ctx, topic, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream events: %w", err)
+	}
+	return messages, nil
+}
@@ -2,27 +2,43 @@ package service
 
 import (
 	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/logger"
 	"advanced-blog-management-system/internal/model"
 	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/tracing"
 	"advanced-blog-management-system/pkg/auth"
 	"context"
 	"errors"
 	"fmt"
+	"time"
 )
 
 type UserService struct {
-	userRepo   repository.UserRepository
-	jwtManager *auth.JWTManager
+	userRepo    repository.UserRepository
+	postRepo    repository.PostRepository
+	commentRepo repository.CommentRepository
+	webhookRepo repository.WebhookRepository
+	jwtManager  *auth.JWTManager
+	tx          repository.TxManager
+	events      EventPublisher
 }
 
-func NewUserService(userRepo repository.UserRepository, jwtManager *auth.JWTManager) *UserService {
+func NewUserService(userRepo repository.UserRepository, postRepo repository.PostRepository, commentRepo repository.CommentRepository, webhookRepo repository.WebhookRepository, jwtManager *auth.JWTManager, tx repository.TxManager, events EventPublisher) *UserService {
 	return &UserService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:    userRepo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		webhookRepo: webhookRepo,
+		jwtManager:  jwtManager,
+		tx:          tx,
+		events:      events,
 	}
 }
 
-func (s *UserService) Register(ctx context.Context, req *model.UserCreateRequest) (*model.TokenResponse, error) {
+func (s *UserService) Register(ctx context.Context, req *model.UserCreateRequest) (_ *model.TokenResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer func() { tracing.End(span, err) }()
+
 	// 1. Валидация входных данных
 	if err := req.Validate(); err != nil {
 		return nil, err
@@ -60,12 +76,19 @@ func (s *UserService) Register(ctx context.Context, req *model.UserCreateRequest
 	}
 
 	// 6. Сохранение пользователя
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return s.events.Publish(ctx, model.NewUserRegisteredEvent(user))
+	})
+	if err != nil {
+		return nil, err
 	}
+	logger.FromContext(ctx).Info("user registered", "user_id", user.ID)
 
 	// 7. Генерация JWT токена
-	token, expiresAt, err := s.jwtManager.GenerateToken(user.ID, user.Email, user.Username)
+	token, expiresAt, err := s.jwtManager.GenerateToken(user.ID, user.Email, user.Username, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
@@ -78,7 +101,10 @@ func (s *UserService) Register(ctx context.Context, req *model.UserCreateRequest
 	}, nil
 }
 
-func (s *UserService) Login(ctx context.Context, req *model.UserLoginRequest) (*model.TokenResponse, error) {
+func (s *UserService) Login(ctx context.Context, req *model.UserLoginRequest) (_ *model.TokenResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer func() { tracing.End(span, err) }()
+
 	// 1. Валидация входных данных
 	if err := req.Validate(); err != nil {
 		return nil, err
@@ -88,6 +114,7 @@ func (s *UserService) Login(ctx context.Context, req *model.UserLoginRequest) (*
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrUserNotFound) {
+			publishBestEffort(ctx, s.events, model.NewLoginFailedEvent(0, req.Email, "unknown_email"))
 			return nil, apperrors.ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -95,15 +122,19 @@ func (s *UserService) Login(ctx context.Context, req *model.UserLoginRequest) (*
 
 	// 3. Проверка пароля
 	if !auth.CheckPassword(req.Password, user.Password) {
+		logger.FromContext(ctx).Warn("login failed: invalid password", "user_id", user.ID)
+		publishBestEffort(ctx, s.events, model.NewLoginFailedEvent(user.ID, req.Email, "invalid_password"))
 		return nil, apperrors.ErrInvalidCredentials
 	}
 
 	// 4. Генерация JWT токена
-	token, expiresAt, err := s.jwtManager.GenerateToken(user.ID, user.Email, user.Username)
+	token, expiresAt, err := s.jwtManager.GenerateToken(user.ID, user.Email, user.Username, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
+	publishBestEffort(ctx, s.events, model.NewUserLoggedInEvent(user))
+
 	// 5. Возврат TokenResponse
 	return &model.TokenResponse{
 		Token:     token,
@@ -112,7 +143,10 @@ func (s *UserService) Login(ctx context.Context, req *model.UserLoginRequest) (*
 	}, nil
 }
 
-func (s *UserService) GetByID(ctx context.Context, id int) (*model.User, error) {
+func (s *UserService) GetByID(ctx context.Context, id int) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetByID")
+	defer func() { tracing.End(span, err) }()
+
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -120,10 +154,146 @@ func (s *UserService) GetByID(ctx context.Context, id int) (*model.User, error)
 	return user, nil
 }
 
-func (s *UserService) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+func (s *UserService) GetByEmail(ctx context.Context, email string) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetByEmail")
+	defer func() { tracing.End(span, err) }()
+
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
+
+// ChangeRole меняет роль пользователя; вызывается администратором actorID.
+// Администратор не может менять собственную роль, чтобы случайно не остаться без администраторов
+func (s *UserService) ChangeRole(ctx context.Context, actorID, userID int, req *model.UserRoleUpdateRequest) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.ChangeRole")
+	defer func() { tracing.End(span, err) }()
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if actorID == userID {
+		return nil, apperrors.ErrForbidden
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == req.Role {
+		return user, nil
+	}
+
+	oldRole := user.Role
+	user.Role = req.Role
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdateRole(ctx, userID, req.Role); err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		return s.events.Publish(ctx, model.NewUserRoleChangedEvent(actorID, user, oldRole))
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("user role changed", "target_user_id", userID, "old_role", oldRole, "new_role", user.Role)
+
+	return user, nil
+}
+
+// Delete переносит пользователя в корзину вместе с его постами, комментариями и вебхуками; вызывается
+// администратором actorID. Все записи помечаются одним временем, чтобы восстановление
+// вернуло ровно то, что было удалено вместе с пользователем. Удалить себя нельзя
+func (s *UserService) Delete(ctx context.Context, actorID, userID int) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	if actorID == userID {
+		return apperrors.ErrForbidden
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, userID, deletedAt); err != nil {
+			return err
+		}
+		if err := s.postRepo.DeleteByAuthor(ctx, userID, deletedAt); err != nil {
+			return fmt.Errorf("failed to delete user posts: %w", err)
+		}
+		if err := s.commentRepo.DeleteByAuthor(ctx, userID, deletedAt); err != nil {
+			return fmt.Errorf("failed to delete user comments: %w", err)
+		}
+		if err := s.webhookRepo.DeleteByOwner(ctx, userID, deletedAt); err != nil {
+			return fmt.Errorf("failed to delete user webhooks: %w", err)
+		}
+		return s.events.Publish(ctx, model.NewUserDeletedEvent(actorID, user))
+	})
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("user deleted", "target_user_id", userID)
+
+	return nil
+}
+
+// Restore возвращает пользователя из корзины вместе с постами, комментариями и вебхуками,
+// удаленными одновременно с ним; вызывается администратором actorID
+func (s *UserService) Restore(ctx context.Context, actorID, userID int) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Restore")
+	defer func() { tracing.End(span, err) }()
+
+	var user *model.User
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		deletedAt, err := s.userRepo.Restore(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.postRepo.RestoreByAuthor(ctx, userID, deletedAt); err != nil {
+			return fmt.Errorf("failed to restore user posts: %w", err)
+		}
+		if err := s.commentRepo.RestoreByAuthor(ctx, userID, deletedAt); err != nil {
+			return fmt.Errorf("failed to restore user comments: %w", err)
+		}
+		if err := s.webhookRepo.RestoreByOwner(ctx, userID, deletedAt); err != nil {
+			return fmt.Errorf("failed to restore user webhooks: %w", err)
+		}
+		if user, err = s.userRepo.GetByID(ctx, userID); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, model.NewUserRestoredEvent(actorID, user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("user restored", "target_user_id", userID)
+
+	return user, nil
+}
+
+// ListDeleted возвращает пользователей из корзины, от недавно удаленных к старым
+func (s *UserService) ListDeleted(ctx context.Context, limit, offset int) (_ []*model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListDeleted")
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	users, err := s.userRepo.GetDeleted(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted users: %w", err)
+	}
+	return users, nil
+}
@@ -10,4 +10,8 @@ type UserServiceInterface interface {
 	Register(ctx context.Context, req *model.UserCreateRequest) (*model.TokenResponse, error)
 	Login(ctx context.Context, req *model.UserLoginRequest) (*model.TokenResponse, error)
 	GetByID(ctx context.Context, id int) (*model.User, error)
+	ChangeRole(ctx context.Context, actorID, userID int, req *model.UserRoleUpdateRequest) (*model.User, error)
+	Delete(ctx context.Context, actorID, userID int) error
+	Restore(ctx context.Context, actorID, userID int) (*model.User, error)
+	ListDeleted(ctx context.Context, limit, offset int) ([]*model.User, error)
 }
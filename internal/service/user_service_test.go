@@ -1,6 +1,7 @@
 package service
 
 import (
+	"advanced-blog-management-system/internal/errors/apperrors"
 	"advanced-blog-management-system/internal/model"
 	"advanced-blog-management-system/pkg/auth"
 	"context"
@@ -18,7 +19,10 @@ type mockUserRepo struct {
 	existsByEmailFunc     func(ctx context.Context, email string) (bool, error)
 	existsByUsernameFunc  func(ctx context.Context, username string) (bool, error)
 	updateFunc            func(ctx context.Context, user *model.User) error
-	deleteFunc            func(ctx context.Context, id int) error
+	updateRoleFunc        func(ctx context.Context, id int, role string) error
+	deleteFunc            func(ctx context.Context, id int, deletedAt time.Time) error
+	restoreFunc           func(ctx context.Context, id int) (time.Time, error)
+	getDeletedFunc        func(ctx context.Context, limit, offset int) ([]*model.User, error)
 }
 
 func (m *mockUserRepo) Create(ctx context.Context, user *model.User) error {
@@ -70,10 +74,87 @@ func (m *mockUserRepo) Update(ctx context.Context, user *model.User) error {
 	return nil
 }
 
-func (m *mockUserRepo) Delete(ctx context.Context, id int) error {
+func (m *mockUserRepo) UpdateRole(ctx context.Context, id int, role string) error {
+	if m.updateRoleFunc != nil {
+		return m.updateRoleFunc(ctx, id, role)
+	}
+	return nil
+}
+
+func (m *mockUserRepo) Delete(ctx context.Context, id int, deletedAt time.Time) error {
 	if m.deleteFunc != nil {
-		return m.deleteFunc(ctx, id)
+		return m.deleteFunc(ctx, id, deletedAt)
+	}
+	return nil
+}
+
+func (m *mockUserRepo) Restore(ctx context.Context, id int) (time.Time, error) {
+	if m.restoreFunc != nil {
+		return m.restoreFunc(ctx, id)
+	}
+	return time.Time{}, apperrors.ErrUserNotFound
+}
+
+func (m *mockUserRepo) GetDeleted(ctx context.Context, limit, offset int) ([]*model.User, error) {
+	if m.getDeletedFunc != nil {
+		return m.getDeletedFunc(ctx, limit, offset)
+	}
+	return nil, nil
+}
+
+// mockWebhookRepo is a mock implementation of WebhookRepository recording owner trash operations
+type mockWebhookRepo struct {
+	deletedAt  map[int]time.Time
+	restoredAt map[int]time.Time
+}
+
+func (m *mockWebhookRepo) Create(ctx context.Context, webhook *model.Webhook) error { return nil }
+
+func (m *mockWebhookRepo) GetByID(ctx context.Context, id int) (*model.Webhook, error) {
+	return nil, apperrors.ErrWebhookNotFound
+}
+
+func (m *mockWebhookRepo) List(ctx context.Context) ([]*model.Webhook, error) { return nil, nil }
+
+func (m *mockWebhookRepo) ListActive(ctx context.Context) ([]*model.Webhook, error) { return nil, nil }
+
+func (m *mockWebhookRepo) Update(ctx context.Context, webhook *model.Webhook) error { return nil }
+
+func (m *mockWebhookRepo) Delete(ctx context.Context, id int) error { return nil }
+
+func (m *mockWebhookRepo) DeleteByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error {
+	if m.deletedAt == nil {
+		m.deletedAt = make(map[int]time.Time)
+	}
+	m.deletedAt[ownerID] = deletedAt
+	return nil
+}
+
+func (m *mockWebhookRepo) RestoreByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error {
+	if m.restoredAt == nil {
+		m.restoredAt = make(map[int]time.Time)
 	}
+	m.restoredAt[ownerID] = deletedAt
+	return nil
+}
+
+func (m *mockWebhookRepo) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	return nil
+}
+
+func (m *mockWebhookRepo) ClaimDeliveries(ctx context.Context, limit int, lockedUntil time.Time) ([]*model.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (m *mockWebhookRepo) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return nil
+}
+
+func (m *mockWebhookRepo) ListDeliveries(ctx context.Context, webhookID int, limit, offset int) ([]*model.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (m *mockWebhookRepo) Redeliver(ctx context.Context, webhookID int, deliveryID int64) error {
 	return nil
 }
 
@@ -96,7 +177,7 @@ func TestUserService_Register_Success(t *testing.T) {
 	}
 	jwtManager := auth.NewJWTManager("test-secret", 24)
 
-	service := NewUserService(mockRepo, jwtManager)
+	service := NewUserService(mockRepo, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, jwtManager, &mockTxManager{}, &mockEventPublisher{})
 
 	req := &model.UserCreateRequest{
 		Username: "testuser",
@@ -126,7 +207,7 @@ func TestUserService_Register_EmailExists(t *testing.T) {
 	}
 	jwtManager := auth.NewJWTManager("test-secret", 24)
 
-	service := NewUserService(mockRepo, jwtManager)
+	service := NewUserService(mockRepo, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, jwtManager, &mockTxManager{}, &mockEventPublisher{})
 
 	req := &model.UserCreateRequest{
 		Username: "testuser",
@@ -156,7 +237,7 @@ func TestUserService_Login_Success(t *testing.T) {
 	}
 	jwtManager := auth.NewJWTManager("test-secret", 24)
 
-	service := NewUserService(mockRepo, jwtManager)
+	service := NewUserService(mockRepo, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, jwtManager, &mockTxManager{}, &mockEventPublisher{})
 
 	req := &model.UserLoginRequest{
 		Email:    "test@example.com",
@@ -189,7 +270,7 @@ func TestUserService_Login_InvalidPassword(t *testing.T) {
 	}
 	jwtManager := auth.NewJWTManager("test-secret", 24)
 
-	service := NewUserService(mockRepo, jwtManager)
+	service := NewUserService(mockRepo, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, jwtManager, &mockTxManager{}, &mockEventPublisher{})
 
 	req := &model.UserLoginRequest{
 		Email:    "test@example.com",
@@ -201,3 +282,129 @@ func TestUserService_Login_InvalidPassword(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestUserService_ChangeRole(t *testing.T) {
+	var updatedRole string
+	mockRepo := &mockUserRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.User, error) {
+			return &model.User{ID: id, Username: "bob", Role: model.RoleUser}, nil
+		},
+		updateRoleFunc: func(ctx context.Context, id int, role string) error {
+			updatedRole = role
+			return nil
+		},
+	}
+	publisher := &mockEventPublisher{}
+	service := NewUserService(mockRepo, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, auth.NewJWTManager("test-secret", 24), &mockTxManager{}, publisher)
+
+	user, err := service.ChangeRole(context.Background(), 1, 2, &model.UserRoleUpdateRequest{Role: model.RoleEditor})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if updatedRole != model.RoleEditor || user.Role != model.RoleEditor {
+		t.Errorf("expected role editor, got repo=%q user=%q", updatedRole, user.Role)
+	}
+	if len(publisher.events) != 1 || publisher.events[0].Type != model.EventUserRoleChanged || publisher.events[0].ActorID != 1 {
+		t.Errorf("expected user.role_changed event by actor 1, got %+v", publisher.events)
+	}
+}
+
+func TestUserService_ChangeRole_Self(t *testing.T) {
+	service := NewUserService(&mockUserRepo{}, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, auth.NewJWTManager("test-secret", 24), &mockTxManager{}, &mockEventPublisher{})
+
+	_, err := service.ChangeRole(context.Background(), 1, 1, &model.UserRoleUpdateRequest{Role: model.RoleUser})
+	if !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestUserService_Delete_CascadesWithSameTimestamp(t *testing.T) {
+	var userAt, postsAt, commentsAt time.Time
+	mockRepo := &mockUserRepo{
+		getByIDFunc: func(ctx context.Context, id int) (*model.User, error) {
+			return &model.User{ID: id, Username: "bob"}, nil
+		},
+		deleteFunc: func(ctx context.Context, id int, deletedAt time.Time) error {
+			userAt = deletedAt
+			return nil
+		},
+	}
+	posts := &mockPostRepo{
+		deleteByAuthorFunc: func(ctx context.Context, authorID int, deletedAt time.Time) error {
+			postsAt = deletedAt
+			return nil
+		},
+	}
+	comments := &mockCommentRepo{
+		deleteByAuthorFunc: func(ctx context.Context, authorID int, deletedAt time.Time) error {
+			commentsAt = deletedAt
+			return nil
+		},
+	}
+	webhooks := &mockWebhookRepo{}
+	publisher := &mockEventPublisher{}
+	service := NewUserService(mockRepo, posts, comments, webhooks, auth.NewJWTManager("test-secret", 24), &mockTxManager{}, publisher)
+
+	if err := service.Delete(context.Background(), 1, 2); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if userAt.IsZero() || !postsAt.Equal(userAt) || !commentsAt.Equal(userAt) {
+		t.Errorf("expected one deletion timestamp, got user=%v posts=%v comments=%v", userAt, postsAt, commentsAt)
+	}
+	if at, ok := webhooks.deletedAt[2]; !ok || !at.Equal(userAt) {
+		t.Errorf("expected owner webhooks deleted at %v, got %v", userAt, webhooks.deletedAt)
+	}
+	if types := publisher.types(); len(types) != 1 || types[0] != model.EventUserDeleted {
+		t.Errorf("expected user.deleted event, got %v", types)
+	}
+}
+
+func TestUserService_Delete_Self(t *testing.T) {
+	service := NewUserService(&mockUserRepo{}, &mockPostRepo{}, &mockCommentRepo{}, &mockWebhookRepo{}, auth.NewJWTManager("test-secret", 24), &mockTxManager{}, &mockEventPublisher{})
+
+	if err := service.Delete(context.Background(), 1, 1); !errors.Is(err, apperrors.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestUserService_Restore_UsesDeletionTimestamp(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var postsAt, commentsAt time.Time
+	mockRepo := &mockUserRepo{
+		restoreFunc: func(ctx context.Context, id int) (time.Time, error) {
+			return deletedAt, nil
+		},
+		getByIDFunc: func(ctx context.Context, id int) (*model.User, error) {
+			return &model.User{ID: id, Username: "bob"}, nil
+		},
+	}
+	posts := &mockPostRepo{
+		restoreByAuthorFunc: func(ctx context.Context, authorID int, at time.Time) error {
+			postsAt = at
+			return nil
+		},
+	}
+	comments := &mockCommentRepo{
+		restoreByAuthorFunc: func(ctx context.Context, authorID int, at time.Time) error {
+			commentsAt = at
+			return nil
+		},
+	}
+	webhooks := &mockWebhookRepo{}
+	service := NewUserService(mockRepo, posts, comments, webhooks, auth.NewJWTManager("test-secret", 24), &mockTxManager{}, &mockEventPublisher{})
+
+	user, err := service.Restore(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.ID != 2 {
+		t.Errorf("expected user 2, got %d", user.ID)
+	}
+	if !postsAt.Equal(deletedAt) || !commentsAt.Equal(deletedAt) {
+		t.Errorf("expected dependents restored by %v, got posts=%v comments=%v", deletedAt, postsAt, commentsAt)
+	}
+	if at, ok := webhooks.restoredAt[2]; !ok || !at.Equal(deletedAt) {
+		t.Errorf("expected owner webhooks restored by %v, got %v", deletedAt, webhooks.restoredAt)
+	}
+}
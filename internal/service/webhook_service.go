@@ -0,0 +1,159 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/tracing"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// webhookSecretBytes - длина генерируемого секрета подписи
+const webhookSecretBytes = 32
+
+type WebhookService struct {
+	repo repository.WebhookRepository
+}
+
+func NewWebhookService(repo repository.WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// Create создает подписку. Если секрет не передан, он генерируется; секрет возвращается
+// только в ответе на создание
+func (s *WebhookService) Create(ctx context.Context, ownerID int, req *model.WebhookCreateRequest) (_ *model.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	webhook := &model.Webhook{
+		OwnerID: ownerID,
+		URL:     req.URL,
+		Secret:  secret,
+		Events:  req.Events,
+		Active:  true,
+	}
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("webhook created", "webhook_id", webhook.ID, "url", webhook.URL)
+	return webhook, nil
+}
+
+// GetByID возвращает подписку без секрета
+func (s *WebhookService) GetByID(ctx context.Context, id int) (_ *model.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetByID")
+	defer func() { tracing.End(span, err) }()
+
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// List возвращает все подписки без секретов
+func (s *WebhookService) List(ctx context.Context) (_ []*model.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.List")
+	defer func() { tracing.End(span, err) }()
+
+	webhooks, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	for _, w := range webhooks {
+		w.Secret = ""
+	}
+	return webhooks, nil
+}
+
+// Update частично обновляет подписку: меняются только переданные поля
+func (s *WebhookService) Update(ctx context.Context, id int, req *model.WebhookUpdateRequest) (_ *model.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		webhook.Events = *req.Events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// Delete удаляет подписку
+func (s *WebhookService) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("webhook deleted", "webhook_id", id)
+	return nil
+}
+
+// ListDeliveries возвращает журнал доставок подписки
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID int, limit, offset int) (_ []*model.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.repo.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver ставит доставку в очередь повторно, независимо от ее текущего статуса
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID int, deliveryID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Redeliver")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.repo.Redeliver(ctx, webhookID, deliveryID); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("webhook delivery requeued", "webhook_id", webhookID, "delivery_id", deliveryID)
+	return nil
+}
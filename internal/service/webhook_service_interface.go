@@ -0,0 +1,22 @@
+package service
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+)
+
+type WebhookServiceInterface interface {
+	Create(ctx context.Context, ownerID int, req *model.WebhookCreateRequest) (*model.Webhook, error)
+
+	GetByID(ctx context.Context, id int) (*model.Webhook, error)
+
+	List(ctx context.Context) ([]*model.Webhook, error)
+
+	Update(ctx context.Context, id int, req *model.WebhookUpdateRequest) (*model.Webhook, error)
+
+	Delete(ctx context.Context, id int) error
+
+	ListDeliveries(ctx context.Context, webhookID int, limit, offset int) ([]*model.WebhookDelivery, error)
+
+	Redeliver(ctx context.Context, webhookID int, deliveryID int64) error
+}
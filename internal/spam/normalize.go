@@ -0,0 +1,67 @@
+package spam
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// homoglyphs сводит буквы, которые выглядят одинаково в разных алфавитах, и типичные замены
+// букв цифрами и символами к одной латинской букве. Проверяемый текст и словари приводятся
+// к этому "скелету" одинаково, поэтому "cаsino" с кириллической "а" и "c4sino" совпадают с
+// "casino", а "kaзинo" - с "казино"
+var homoglyphs = map[rune]rune{
+	// Кириллица
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	// Греческий
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x',
+	// Цифры и символы
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+// Normalize приводит текст к скелету для сравнения: совместимая декомпозиция Unicode
+// (полноширинные буквы, лигатуры), удаление диакритики и невидимых символов, нижний регистр,
+// замена двойников и схлопывание повторов букв ("spaaam" -> "spam"). Слова разделяются одним пробелом
+func Normalize(text string) string {
+	return strings.Join(tokens(text), " ")
+}
+
+// tokens разбивает нормализованный текст на слова
+func tokens(text string) []string {
+	var words []string
+	var word strings.Builder
+	var last rune
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+		last = 0
+	}
+
+	for _, r := range norm.NFKD.String(text) {
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if g, ok := homoglyphs[r]; ok {
+			r = g
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if r == last {
+			continue
+		}
+		word.WriteRune(r)
+		last = r
+	}
+	flush()
+
+	return words
+}
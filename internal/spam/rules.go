@@ -0,0 +1,260 @@
+package spam
+
+import (
+	"advanced-blog-management-system/internal/repository"
+	"bufio"
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Баллы правил по умолчанию. Они подобраны под пороги Config по умолчанию (5 и 10):
+// одно запрещенное слово или дубликат отправляют на модерацию, два признака - отклоняют
+const (
+	BannedWordScore         = 5
+	ExtraLinkScore          = 3
+	DuplicateScore          = 5
+	NewAccountLinkScore     = 3
+	NewAccountThrottleScore = 10
+)
+
+//go:embed words/*.txt
+var defaultWords embed.FS
+
+// DefaultWords возвращает встроенные списки запрещенных слов на русском и английском
+func DefaultWords() []string {
+	var words []string
+	for _, name := range []string{"words/ru.txt", "words/en.txt"} {
+		f, err := defaultWords.Open(name)
+		if err != nil {
+			panic(fmt.Sprintf("spam: embedded word list %s: %v", name, err))
+		}
+		list, err := ReadWords(f)
+		f.Close()
+		if err != nil {
+			panic(fmt.Sprintf("spam: embedded word list %s: %v", name, err))
+		}
+		words = append(words, list...)
+	}
+	return words
+}
+
+// ReadWordsFile читает список запрещенных слов из файла
+func ReadWordsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word list: %w", err)
+	}
+	defer f.Close()
+	return ReadWords(f)
+}
+
+// ReadWords читает список запрещенных слов и фраз: по одной записи на строку,
+// пустые строки и строки, начинающиеся с #, пропускаются
+func ReadWords(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word list: %w", err)
+	}
+	return words, nil
+}
+
+// BannedWords начисляет баллы за каждое найденное запрещенное слово или фразу.
+// Сравниваются нормализованные слова целиком, поэтому "class" не совпадает с "ass"
+type BannedWords struct {
+	phrases [][]string
+	score   int
+}
+
+func NewBannedWords(words []string) *BannedWords {
+	rule := &BannedWords{score: BannedWordScore}
+	seen := make(map[string]bool)
+	for _, w := range words {
+		phrase := tokens(w)
+		key := strings.Join(phrase, " ")
+		if len(phrase) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		rule.phrases = append(rule.phrases, phrase)
+	}
+	return rule
+}
+
+func (r *BannedWords) Name() string { return "banned_words" }
+
+func (r *BannedWords) Check(ctx context.Context, c *Content) (int, string, error) {
+	var found []string
+	for _, phrase := range r.phrases {
+		if containsPhrase(c.Words, phrase) {
+			found = append(found, strings.Join(phrase, " "))
+		}
+	}
+	if len(found) == 0 {
+		return 0, "", nil
+	}
+	return len(found) * r.score, fmt.Sprintf("banned words: %s", strings.Join(found, ", ")), nil
+}
+
+func containsPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j, w := range phrase {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// linkPattern находит ссылки со схемой, начинающиеся с www и голые домены популярных зон.
+// \b в RE2 понимает только ASCII, поэтому конец домена проверяет findLinks
+var linkPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s<>()\[\]"']+|[\p{L}\d-]+\.(?:com|net|org|info|biz|ru|su|рф|io|me|xyz|top|site|online|club|click|link|shop)(?:/[^\s<>()\[\]"']*)?`)
+
+// findLinks возвращает ссылки исходного текста без повторов
+func findLinks(text string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, loc := range linkPattern.FindAllStringIndex(text, -1) {
+		// "example.community" - не ссылка на example.com
+		if next, _ := utf8.DecodeRuneInString(text[loc[1]:]); unicode.IsLetter(next) || unicode.IsDigit(next) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?"))
+		if !seen[key] {
+			seen[key] = true
+			links = append(links, key)
+		}
+	}
+	return links
+}
+
+// LinkLimit начисляет баллы за каждую ссылку сверх лимита для вида публикации.
+// Для видов без лимита ссылки не ограничиваются
+type LinkLimit struct {
+	limits map[Kind]int
+	score  int
+}
+
+func NewLinkLimit(limits map[Kind]int) *LinkLimit {
+	return &LinkLimit{limits: limits, score: ExtraLinkScore}
+}
+
+func (r *LinkLimit) Name() string { return "link_limit" }
+
+func (r *LinkLimit) Check(ctx context.Context, c *Content) (int, string, error) {
+	limit, ok := r.limits[c.Kind]
+	if !ok || len(c.Links) <= limit {
+		return 0, "", nil
+	}
+	extra := len(c.Links) - limit
+	return extra * r.score, fmt.Sprintf("%d links, limit is %d", len(c.Links), limit), nil
+}
+
+// minDuplicateLength - короче этого нормализованные тексты не сравниваются:
+// короткие ответы вроде "спасибо!" повторяются естественно
+const minDuplicateLength = 20
+
+// duplicateHistory - сколько последних публикаций автора сравнивается с новой
+const duplicateHistory = 50
+
+// Duplicates начисляет баллы, если автор уже публиковал тот же текст в пределах окна.
+// Тексты сравниваются после нормализации, поэтому замена букв двойниками и повторы не помогают
+type Duplicates struct {
+	activity repository.ActivityRepository
+	window   time.Duration
+	score    int
+	now      func() time.Time
+}
+
+func NewDuplicates(activity repository.ActivityRepository, window time.Duration) *Duplicates {
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	return &Duplicates{activity: activity, window: window, score: DuplicateScore, now: time.Now}
+}
+
+func (r *Duplicates) Name() string { return "duplicate" }
+
+func (r *Duplicates) Check(ctx context.Context, c *Content) (int, string, error) {
+	normalized := c.Normalized()
+	if len([]rune(normalized)) < minDuplicateLength {
+		return 0, "", nil
+	}
+
+	recent, err := r.activity.RecentContent(ctx, c.AuthorID, r.now().Add(-r.window), duplicateHistory)
+	if err != nil {
+		return 0, "", err
+	}
+	for _, text := range recent {
+		if Normalize(text) == normalized {
+			return r.score, "duplicates recent content of the author", nil
+		}
+	}
+	return 0, "", nil
+}
+
+// NewAccount ограничивает аккаунты моложе age: ссылки от них подозрительнее, а больше
+// hourlyLimit публикаций в час отклоняются
+type NewAccount struct {
+	activity    repository.ActivityRepository
+	age         time.Duration
+	hourlyLimit int
+	now         func() time.Time
+}
+
+func NewNewAccount(activity repository.ActivityRepository, age time.Duration, hourlyLimit int) *NewAccount {
+	if age <= 0 {
+		age = 24 * time.Hour
+	}
+	if hourlyLimit <= 0 {
+		hourlyLimit = 5
+	}
+	return &NewAccount{activity: activity, age: age, hourlyLimit: hourlyLimit, now: time.Now}
+}
+
+func (r *NewAccount) Name() string { return "new_account" }
+
+func (r *NewAccount) Check(ctx context.Context, c *Content) (int, string, error) {
+	if c.Author == nil || r.now().Sub(c.Author.CreatedAt) >= r.age {
+		return 0, "", nil
+	}
+
+	var score int
+	var reasons []string
+	if len(c.Links) > 0 {
+		score += NewAccountLinkScore
+		reasons = append(reasons, "links from a new account")
+	}
+
+	count, err := r.activity.CountSince(ctx, c.AuthorID, r.now().Add(-time.Hour))
+	if err != nil {
+		return 0, "", err
+	}
+	if count >= r.hourlyLimit {
+		score += NewAccountThrottleScore
+		reasons = append(reasons, fmt.Sprintf("new account exceeded %d publications per hour", r.hourlyLimit))
+	}
+
+	return score, strings.Join(reasons, "; "), nil
+}
@@ -0,0 +1,168 @@
+package spam
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/logger"
+	"advanced-blog-management-system/internal/metrics"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind - вид проверяемой публикации
+type Kind string
+
+const (
+	KindPost    Kind = "post"
+	KindComment Kind = "comment"
+)
+
+// Action - решение фильтра
+type Action string
+
+const (
+	// Allow - публикация проходит без ограничений
+	Allow Action = "allow"
+	// Moderate - публикация отправляется на модерацию
+	Moderate Action = "moderate"
+	// Reject - публикация отклоняется
+	Reject Action = "reject"
+)
+
+// Input - проверяемая публикация
+type Input struct {
+	Kind     Kind
+	AuthorID int
+	Text     string
+}
+
+// Content - публикация, подготовленная для правил: автор, нормализованные слова и ссылки
+// вычисляются один раз на всю цепочку
+type Content struct {
+	Input
+	Author *model.User
+	// Words - слова текста после Normalize
+	Words []string
+	// Links - ссылки, найденные в исходном тексте
+	Links []string
+}
+
+// Normalized возвращает нормализованный текст публикации
+func (c *Content) Normalized() string {
+	return strings.Join(c.Words, " ")
+}
+
+// Rule - правило фильтра. Check возвращает баллы подозрительности и причину;
+// 0 баллов означает, что правило не сработало
+type Rule interface {
+	Name() string
+	Check(ctx context.Context, c *Content) (score int, reason string, err error)
+}
+
+// Hit - сработавшее правило
+type Hit struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// Verdict - итог проверки: сумма баллов всех правил и решение по порогам
+type Verdict struct {
+	Action Action `json:"action"`
+	Score  int    `json:"score"`
+	Hits   []Hit  `json:"hits,omitempty"`
+}
+
+// Config содержит пороги фильтра
+type Config struct {
+	// Enabled - при false все публикации пропускаются без проверки
+	Enabled bool
+	// ModerateScore - сумма баллов, начиная с которой публикация отправляется на модерацию
+	ModerateScore int
+	// RejectScore - сумма баллов, начиная с которой публикация отклоняется
+	RejectScore int
+}
+
+func withDefaults(cfg Config) Config {
+	if cfg.ModerateScore <= 0 {
+		cfg.ModerateScore = 5
+	}
+	if cfg.RejectScore <= 0 {
+		cfg.RejectScore = 10
+	}
+	return cfg
+}
+
+// Filter прогоняет публикацию через цепочку правил и суммирует баллы.
+// Публикации редакторов и администраторов не проверяются
+type Filter struct {
+	users repository.UserRepository
+	rules []Rule
+	cfg   Config
+}
+
+func New(users repository.UserRepository, cfg Config, rules ...Rule) *Filter {
+	return &Filter{
+		users: users,
+		rules: rules,
+		cfg:   withDefaults(cfg),
+	}
+}
+
+// Check проверяет публикацию. Ошибка отдельного правила (например, недоступность БД) только
+// логируется: сбой фильтра не должен блокировать все публикации
+func (f *Filter) Check(ctx context.Context, in Input) (_ *Verdict, err error) {
+	ctx, span := tracing.Start(ctx, "spam.Filter.Check")
+	defer func() { tracing.End(span, err) }()
+
+	if !f.cfg.Enabled {
+		return &Verdict{Action: Allow}, nil
+	}
+
+	author, err := f.users.GetByID(ctx, in.AuthorID)
+	if err != nil && !errors.Is(err, apperrors.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get author: %w", err)
+	}
+	if author != nil && (author.Role == model.RoleEditor || author.Role == model.RoleAdmin) {
+		return &Verdict{Action: Allow}, nil
+	}
+
+	content := &Content{
+		Input:  in,
+		Author: author,
+		Words:  tokens(in.Text),
+		Links:  findLinks(in.Text),
+	}
+
+	verdict := &Verdict{Action: Allow}
+	for _, rule := range f.rules {
+		score, reason, err := rule.Check(ctx, content)
+		if err != nil {
+			logger.FromContext(ctx).Error("spam rule failed", "rule", rule.Name(), "error", err)
+			continue
+		}
+		if score > 0 {
+			verdict.Score += score
+			verdict.Hits = append(verdict.Hits, Hit{Rule: rule.Name(), Score: score, Reason: reason})
+		}
+	}
+
+	switch {
+	case verdict.Score >= f.cfg.RejectScore:
+		verdict.Action = Reject
+	case verdict.Score >= f.cfg.ModerateScore:
+		verdict.Action = Moderate
+	}
+
+	metrics.SpamVerdicts.WithLabelValues(string(in.Kind), string(verdict.Action)).Inc()
+	if verdict.Action != Allow {
+		logger.FromContext(ctx).Info("content flagged by spam filter",
+			"kind", in.Kind, "author_id", in.AuthorID, "action", verdict.Action, "score", verdict.Score, "hits", verdict.Hits)
+	}
+
+	return verdict, nil
+}
@@ -0,0 +1,217 @@
+package spam
+
+import (
+	"advanced-blog-management-system/internal/errors/apperrors"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeUsers serves GetByID from a map; other UserRepository methods are not used by the filter
+type fakeUsers struct {
+	repository.UserRepository
+	users map[int]*model.User
+}
+
+func (f *fakeUsers) GetByID(ctx context.Context, id int) (*model.User, error) {
+	if u, ok := f.users[id]; ok {
+		return u, nil
+	}
+	return nil, apperrors.ErrUserNotFound
+}
+
+type fakeActivity struct {
+	recent []string
+	count  int
+	err    error
+}
+
+func (f *fakeActivity) RecentContent(ctx context.Context, authorID int, since time.Time, limit int) ([]string, error) {
+	return f.recent, f.err
+}
+
+func (f *fakeActivity) CountSince(ctx context.Context, authorID int, since time.Time) (int, error) {
+	return f.count, f.err
+}
+
+type fixedRule struct {
+	score int
+	err   error
+}
+
+func (r fixedRule) Name() string { return "fixed" }
+
+func (r fixedRule) Check(ctx context.Context, c *Content) (int, string, error) {
+	return r.score, "fixed score", r.err
+}
+
+func content(kind Kind, text string) *Content {
+	return &Content{
+		Input: Input{Kind: kind, AuthorID: 1, Text: text},
+		Words: tokens(text),
+		Links: findLinks(text),
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Online CASINO!!!", "online casino"},
+		// Cyrillic "а" and "о" inside a Latin word
+		{"cаsinо", "casino"},
+		{"c4s1n0", "casino"},
+		{"ｃａｓｉｎｏ", "casino"},
+		{"spaaaam", "spam"},
+		{"ca​sino", "casino"},
+		{"Казино", Normalize("kaзинo")},
+		{"café", "cafe"},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBannedWords(t *testing.T) {
+	rule := NewBannedWords(DefaultWords())
+
+	tests := []struct {
+		text  string
+		score int
+	}{
+		{"Best c4sino in town", BannedWordScore},
+		{"Лучшее КАЗИНО и быстрый  заработок", 2 * BannedWordScore},
+		{"бЫстрый зaрабoтoк и cаsino", 2 * BannedWordScore},
+		{"casinos are not matched as a different word", 0},
+		{"быстрый, но честный заработок", 0},
+	}
+
+	for _, tt := range tests {
+		score, _, err := rule.Check(context.Background(), content(KindComment, tt.text))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if score != tt.score {
+			t.Errorf("%q: expected score %d, got %d", tt.text, tt.score, score)
+		}
+	}
+}
+
+func TestFindLinks(t *testing.T) {
+	text := "See https://example.com/a, www.test.org and shop.xyz. Also https://example.com/a again, " +
+		"сайт.рф and example.community"
+
+	want := []string{"https://example.com/a", "www.test.org", "shop.xyz", "сайт.рф"}
+	if got := findLinks(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("findLinks() = %v, want %v", got, want)
+	}
+}
+
+func TestLinkLimit(t *testing.T) {
+	rule := NewLinkLimit(map[Kind]int{KindComment: 1})
+	text := "a.com b.com c.com"
+
+	if score, _, _ := rule.Check(context.Background(), content(KindComment, text)); score != 2*ExtraLinkScore {
+		t.Errorf("expected %d for two extra links, got %d", 2*ExtraLinkScore, score)
+	}
+	if score, _, _ := rule.Check(context.Background(), content(KindPost, text)); score != 0 {
+		t.Errorf("expected no limit for posts, got %d", score)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	activity := &fakeActivity{recent: []string{"Something else entirely", "Buy cheap watches at our store today"}}
+	rule := NewDuplicates(activity, time.Hour)
+
+	score, _, err := rule.Check(context.Background(), content(KindComment, "BUY cheap watches at оur store TODAY!!"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if score != DuplicateScore {
+		t.Errorf("expected duplicate score %d, got %d", DuplicateScore, score)
+	}
+
+	activity.recent = []string{"thanks!"}
+	if score, _, _ := rule.Check(context.Background(), content(KindComment, "Thanks!")); score != 0 {
+		t.Errorf("expected short texts not to be compared, got %d", score)
+	}
+}
+
+func TestNewAccount(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	activity := &fakeActivity{count: 5}
+	rule := NewNewAccount(activity, 24*time.Hour, 5)
+	rule.now = func() time.Time { return now }
+
+	c := content(KindComment, "visit shop.xyz")
+	c.Author = &model.User{ID: 1, CreatedAt: now.Add(-time.Hour)}
+	if score, _, _ := rule.Check(context.Background(), c); score != NewAccountLinkScore+NewAccountThrottleScore {
+		t.Errorf("expected link and throttle scores for a new account, got %d", score)
+	}
+
+	c.Author.CreatedAt = now.Add(-48 * time.Hour)
+	if score, _, _ := rule.Check(context.Background(), c); score != 0 {
+		t.Errorf("expected old accounts not to be limited, got %d", score)
+	}
+}
+
+func TestFilter_Thresholds(t *testing.T) {
+	users := &fakeUsers{users: map[int]*model.User{
+		1: {ID: 1, Role: model.RoleUser},
+		2: {ID: 2, Role: model.RoleEditor},
+	}}
+
+	tests := []struct {
+		name   string
+		cfg    Config
+		author int
+		score  int
+		want   Action
+	}{
+		{"below moderation", Config{Enabled: true}, 1, 4, Allow},
+		{"moderation", Config{Enabled: true}, 1, 5, Moderate},
+		{"rejection", Config{Enabled: true}, 1, 10, Reject},
+		{"custom thresholds", Config{Enabled: true, ModerateScore: 2, RejectScore: 4}, 1, 4, Reject},
+		{"editors are trusted", Config{Enabled: true}, 2, 100, Allow},
+		{"disabled", Config{}, 1, 100, Allow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := New(users, tt.cfg, fixedRule{score: tt.score})
+
+			verdict, err := filter.Check(context.Background(), Input{Kind: KindPost, AuthorID: tt.author, Text: "text"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if verdict.Action != tt.want {
+				t.Errorf("expected %s, got %s (score %d)", tt.want, verdict.Action, verdict.Score)
+			}
+		})
+	}
+}
+
+func TestFilter_RuleErrorIsSkipped(t *testing.T) {
+	users := &fakeUsers{users: map[int]*model.User{1: {ID: 1, Role: model.RoleUser}}}
+	filter := New(users, Config{Enabled: true},
+		fixedRule{score: 100, err: errors.New("db is down")},
+		NewBannedWords([]string{"casino"}),
+	)
+
+	verdict, err := filter.Check(context.Background(), Input{Kind: KindComment, AuthorID: 1, Text: "casino"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if verdict.Action != Moderate || len(verdict.Hits) != 1 || !strings.HasPrefix(verdict.Hits[0].Reason, "banned words") {
+		t.Errorf("expected only the banned words hit, got %+v", verdict)
+	}
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import "testing"
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name: "keeps placeholders and collapses whitespace",
+			query: `
+				SELECT id, title
+				FROM posts
+				WHERE author_id = $1
+				LIMIT $2 OFFSET $3
+			`,
+			want: "SELECT id, title FROM posts WHERE author_id = $1 LIMIT $2 OFFSET $3",
+		},
+		{
+			name:  "replaces literals",
+			query: "SELECT 1 FROM users WHERE email = 'john@example.com' AND id > 10",
+			want:  "SELECT ? FROM users WHERE email = ? AND id > ?",
+		},
+		{
+			name:  "keeps digits inside identifiers",
+			query: "SELECT * FROM schema_migrations WHERE version = '001_init.sql'",
+			want:  "SELECT * FROM schema_migrations WHERE version = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeSQL(tt.query); got != tt.want {
+				t.Errorf("SanitizeSQL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package trash
+
+import (
+	"advanced-blog-management-system/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config содержит настройки очистки корзины
+type Config struct {
+	// Interval - как часто запускать очистку
+	Interval time.Duration
+	// Retention - сколько удаленные записи хранятся в корзине до окончательного удаления
+	Retention time.Duration
+	// BatchSize - сколько строк каждой таблицы удалять за один запрос
+	BatchSize int
+}
+
+// Purger периодически окончательно удаляет записи, пролежавшие в корзине дольше Retention.
+// Удаление идет пакетами, чтобы не держать долгих блокировок на больших корзинах
+type Purger struct {
+	repo repository.TrashRepository
+	cfg  Config
+
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	running atomic.Bool
+
+	now func() time.Time
+}
+
+func NewPurger(repo repository.TrashRepository, cfg Config) *Purger {
+	return &Purger{
+		repo: repo,
+		cfg:  withDefaults(cfg),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		now:  time.Now,
+	}
+}
+
+func withDefaults(cfg Config) Config {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 30 * 24 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	return cfg
+}
+
+func (p *Purger) Start() {
+	p.running.Store(true)
+	go p.run()
+}
+
+// Running сообщает, работает ли горутина очистки
+func (p *Purger) Running() bool {
+	return p.running.Load()
+}
+
+// Stop прекращает очистку; начатый пакет дозавершается
+func (p *Purger) Stop() {
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		slog.Info("trash purger stopped gracefully")
+	})
+}
+
+func (p *Purger) run() {
+	defer close(p.done)
+	defer p.running.Store(false)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if _, err := p.PurgeOnce(context.Background()); err != nil {
+				slog.Error("failed to purge trash", "error", err)
+			}
+		}
+	}
+}
+
+// PurgeOnce удаляет все записи старше срока хранения и возвращает их число по таблицам.
+// Пакеты повторяются, пока хотя бы одна таблица возвращает полный пакет. Таблица, которую
+// не удалось очистить, не мешает остальным; ее ошибка возвращается после завершения прохода
+func (p *Purger) PurgeOnce(ctx context.Context) (map[string]int, error) {
+	before := p.now().Add(-p.cfg.Retention)
+	total := make(map[string]int)
+	var errs []error
+
+	for {
+		select {
+		case <-p.stop:
+			return total, errors.Join(errs...)
+		default:
+		}
+
+		purged, err := p.repo.Purge(ctx, before, p.cfg.BatchSize)
+		for table, n := range purged {
+			total[table] += n
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		full := false
+		for _, n := range purged {
+			if n >= p.cfg.BatchSize {
+				full = true
+			}
+		}
+		if !full {
+			break
+		}
+	}
+
+	for table, n := range total {
+		if n > 0 {
+			slog.Info("trash purged", "table", table, "rows", n, "before", before)
+		}
+	}
+	return total, errors.Join(errs...)
+}
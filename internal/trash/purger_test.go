@@ -0,0 +1,134 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeTrash is an in-memory TrashRepository holding deletion times per table
+type fakeTrash struct {
+	rows   map[string][]time.Time
+	calls  int
+	err    error
+	before []time.Time
+	// blocked tables fail with the given error, the rest are purged as usual
+	blocked map[string]error
+}
+
+func (f *fakeTrash) Purge(ctx context.Context, before time.Time, limit int) (map[string]int, error) {
+	f.calls++
+	f.before = append(f.before, before)
+	if f.err != nil {
+		return nil, f.err
+	}
+	purged := make(map[string]int)
+	var errs []error
+	for table, rows := range f.rows {
+		if err, ok := f.blocked[table]; ok {
+			errs = append(errs, err)
+			continue
+		}
+		var kept []time.Time
+		for _, at := range rows {
+			if at.Before(before) && purged[table] < limit {
+				purged[table]++
+				continue
+			}
+			kept = append(kept, at)
+		}
+		f.rows[table] = kept
+	}
+	return purged, errors.Join(errs...)
+}
+
+func TestPurger_PurgeOnce_DeletesOnlyExpiredInBatches(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-40 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	repo := &fakeTrash{rows: map[string][]time.Time{
+		"posts":    {old, old, old, old, old, recent},
+		"comments": {old, recent},
+	}}
+	p := NewPurger(repo, Config{Retention: 30 * 24 * time.Hour, BatchSize: 2})
+	p.now = func() time.Time { return now }
+
+	total, err := p.PurgeOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total["posts"] != 5 || total["comments"] != 1 {
+		t.Errorf("unexpected purge totals: %v", total)
+	}
+	if len(repo.rows["posts"]) != 1 || len(repo.rows["comments"]) != 1 {
+		t.Errorf("recent rows must stay in trash, got %v", repo.rows)
+	}
+	// 2 + 2 + 1 posts: the third batch is not full, so the loop stops there
+	if repo.calls != 3 {
+		t.Errorf("expected 3 batches, got %d", repo.calls)
+	}
+	if want := now.Add(-30 * 24 * time.Hour); !repo.before[0].Equal(want) {
+		t.Errorf("expected cutoff %v, got %v", want, repo.before[0])
+	}
+}
+
+func TestPurger_PurgeOnce_Error(t *testing.T) {
+	repo := &fakeTrash{err: errors.New("db down")}
+	p := NewPurger(repo, Config{})
+
+	if _, err := p.PurgeOnce(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if repo.calls != 1 {
+		t.Errorf("expected a single attempt, got %d", repo.calls)
+	}
+}
+
+func TestPurger_PurgeOnce_ContinuesPastBlockedTable(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-40 * 24 * time.Hour)
+
+	// A deleted admin still owns a webhook: without ON DELETE CASCADE on webhooks.owner_id
+	// the users batch fails with a foreign key violation on every run
+	ownsWebhook := errors.New(`update or delete on table "users" violates foreign key constraint on table "webhooks"`)
+	repo := &fakeTrash{
+		rows: map[string][]time.Time{
+			"users":    {old},
+			"posts":    {old, old, old},
+			"comments": {old},
+		},
+		blocked: map[string]error{"users": ownsWebhook},
+	}
+	p := NewPurger(repo, Config{Retention: 30 * 24 * time.Hour, BatchSize: 2})
+	p.now = func() time.Time { return now }
+
+	total, err := p.PurgeOnce(context.Background())
+	if !errors.Is(err, ownsWebhook) {
+		t.Fatalf("expected the users error to be reported, got %v", err)
+	}
+	if total["posts"] != 3 || total["comments"] != 1 {
+		t.Errorf("expected other tables purged despite the failure, got %v", total)
+	}
+	if len(repo.rows["users"]) != 1 {
+		t.Errorf("expected blocked user to stay in trash, got %v", repo.rows["users"])
+	}
+	if repo.calls != 2 {
+		t.Errorf("expected batching to go on past the failure, got %d calls", repo.calls)
+	}
+}
+
+func TestPurger_StartStop(t *testing.T) {
+	p := NewPurger(&fakeTrash{rows: map[string][]time.Time{}}, Config{Interval: 10 * time.Millisecond})
+	p.Start()
+	if !p.Running() {
+		t.Fatal("expected purger to be running")
+	}
+	p.Stop()
+	p.Stop()
+	if p.Running() {
+		t.Error("expected purger to be stopped")
+	}
+}
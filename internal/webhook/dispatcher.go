@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"advanced-blog-management-system/internal/metrics"
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"advanced-blog-management-system/pkg/retry"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxResponseBody - сколько байт ответа получателя сохраняется в журнале доставок
+const maxResponseBody = 1024
+
+// DispatcherConfig содержит настройки отправки вебхуков
+type DispatcherConfig struct {
+	// PollInterval - как часто проверять очередь доставок
+	PollInterval time.Duration
+	// BatchSize - сколько доставок захватывать за один раз
+	BatchSize int
+	// MaxAttempts - после стольких неудачных попыток доставка помечается failed
+	MaxAttempts int
+	// BaseBackoff и MaxBackoff задают экспоненциальную задержку между попытками
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Timeout - максимальное время одного HTTP-запроса
+	Timeout time.Duration
+	// LeaseTimeout - на сколько захватывается пакет; после падения реплики доставки
+	// снова станут доступны по истечении аренды. Не меньше двух Timeout
+	LeaseTimeout time.Duration
+}
+
+// Dispatcher отправляет поставленные в очередь доставки получателям. Запрос подписывается
+// секретом вебхука; ответ 2xx считается успехом, остальное повторяется с экспоненциальной задержкой
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	cfg    DispatcherConfig
+
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	running atomic.Bool
+
+	now func() time.Time
+}
+
+func NewDispatcher(repo repository.WebhookRepository, cfg DispatcherConfig) *Dispatcher {
+	cfg = withDispatcherDefaults(cfg)
+	return &Dispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		now:    time.Now,
+	}
+}
+
+func withDispatcherDefaults(cfg DispatcherConfig) DispatcherConfig {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 20
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 5 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.LeaseTimeout < 2*cfg.Timeout {
+		cfg.LeaseTimeout = max(time.Minute, 2*cfg.Timeout)
+	}
+	return cfg
+}
+
+func (d *Dispatcher) Start() {
+	d.running.Store(true)
+	go d.run()
+}
+
+// Running сообщает, работает ли горутина отправки
+func (d *Dispatcher) Running() bool {
+	return d.running.Load()
+}
+
+// Stop прекращает опрос очереди. Неотправленные доставки остаются в БД
+func (d *Dispatcher) Stop() {
+	d.once.Do(func() {
+		close(d.stop)
+		<-d.done
+		d.client.CloseIdleConnections()
+		slog.Info("webhook dispatcher stopped gracefully")
+	})
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	defer d.running.Store(false)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.drain()
+		}
+	}
+}
+
+func (d *Dispatcher) drain() {
+	for {
+		n, err := d.ProcessBatch(context.Background())
+		if err != nil {
+			slog.Error("failed to process webhook deliveries", "error", err)
+			return
+		}
+		if n < d.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// ProcessBatch отправляет один пакет доставок параллельно и сохраняет результаты.
+// Пакет захватывается арендой, запросы идут вне транзакции, а результат каждой доставки
+// сохраняется отдельно: ошибка записи одной строки не отменяет остальные, и неудачная
+// строка будет отправлена повторно после истечения аренды
+func (d *Dispatcher) ProcessBatch(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.ClaimDeliveries(ctx, d.cfg.BatchSize, d.now().Add(d.cfg.LeaseTimeout))
+	if err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, delivery := range deliveries {
+		if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+			errs = append(errs, fmt.Errorf("delivery %d: %w", delivery.ID, err))
+		}
+	}
+	return len(deliveries), errors.Join(errs...)
+}
+
+// attempt выполняет одну попытку и записывает ее результат в delivery
+func (d *Dispatcher) attempt(ctx context.Context, delivery *model.WebhookDelivery) {
+	start := d.now()
+	code, body, err := d.send(ctx, delivery)
+	duration := int(time.Since(start).Milliseconds())
+
+	delivery.Attempts++
+	delivery.DurationMS = &duration
+	delivery.ResponseCode = nil
+	delivery.ResponseBody = body
+	delivery.LastError = ""
+	if code != 0 {
+		delivery.ResponseCode = &code
+	}
+
+	if err == nil {
+		now := d.now()
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		metrics.WebhookDeliveries.WithLabelValues(model.WebhookDeliverySucceeded).Inc()
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+		metrics.WebhookDeliveries.WithLabelValues(model.WebhookDeliveryFailed).Inc()
+		slog.Warn("webhook delivery failed permanently",
+			"webhook_id", delivery.WebhookID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		return
+	}
+
+	delivery.NextAttemptAt = d.now().Add(retry.Backoff(d.cfg.BaseBackoff, d.cfg.MaxBackoff, delivery.Attempts))
+	metrics.WebhookDeliveries.WithLabelValues("retry").Inc()
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *model.WebhookDelivery) (int, string, error) {
+	timestamp := d.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderDelivery, delivery.EventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, responseText(body), fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, responseText(body), nil
+}
+
+// responseText готовит начало ответа получателя к записи в TEXT-колонку: обрезка по
+// maxResponseBody может разрезать символ, а получатель может вернуть двоичные данные,
+// которые PostgreSQL не примет. Некорректные последовательности заменяются на U+FFFD, NUL удаляется
+func responseText(body []byte) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(string(body), "\uFFFD"), "\x00", "")
+}
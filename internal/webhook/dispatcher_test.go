@@ -0,0 +1,357 @@
+package webhook
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// memoryWebhooks is an in-memory WebhookRepository used by dispatcher and sink tests
+type memoryWebhooks struct {
+	mu         sync.Mutex
+	webhooks   []*model.Webhook
+	deliveries []*model.WebhookDelivery
+	// failUpdate makes UpdateDelivery fail for the given delivery ID
+	failUpdate int64
+	saved      map[int64]model.WebhookDelivery
+}
+
+func (m *memoryWebhooks) Create(ctx context.Context, w *model.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.ID = len(m.webhooks) + 1
+	m.webhooks = append(m.webhooks, w)
+	return nil
+}
+
+func (m *memoryWebhooks) GetByID(ctx context.Context, id int) (*model.Webhook, error) {
+	return nil, nil
+}
+
+func (m *memoryWebhooks) List(ctx context.Context) ([]*model.Webhook, error) {
+	return m.webhooks, nil
+}
+
+func (m *memoryWebhooks) ListActive(ctx context.Context) ([]*model.Webhook, error) {
+	var active []*model.Webhook
+	for _, w := range m.webhooks {
+		if w.Active {
+			active = append(active, w)
+		}
+	}
+	return active, nil
+}
+
+func (m *memoryWebhooks) Update(ctx context.Context, w *model.Webhook) error { return nil }
+
+func (m *memoryWebhooks) Delete(ctx context.Context, id int) error { return nil }
+
+func (m *memoryWebhooks) DeleteByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error {
+	return nil
+}
+
+func (m *memoryWebhooks) RestoreByOwner(ctx context.Context, ownerID int, deletedAt time.Time) error {
+	return nil
+}
+
+func (m *memoryWebhooks) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range deliveries {
+		d.ID = int64(len(m.deliveries) + 1)
+		d.Status = model.WebhookDeliveryPending
+		m.deliveries = append(m.deliveries, d)
+	}
+	return nil
+}
+
+func (m *memoryWebhooks) ClaimDeliveries(ctx context.Context, limit int, lockedUntil time.Time) ([]*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var pending []*model.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status != model.WebhookDeliveryPending || d.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		d.NextAttemptAt = lockedUntil
+		for _, w := range m.webhooks {
+			if w.ID == d.WebhookID {
+				d.URL, d.Secret = w.URL, w.Secret
+			}
+		}
+		pending = append(pending, d)
+	}
+	return pending, nil
+}
+
+// UpdateDelivery rejects text PostgreSQL would reject and remembers what was saved
+func (m *memoryWebhooks) UpdateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d.ID == m.failUpdate {
+		return errors.New("update failed")
+	}
+	if !utf8.ValidString(d.ResponseBody) || strings.Contains(d.ResponseBody, "\x00") {
+		return errors.New("invalid byte sequence for encoding UTF8")
+	}
+	if m.saved == nil {
+		m.saved = make(map[int64]model.WebhookDelivery)
+	}
+	m.saved[d.ID] = *d
+	return nil
+}
+
+func (m *memoryWebhooks) ListDeliveries(ctx context.Context, webhookID int, limit, offset int) ([]*model.WebhookDelivery, error) {
+	return m.deliveries, nil
+}
+
+func (m *memoryWebhooks) Redeliver(ctx context.Context, webhookID int, deliveryID int64) error {
+	return nil
+}
+
+func newTestDispatcher(repo *memoryWebhooks, maxAttempts int) *Dispatcher {
+	return NewDispatcher(repo, DispatcherConfig{MaxAttempts: maxAttempts, BaseBackoff: time.Minute})
+}
+
+func enqueue(t *testing.T, repo *memoryWebhooks, url, secret string) {
+	t.Helper()
+	repo.Create(context.Background(), &model.Webhook{URL: url, Secret: secret, Active: true})
+	e := model.NewEvent(model.EventPostCreated, 1, model.EventTarget{Type: "post", ID: 7}, nil)
+	if err := NewSink(repo).Write(context.Background(), []model.Event{e}); err != nil {
+		t.Fatalf("failed to enqueue delivery: %v", err)
+	}
+}
+
+func TestDispatcher_SignsRequest(t *testing.T) {
+	const secret = "0123456789abcdef"
+	var verifyErr atomic.Value
+	var eventHeader atomic.Value
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute); err != nil {
+			verifyErr.Store(err)
+		}
+		eventHeader.Store(r.Header.Get(HeaderEvent))
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	repo := &memoryWebhooks{}
+	enqueue(t, repo, receiver.URL, secret)
+
+	if _, err := newTestDispatcher(repo, 3).ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch failed: %v", err)
+	}
+
+	if err, _ := verifyErr.Load().(error); err != nil {
+		t.Errorf("receiver rejected signature: %v", err)
+	}
+	if got := eventHeader.Load(); got != string(model.EventPostCreated) {
+		t.Errorf("expected %s header %q, got %v", HeaderEvent, model.EventPostCreated, got)
+	}
+
+	d := repo.deliveries[0]
+	if d.Status != model.WebhookDeliverySucceeded || d.Attempts != 1 || d.DeliveredAt == nil {
+		t.Errorf("unexpected delivery state: %+v", d)
+	}
+	if d.ResponseCode == nil || *d.ResponseCode != http.StatusOK || d.ResponseBody != "ok" {
+		t.Errorf("expected logged 200 response, got code=%v body=%q", d.ResponseCode, d.ResponseBody)
+	}
+}
+
+func TestDispatcher_RetriesUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "temporarily down", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryWebhooks{}
+	enqueue(t, repo, receiver.URL, "secret-secret-secret")
+	dispatcher := newTestDispatcher(repo, 3)
+
+	start := time.Now()
+	dispatcher.ProcessBatch(context.Background())
+
+	d := repo.deliveries[0]
+	if d.Status != model.WebhookDeliveryPending || d.Attempts != 1 {
+		t.Fatalf("expected pending delivery after first failure, got %+v", d)
+	}
+	if d.ResponseCode == nil || *d.ResponseCode != http.StatusInternalServerError || d.LastError == "" {
+		t.Errorf("expected logged 500 response, got code=%v error=%q", d.ResponseCode, d.LastError)
+	}
+	if !d.NextAttemptAt.After(start) {
+		t.Errorf("expected next attempt to be scheduled in the future, got %v", d.NextAttemptAt)
+	}
+
+	// Simulate the retry time being reached
+	d.NextAttemptAt = time.Now()
+	dispatcher.ProcessBatch(context.Background())
+
+	if d.Status != model.WebhookDeliverySucceeded || d.Attempts != 2 || d.LastError != "" {
+		t.Errorf("expected delivery to succeed on retry, got %+v", d)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", calls.Load())
+	}
+}
+
+func TestDispatcher_FailsAfterMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryWebhooks{}
+	enqueue(t, repo, receiver.URL, "secret-secret-secret")
+	dispatcher := newTestDispatcher(repo, 2)
+
+	for range 2 {
+		repo.deliveries[0].NextAttemptAt = time.Now()
+		dispatcher.ProcessBatch(context.Background())
+	}
+
+	if d := repo.deliveries[0]; d.Status != model.WebhookDeliveryFailed || d.Attempts != 2 {
+		t.Errorf("expected failed delivery after 2 attempts, got %+v", d)
+	}
+}
+
+func TestDispatcher_SkipsLeasedDeliveries(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{}, 1)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryWebhooks{}
+	enqueue(t, repo, receiver.URL, "secret-secret-secret")
+	dispatcher := NewDispatcher(repo, DispatcherConfig{MaxAttempts: 3})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := dispatcher.ProcessBatch(context.Background())
+		done <- err
+	}()
+	<-received
+
+	// While the first request is in flight the delivery is leased and skipped
+	if n, err := dispatcher.ProcessBatch(context.Background()); err != nil || n != 0 {
+		t.Errorf("expected leased delivery to be skipped, got %d, %v", n, err)
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("ProcessBatch failed: %v", err)
+	}
+	if d := repo.deliveries[0]; d.Status != model.WebhookDeliverySucceeded || d.Attempts != 1 {
+		t.Errorf("expected single successful attempt, got %+v", d)
+	}
+}
+
+func TestDispatcher_StoresTruncatedResponseAsValidText(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A NUL byte and ASCII padding put the first Cyrillic rune across the stored prefix boundary
+		body := "\x00" + strings.Repeat("a", maxResponseBody-2) + "ошибка"
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(body))
+	}))
+	defer receiver.Close()
+
+	repo := &memoryWebhooks{}
+	enqueue(t, repo, receiver.URL, "secret-secret-secret")
+
+	if _, err := newTestDispatcher(repo, 3).ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch failed: %v", err)
+	}
+
+	saved, ok := repo.saved[1]
+	if !ok {
+		t.Fatal("expected delivery result to be saved")
+	}
+	if !utf8.ValidString(saved.ResponseBody) || strings.Contains(saved.ResponseBody, "\x00") {
+		t.Errorf("expected valid text without NUL, got %q", saved.ResponseBody)
+	}
+	if saved.Attempts != 1 || saved.ResponseCode == nil || *saved.ResponseCode != http.StatusBadGateway {
+		t.Errorf("expected logged 502 attempt, got %+v", saved)
+	}
+}
+
+func TestDispatcher_SavesOtherResultsWhenOneUpdateFails(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryWebhooks{failUpdate: 1}
+	repo.Create(context.Background(), &model.Webhook{URL: receiver.URL, Secret: "secret-secret-secret", Active: true})
+	repo.Create(context.Background(), &model.Webhook{URL: receiver.URL, Secret: "secret-secret-secret", Active: true})
+	e := model.NewEvent(model.EventPostCreated, 1, model.EventTarget{Type: "post", ID: 7}, nil)
+	if err := NewSink(repo).Write(context.Background(), []model.Event{e}); err != nil {
+		t.Fatalf("failed to enqueue deliveries: %v", err)
+	}
+
+	n, err := newTestDispatcher(repo, 3).ProcessBatch(context.Background())
+	if n != 2 || err == nil {
+		t.Fatalf("expected 2 processed deliveries and an update error, got %d, %v", n, err)
+	}
+	if saved, ok := repo.saved[2]; !ok || saved.Status != model.WebhookDeliverySucceeded {
+		t.Errorf("expected second delivery result to be saved, got %+v", saved)
+	}
+}
+
+func TestSink_EnqueuesMatchingWebhooks(t *testing.T) {
+	repo := &memoryWebhooks{}
+	repo.Create(context.Background(), &model.Webhook{URL: "http://all", Active: true})
+	repo.Create(context.Background(), &model.Webhook{URL: "http://posts", Events: []string{"post.*"}, Active: true})
+	repo.Create(context.Background(), &model.Webhook{URL: "http://comments", Events: []string{"comment.created"}, Active: true})
+	repo.Create(context.Background(), &model.Webhook{URL: "http://disabled", Active: false})
+
+	events := []model.Event{
+		model.NewEvent(model.EventPostCreated, 1, model.EventTarget{Type: "post", ID: 1}, nil),
+		model.NewEvent(model.EventCommentCreated, 1, model.EventTarget{Type: "comment", ID: 2}, nil),
+	}
+	if err := NewSink(repo).Write(context.Background(), events); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	got := map[int][]model.EventType{}
+	for _, d := range repo.deliveries {
+		got[d.WebhookID] = append(got[d.WebhookID], d.EventType)
+	}
+	if len(got[1]) != 2 || len(got[2]) != 1 || got[2][0] != model.EventPostCreated ||
+		len(got[3]) != 1 || got[3][0] != model.EventCommentCreated || len(got[4]) != 0 {
+		t.Errorf("unexpected deliveries by webhook: %v", got)
+	}
+}
+
+func TestVerify_RejectsTamperedBody(t *testing.T) {
+	ts := time.Now().Unix()
+	sig := Sign("secret", ts, []byte(`{"a":1}`))
+
+	if err := Verify("secret", strconv.FormatInt(ts, 10), sig, []byte(`{"a":2}`), time.Minute); err != ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Unix()
+	if err := Verify("secret", strconv.FormatInt(old, 10), Sign("secret", old, nil), nil, time.Minute); err != ErrExpiredTimestamp {
+		t.Errorf("expected ErrExpiredTimestamp, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"advanced-blog-management-system/internal/model"
+	"advanced-blog-management-system/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Sink - приемник событий outbox, который ставит в очередь доставки для подписанных вебхуков.
+// Сам HTTP-запрос выполняет Dispatcher, поэтому медленный получатель не задерживает outbox
+type Sink struct {
+	repo repository.WebhookRepository
+}
+
+func NewSink(repo repository.WebhookRepository) *Sink {
+	return &Sink{repo: repo}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+func (s *Sink) Write(ctx context.Context, events []model.Event) error {
+	webhooks, err := s.repo.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	var deliveries []*model.WebhookDelivery
+	for _, e := range events {
+		var payload []byte
+		for _, w := range webhooks {
+			if !w.Matches(e.Type) {
+				continue
+			}
+			if payload == nil {
+				if payload, err = json.Marshal(e); err != nil {
+					return fmt.Errorf("failed to encode event %s: %w", e.ID, err)
+				}
+			}
+			deliveries = append(deliveries, &model.WebhookDelivery{
+				WebhookID: w.ID,
+				EventID:   e.ID,
+				EventType: e.Type,
+				Payload:   payload,
+			})
+		}
+	}
+
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,2 @@
+-- Индекс для сортировки ленты по заголовку
+CREATE INDEX IF NOT EXISTS idx_posts_title_id ON posts(title, id);
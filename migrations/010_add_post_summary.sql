@@ -0,0 +1,2 @@
+-- Краткое описание поста для списков; пустое значение заменяется выдержкой из текста
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '';
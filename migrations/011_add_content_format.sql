@@ -0,0 +1,9 @@
+-- Формат текста и отрендеренный HTML постов и комментариев. Для записей, созданных раньше,
+-- content_html пуст и строится при чтении
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS content_format VARCHAR(16) NOT NULL DEFAULT 'plain'
+    CHECK (content_format IN ('plain', 'markdown'));
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_format VARCHAR(16) NOT NULL DEFAULT 'plain'
+    CHECK (content_format IN ('plain', 'markdown'));
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,25 @@
+-- Время последнего изменения поста
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE posts SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE posts ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE posts ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+
+-- История правок постов: каждая версия заголовка и текста с автором правки
+CREATE TABLE IF NOT EXISTS post_revisions (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    content TEXT NOT NULL,
+    content_format VARCHAR(16) NOT NULL DEFAULT 'plain'
+        CHECK (content_format IN ('plain', 'markdown')),
+    editor_id INTEGER NOT NULL,
+    restored_from INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, revision)
+);
+
+-- Существующие посты получают первую версию
+INSERT INTO post_revisions (post_id, revision, title, content, content_format, editor_id, created_at)
+SELECT id, 1, title, content, content_format, author_id, created_at
+FROM posts
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+-- Мягкое удаление: строка с deleted_at скрыта от всех запросов и попадает в корзину.
+-- Зависимые записи помечаются тем же временем, чтобы восстановление вернуло ровно их.
+-- Окончательно строки удаляет фоновая очистка; внешние ключи ON DELETE CASCADE
+-- срабатывают только в этот момент
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Корзина и очистка выбирают только удаленные строки
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Оптимистичная блокировка: каждая правка поста или комментария увеличивает version,
+-- а UPDATE выполняется только при совпадении версии, которую видел клиент
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,25 @@
+-- Модерация комментариев: новые комментарии в режиме модерации получают статус pending
+-- и видны только автору и модераторам, пока их не одобрят
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'approved';
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS moderation_reason TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comments ADD CONSTRAINT comments_status_check CHECK (status IN ('pending', 'approved', 'rejected'));
+
+-- Режим модерации отдельного поста; глобальный режим задается конфигурацией
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS moderate_comments BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Очередь модерации и подсчет одобренных комментариев автора для автоодобрения
+CREATE INDEX IF NOT EXISTS idx_comments_pending ON comments(created_at) WHERE status = 'pending' AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_comments_author_status ON comments(author_id, status);
+
+-- Уведомления пользователей, например о решении модератора по их комментарию
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, id DESC);
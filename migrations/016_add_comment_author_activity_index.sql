@@ -0,0 +1,2 @@
+-- Фильтр спама читает недавние комментарии автора: поиск дубликатов и ограничение новых аккаунтов
+CREATE INDEX IF NOT EXISTS idx_comments_author_created_at ON comments(author_id, created_at DESC);
@@ -0,0 +1,13 @@
+-- Подписки удаленного пользователя уходят в корзину вместе с ним и перестают получать события,
+-- а при окончательном удалении пользователя удаляются каскадом и не блокируют очистку корзины
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE webhooks DROP CONSTRAINT IF EXISTS webhooks_owner_id_fkey;
+
+ALTER TABLE webhooks
+ADD CONSTRAINT fk_webhooks_owner
+FOREIGN KEY (owner_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_owner_id ON webhooks(owner_id);
@@ -0,0 +1,19 @@
+-- Журнал событий потоков (SSE, WebSocket). Номер события выдается счетчиком темы: строка
+-- счетчика остается заблокированной до фиксации транзакции, поэтому номера в теме идут
+-- без пропусков и в порядке фиксации, а клиент возобновляет поток по номеру последнего события
+CREATE TABLE IF NOT EXISTS stream_topics (
+    topic VARCHAR(128) PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS stream_events (
+    topic VARCHAR(128) NOT NULL,
+    seq BIGINT NOT NULL,
+    event VARCHAR(64) NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (topic, seq)
+);
+
+-- Очистка удаляет события старше срока хранения корзины
+CREATE INDEX IF NOT EXISTS idx_stream_events_created_at ON stream_events(created_at);
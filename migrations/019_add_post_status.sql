@@ -0,0 +1,8 @@
+-- Модерация постов: пост, который фильтр спама счел подозрительным при создании или правке,
+-- получает статус pending и не показывается в лентах, потоке и кешах, пока его не одобрят
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published';
+
+ALTER TABLE posts ADD CONSTRAINT posts_status_check CHECK (status IN ('pending', 'published'));
+
+-- Очередь модерации постов
+CREATE INDEX IF NOT EXISTS idx_posts_pending ON posts(updated_at) WHERE status = 'pending' AND deleted_at IS NULL;
@@ -0,0 +1,11 @@
+-- Ретранслятор захватывает пакет арендой и пишет в приемники вне транзакции: пока аренда
+-- не истекла, другие реплики пакет пропускают, а после падения процесса он снова становится доступен
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+-- Приемники, уже принявшие событие. При повторе событие пишется только в оставшиеся приемники
+CREATE TABLE IF NOT EXISTS outbox_sink_deliveries (
+    outbox_id BIGINT NOT NULL REFERENCES outbox(id) ON DELETE CASCADE,
+    sink VARCHAR(64) NOT NULL,
+    delivered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (outbox_id, sink)
+);
@@ -17,6 +17,7 @@ type Claims struct {
 	UserID   int    `json:"user_id"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -35,13 +36,14 @@ func NewJWTManager(secretKey string, ttlHours int) *JWTManager {
 }
 
 // GenerateToken создает новый JWT токен для пользователя
-func (m *JWTManager) GenerateToken(userID int, email, username string) (string, time.Time, error) {
+func (m *JWTManager) GenerateToken(userID int, email, username, role string) (string, time.Time, error) {
 	// 1. Создать Claims с данными пользователя
 	expiredAt := time.Now().Add(m.ttl)
 	claims := &Claims{
 		UserID:   userID,
 		Email:    email,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiredAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -12,7 +12,7 @@ func TestJWTManager_GenerateToken(t *testing.T) {
 	email := "test@example.com"
 	username := "testuser"
 
-	token, expiresAt, err := manager.GenerateToken(userID, email, username)
+	token, expiresAt, err := manager.GenerateToken(userID, email, username, "user")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -33,7 +33,7 @@ func TestJWTManager_ValidateToken_Success(t *testing.T) {
 	email := "test@example.com"
 	username := "testuser"
 
-	token, _, err := manager.GenerateToken(userID, email, username)
+	token, _, err := manager.GenerateToken(userID, email, username, "admin")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
@@ -54,6 +54,10 @@ func TestJWTManager_ValidateToken_Success(t *testing.T) {
 	if claims.Username != username {
 		t.Errorf("expected Username %s, got %s", username, claims.Username)
 	}
+
+	if claims.Role != "admin" {
+		t.Errorf("expected Role admin, got %s", claims.Role)
+	}
 }
 
 func TestJWTManager_ValidateToken_InvalidToken(t *testing.T) {
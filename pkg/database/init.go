@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -59,8 +60,8 @@ func Close(db *sql.DB) {
 	}
 }
 
-// TestConnection выполняет тестовый запрос к БД
-func TestConnection(db *sql.DB) error {
+// TestConnection выполняет тестовый запрос к БД с учетом таймаута контекста
+func TestConnection(ctx context.Context, db *sql.DB) error {
 	var result int
-	return db.QueryRow("SELECT 1").Scan(&result)
+	return db.QueryRowContext(ctx, "SELECT 1").Scan(&result)
 }
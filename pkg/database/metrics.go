@@ -0,0 +1,13 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// NewStatsCollector возвращает коллектор Prometheus со статистикой пула соединений sql.DB.Stats()
+func NewStatsCollector(db *sql.DB, dbName string) prometheus.Collector {
+	return collectors.NewDBStatsCollector(db, dbName)
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -11,31 +12,18 @@ import (
 	"strings"
 )
 
+// migrationsDir - папка с SQL-миграциями относительно корня проекта
+const migrationsDir = "migrations"
+
 // Migrate применяет SQL-миграции из папки migrations
 func Migrate(db *sql.DB) error {
-	migrationsDir := "migrations"
 	log.Printf("Looking for migrations in: %s", migrationsDir)
 
-	files, err := os.ReadDir(migrationsDir)
+	migrationFiles, err := listMigrations()
 	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
-	}
-
-	// Фильтруем и сортируем миграции
-	var migrationFiles []string
-	migrationPattern := regexp.MustCompile(`^\d+_.+\.sql$`)
-
-	for _, file := range files {
-		if !file.IsDir() && migrationPattern.MatchString(file.Name()) {
-			migrationFiles = append(migrationFiles, file.Name())
-		}
+		return err
 	}
 
-	// Сортируем миграции по номеру
-	sort.Slice(migrationFiles, func(i, j int) bool {
-		return extractMigrationNumber(migrationFiles[i]) < extractMigrationNumber(migrationFiles[j])
-	})
-
 	// Проверяем, нужно ли применять миграции
 	for _, file := range migrationFiles {
 		log.Printf("Processing migration: %s", file)
@@ -93,6 +81,72 @@ func Migrate(db *sql.DB) error {
 	return nil
 }
 
+// PendingMigrations возвращает миграции из папки migrations, которые еще не применены к БД
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]string, error) {
+	migrationFiles, err := listMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]bool)
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		// Таблицы еще нет - значит, не применена ни одна миграция
+		if strings.Contains(err.Error(), "does not exist") {
+			return migrationFiles, nil
+		}
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate applied migrations: %w", err)
+	}
+
+	var pending []string
+	for _, file := range migrationFiles {
+		if !applied[file] {
+			pending = append(pending, file)
+		}
+	}
+	return pending, nil
+}
+
+// listMigrations возвращает имена файлов миграций, отсортированные по номеру
+func listMigrations() ([]string, error) {
+	files, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	// Фильтруем и сортируем миграции
+	var migrationFiles []string
+	migrationPattern := regexp.MustCompile(`^\d+_.+\.sql$`)
+
+	for _, file := range files {
+		if !file.IsDir() && migrationPattern.MatchString(file.Name()) {
+			migrationFiles = append(migrationFiles, file.Name())
+		}
+	}
+
+	// Сортируем миграции по номеру
+	sort.Slice(migrationFiles, func(i, j int) bool {
+		return extractMigrationNumber(migrationFiles[i]) < extractMigrationNumber(migrationFiles[j])
+	})
+
+	return migrationFiles, nil
+}
+
+// dollarQuoteTag находит открывающий тег dollar-quoted строки PostgreSQL ($$ или $tag$)
+var dollarQuoteTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
 // splitSQLQueries разбивает SQL-скрипт на отдельные запросы
 func splitSQLQueries(content string) []string {
 	var queries []string
@@ -131,6 +185,21 @@ func splitSQLQueries(content string) []string {
 			continue
 		}
 
+		// Тела функций в $$...$$ или $tag$...$tag$ копируем целиком: внутри них есть ';'
+		if !inString && char == '$' {
+			if tag := dollarQuoteTag.FindString(content[i:]); tag != "" {
+				end := strings.Index(content[i+len(tag):], tag)
+				if end < 0 {
+					end = len(content) - i - len(tag)
+				} else {
+					end += len(tag)
+				}
+				currentQuery.WriteString(content[i : i+len(tag)+end])
+				i += len(tag) + end - 1
+				continue
+			}
+		}
+
 		// Обработка строк
 		if char == '\'' || char == '"' {
 			if !inString {
@@ -0,0 +1,29 @@
+package database
+
+import "testing"
+
+func TestSplitSQLQueries(t *testing.T) {
+	content := `
+-- комментарий; с точкой с запятой
+CREATE TABLE t (id INT, note TEXT DEFAULT 'a;b');
+
+CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'no; way';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION g() RETURNS void AS $body$ SELECT 1; $body$ LANGUAGE sql;
+SELECT $1`
+
+	queries := splitSQLQueries(content)
+	if len(queries) != 4 {
+		t.Fatalf("expected 4 queries, got %d: %q", len(queries), queries)
+	}
+	if want := "$$ LANGUAGE plpgsql"; queries[1][len(queries[1])-len(want):] != want {
+		t.Errorf("function body was split: %q", queries[1])
+	}
+	if queries[3] != "SELECT $1" {
+		t.Errorf("expected placeholder to be kept, got %q", queries[3])
+	}
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxNotifyPayload - предельный размер payload NOTIFY в Postgres (меньше 8000 байт)
+const MaxNotifyPayload = 7999
+
+// ErrPayloadTooLarge - сообщение не помещается в NOTIFY
+var ErrPayloadTooLarge = errors.New("notify payload too large")
+
+// Notifier отправляет уведомления через pg_notify. Вызов вне транзакции доставляется сразу,
+// поэтому отправлять его нужно после фиксации изменений
+type Notifier struct {
+	db *sql.DB
+}
+
+func NewNotifier(db *sql.DB) *Notifier {
+	return &Notifier{db: db}
+}
+
+// Notify отправляет payload всем соединениям, выполнившим LISTEN на канале
+func (n *Notifier) Notify(ctx context.Context, channel string, payload []byte) error {
+	if len(payload) > MaxNotifyPayload {
+		return fmt.Errorf("failed to notify %s: %w (%d bytes)", channel, ErrPayloadTooLarge, len(payload))
+	}
+	if _, err := n.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify %s: %w", channel, err)
+	}
+	return nil
+}
+
+// ListenerConfig содержит настройки выделенного соединения LISTEN
+type ListenerConfig struct {
+	// MinReconnect и MaxReconnect - пределы экспоненциальной задержки переподключения
+	MinReconnect time.Duration
+	MaxReconnect time.Duration
+	// PingInterval - как часто проверять соединение, если уведомлений нет
+	PingInterval time.Duration
+}
+
+// Listener держит отдельное соединение с LISTEN на зарегистрированных каналах и вызывает
+// обработчики для каждого уведомления. Соединение восстанавливается автоматически; уведомления,
+// отправленные пока его не было, теряются, поэтому после переподключения вызываются
+// обработчики OnReconnect (например, для сброса локальных кешей)
+type Listener struct {
+	dsn string
+	cfg ListenerConfig
+
+	mu          sync.Mutex
+	handlers    map[string][]func(payload []byte)
+	onReconnect []func()
+
+	pq        *pq.Listener
+	connected atomic.Bool
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+func NewListener(dsn string, cfg ListenerConfig) *Listener {
+	if cfg.MinReconnect <= 0 {
+		cfg.MinReconnect = time.Second
+	}
+	if cfg.MaxReconnect <= 0 {
+		cfg.MaxReconnect = time.Minute
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 90 * time.Second
+	}
+	return &Listener{
+		dsn:      dsn,
+		cfg:      cfg,
+		handlers: make(map[string][]func(payload []byte)),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Handle регистрирует обработчик канала; вызывается до Start.
+// Обработчики выполняются последовательно в горутине слушателя и не должны блокироваться
+func (l *Listener) Handle(channel string, fn func(payload []byte)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers[channel] = append(l.handlers[channel], fn)
+}
+
+// OnReconnect регистрирует функцию, вызываемую после восстановления соединения
+func (l *Listener) OnReconnect(fn func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onReconnect = append(l.onReconnect, fn)
+}
+
+// Start открывает соединение, подписывается на каналы и запускает обработку уведомлений
+func (l *Listener) Start() error {
+	l.pq = pq.NewListener(l.dsn, l.cfg.MinReconnect, l.cfg.MaxReconnect, l.event)
+
+	l.mu.Lock()
+	channels := make([]string, 0, len(l.handlers))
+	for channel := range l.handlers {
+		channels = append(channels, channel)
+	}
+	l.mu.Unlock()
+
+	for _, channel := range channels {
+		if err := l.pq.Listen(channel); err != nil {
+			l.pq.Close()
+			return fmt.Errorf("failed to listen on %s: %w", channel, err)
+		}
+	}
+	l.connected.Store(true)
+
+	go l.run()
+	return nil
+}
+
+// Connected сообщает, есть ли сейчас соединение с сервером
+func (l *Listener) Connected() bool {
+	return l.connected.Load()
+}
+
+// Stop закрывает соединение и дожидается завершения обработчиков
+func (l *Listener) Stop() {
+	l.once.Do(func() {
+		close(l.stop)
+		if l.pq == nil {
+			return
+		}
+		<-l.done
+		if err := l.pq.Close(); err != nil {
+			slog.Error("failed to close listener", "error", err)
+		}
+		slog.Info("database listener stopped gracefully")
+	})
+}
+
+func (l *Listener) event(ev pq.ListenerEventType, err error) {
+	switch ev {
+	case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+		l.connected.Store(true)
+	case pq.ListenerEventDisconnected:
+		l.connected.Store(false)
+		slog.Warn("database listener disconnected", "error", err)
+	case pq.ListenerEventConnectionAttemptFailed:
+		slog.Warn("database listener reconnect failed", "error", err)
+	}
+}
+
+func (l *Listener) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			// Ping выявляет оборванное соединение, когда уведомлений долго нет
+			go func() {
+				if err := l.pq.Ping(); err != nil {
+					slog.Warn("database listener ping failed", "error", err)
+				}
+			}()
+		case n := <-l.pq.Notify:
+			// nil приходит после переподключения: часть уведомлений могла быть потеряна
+			if n == nil {
+				slog.Info("database listener reconnected")
+				l.mu.Lock()
+				hooks := l.onReconnect
+				l.mu.Unlock()
+				for _, fn := range hooks {
+					fn()
+				}
+				continue
+			}
+
+			l.mu.Lock()
+			handlers := l.handlers[n.Channel]
+			l.mu.Unlock()
+			for _, fn := range handlers {
+				fn([]byte(n.Extra))
+			}
+		}
+	}
+}
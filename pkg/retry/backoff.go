@@ -0,0 +1,22 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff возвращает задержку перед попыткой номер attempt (начиная с 1):
+// base * 2^(attempt-1), но не больше max, со случайным разбросом в нижнюю половину,
+// чтобы несколько реплик не повторяли запросы одновременно
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	d := max
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt < 32 {
+		if exp := base << (attempt - 1); exp > 0 && exp < d {
+			d = exp
+		}
+	}
+	return d/2 + rand.N(d/2+1)
+}
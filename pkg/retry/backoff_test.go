@@ -0,0 +1,25 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{10, 30 * time.Second, time.Minute},
+		{100, 30 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		d := Backoff(time.Second, time.Minute, tt.attempt)
+		if d < tt.min || d > tt.max {
+			t.Errorf("Backoff(attempt=%d) = %v, expected within [%v, %v]", tt.attempt, d, tt.min, tt.max)
+		}
+	}
+}